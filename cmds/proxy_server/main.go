@@ -48,6 +48,12 @@ type flags struct {
 	tfChainURL       string
 	relayURL         string
 	mnemonics        string
+	nodeUpInterval   time.Duration
+	standbyInterval  time.Duration
+	statsInterval    time.Duration
+	eventsInterval   time.Duration
+	changesInterval  time.Duration
+	changesRetention time.Duration
 }
 
 func main() {
@@ -68,6 +74,12 @@ func main() {
 	flag.StringVar(&f.tfChainURL, "tfchain-url", DefaultTFChainURL, "TF chain url")
 	flag.StringVar(&f.relayURL, "relay-url", DefaultRelayURL, "RMB relay url")
 	flag.StringVar(&f.mnemonics, "mnemonics", "", "Dummy user mnemonics for relay calls")
+	flag.DurationVar(&f.nodeUpInterval, "node-up-interval", db.DefaultNodeStatusConfig().UpInterval, "max time since the last report of an up node")
+	flag.DurationVar(&f.standbyInterval, "node-standby-interval", db.DefaultNodeStatusConfig().StandbyInterval, "max time since the last report of a node powered off by the farmerbot to be in standby")
+	flag.DurationVar(&f.statsInterval, "stats-snapshot-interval", explorer.DefaultStatsSnapshotInterval, "interval to store the grid stats history at, 0 disables the history")
+	flag.DurationVar(&f.eventsInterval, "events-poll-interval", explorer.DefaultEventsPollInterval, "interval to poll the nodes, contracts and public ips at to detect their changes, 0 disables the node events and webhooks")
+	flag.DurationVar(&f.changesInterval, "changes-poll-interval", 0, "interval to poll the nodes, farms, twins and contracts at to log their changes (e.g. 1m), every poll scans the tables so it's off by default and only one instance sharing the database needs it")
+	flag.DurationVar(&f.changesRetention, "changes-retention", explorer.DefaultChangesRetention, "age of the logged changes to prune, 0 keeps all the changes")
 	flag.Parse()
 
 	// shows version and exit
@@ -102,7 +114,7 @@ func main() {
 		log.Fatal().Err(err).Msg("failed to create realy client")
 	}
 
-	s, err := createServer(f, GitCommit, relayClient)
+	s, err := createServer(ctx, f, GitCommit, relayClient, explorer.NewChainPriceSource(sub))
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create mux server")
 	}
@@ -173,17 +185,33 @@ func createRMBClient(ctx context.Context, relayURL, mnemonics string, sub *subst
 	return client, nil
 }
 
-func createServer(f flags, gitCommit string, relayClient rmb.Client) (*http.Server, error) {
+func createServer(ctx context.Context, f flags, gitCommit string, relayClient rmb.Client, priceSource explorer.PriceSource) (*http.Server, error) {
 	log.Info().Msg("Creating server")
 
 	router := mux.NewRouter().StrictSlash(true)
-	db, err := db.NewPostgresDatabase(f.postgresHost, f.postgresPort, f.postgresUser, f.postgresPassword, f.postgresDB)
+	nodeStatus := db.NodeStatusConfig{
+		UpInterval:      f.nodeUpInterval,
+		StandbyInterval: f.standbyInterval,
+	}
+	db, err := db.NewPostgresDatabase(f.postgresHost, f.postgresPort, f.postgresUser, f.postgresPassword, f.postgresDB, nodeStatus)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't get postgres client")
 	}
+	if f.statsInterval > 0 {
+		go explorer.NewStatsSnapshotter(db, f.statsInterval).Run(ctx)
+	}
+	if f.changesInterval > 0 {
+		go explorer.NewChangeTracker(db, f.changesInterval, f.changesRetention).Run(ctx)
+	}
+	var nodeEvents *explorer.NodeEvents
+	if f.eventsInterval > 0 {
+		nodeEvents = explorer.NewNodeEvents(db, f.eventsInterval)
+		go nodeEvents.Run(ctx)
+		go explorer.NewWebhookDispatcher(db, nodeEvents, f.eventsInterval).Run(ctx)
+	}
 
 	// setup explorer
-	if err := explorer.Setup(router, gitCommit, db, relayClient); err != nil {
+	if err := explorer.Setup(router, gitCommit, db, relayClient, priceSource, nodeEvents); err != nil {
 		return nil, err
 	}
 
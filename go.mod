@@ -3,6 +3,7 @@ module github.com/threefoldtech/grid_proxy_server
 go 1.17
 
 require (
+	github.com/centrifuge/go-substrate-rpc-client/v4 v4.0.5
 	github.com/go-acme/lego/v4 v4.4.0
 	github.com/gorilla/mux v1.8.0
 	github.com/lib/pq v1.10.4
@@ -62,7 +63,6 @@ require (
 )
 
 require (
-	github.com/centrifuge/go-substrate-rpc-client/v4 v4.0.5 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.1.0 // indirect
 	github.com/golang-jwt/jwt v3.2.2+incompatible // indirect
@@ -0,0 +1,144 @@
+package explorer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+const (
+	// DefaultChangesRetention is the default age of the logged changes to prune
+	DefaultChangesRetention = 30 * 24 * time.Hour
+	// changesDefaultSize is the number of changes returned if no size is given
+	changesDefaultSize = 500
+	// changesMaxSize is the max number of changes returned at once
+	changesMaxSize = 5000
+)
+
+// ChangeTracker logs the nodes, farms, twins and contracts created or modified between database polls
+// to serve the changes after a checkpoint
+type ChangeTracker struct {
+	db        db.Database
+	interval  time.Duration
+	retention time.Duration
+}
+
+// NewChangeTracker creates a new change tracker polling the database every interval, and pruning the
+// changes older than the retention, 0 retention keeps all the changes
+func NewChangeTracker(database db.Database, interval time.Duration, retention time.Duration) *ChangeTracker {
+	return &ChangeTracker{db: database, interval: interval, retention: retention}
+}
+
+// Run logs the changes right away then every interval until the context is canceled
+func (c *ChangeTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		if count, err := c.db.RecordChanges(now.Unix()); err != nil {
+			log.Error().Err(err).Msg("failed to record changes")
+		} else {
+			log.Debug().Int64("changes", count).Msg("recorded changes")
+		}
+		if c.retention > 0 {
+			if count, err := c.db.PruneChanges(now.Add(-c.retention).Unix()); err != nil {
+				log.Error().Err(err).Msg("failed to prune changes")
+			} else {
+				log.Debug().Int64("changes", count).Msg("pruned changes")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// changesAfter returns the objects changed after the checkpoint, the objects changed more than
+// once are returned once
+func (a *App) changesAfter(since uint64, size uint64) (types.Changes, error) {
+	res := types.Changes{
+		Checkpoint: fmt.Sprint(since),
+		Nodes:      []types.Node{},
+		Farms:      []types.Farm{},
+		Twins:      []types.Twin{},
+		Contracts:  []types.Contract{},
+	}
+	// get one more change to know if there are more
+	changes, err := a.db.GetChanges(since, size+1)
+	if err != nil {
+		return res, err
+	}
+	if uint64(len(changes)) > size {
+		res.More = true
+		changes = changes[:size]
+	}
+	if len(changes) == 0 {
+		return res, nil
+	}
+	res.Checkpoint = fmt.Sprint(changes[len(changes)-1].ID)
+
+	ids := make(map[string][]uint64)
+	seen := make(map[db.Change]struct{})
+	for _, change := range changes {
+		key := db.Change{Kind: change.Kind, ObjectID: change.ObjectID}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		ids[change.Kind] = append(ids[change.Kind], change.ObjectID)
+	}
+	limit := func(ids []uint64) types.Limit {
+		return types.Limit{Page: 1, Size: uint64(len(ids))}
+	}
+
+	if nodeIDs := ids[db.ChangeNode]; len(nodeIDs) != 0 {
+		dbNodes, _, err := a.db.GetNodes(types.NodeFilter{NodeIDs: nodeIDs}, limit(nodeIDs))
+		if err != nil {
+			return res, errors.Wrap(err, "couldn't get changed nodes")
+		}
+		for _, node := range dbNodes {
+			res.Nodes = append(res.Nodes, nodeFromDBNode(node))
+		}
+	}
+	if farmIDs := ids[db.ChangeFarm]; len(farmIDs) != 0 {
+		dbFarms, _, err := a.db.GetFarms(types.FarmFilter{FarmIDs: farmIDs}, limit(farmIDs))
+		if err != nil {
+			return res, errors.Wrap(err, "couldn't get changed farms")
+		}
+		for _, farm := range dbFarms {
+			f, err := farmFromDBFarm(farm)
+			if err != nil {
+				log.Err(err).Msg("couldn't convert db farm to api farm")
+			}
+			res.Farms = append(res.Farms, f)
+		}
+	}
+	if twinIDs := ids[db.ChangeTwin]; len(twinIDs) != 0 {
+		twins, _, err := a.db.GetTwins(types.TwinFilter{TwinIDs: twinIDs}, limit(twinIDs))
+		if err != nil {
+			return res, errors.Wrap(err, "couldn't get changed twins")
+		}
+		res.Twins = append(res.Twins, twins...)
+	}
+	if contractIDs := ids[db.ChangeContract]; len(contractIDs) != 0 {
+		dbContracts, _, err := a.db.GetContracts(types.ContractFilter{ContractIDs: contractIDs}, limit(contractIDs))
+		if err != nil {
+			return res, errors.Wrap(err, "couldn't get changed contracts")
+		}
+		for _, contract := range dbContracts {
+			c, err := contractFromDBContract(contract)
+			if err != nil {
+				log.Err(err).Msg("failed to convert db contract to api contract")
+			}
+			res.Contracts = append(res.Contracts, c)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,197 @@
+package explorer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+func changesDatabase() *fakeDatabase {
+	return &fakeDatabase{
+		nodes:     []db.Node{{NodeID: 1}, {NodeID: 2}, {NodeID: 3}},
+		farms:     []db.Farm{{FarmID: 1, Name: "farm1"}, {FarmID: 2, Name: "farm2"}},
+		twins:     []types.Twin{{TwinID: 7}, {TwinID: 8}},
+		contracts: []db.DBContract{{ContractID: 10, Type: "rent", NodeID: 1}, {ContractID: 11, Type: "name", Name: "name"}},
+		changes: []db.Change{
+			{ID: 1, Kind: db.ChangeNode, ObjectID: 1},
+			{ID: 2, Kind: db.ChangeFarm, ObjectID: 2},
+			{ID: 3, Kind: db.ChangeNode, ObjectID: 3},
+			{ID: 4, Kind: db.ChangeNode, ObjectID: 1},
+			{ID: 5, Kind: db.ChangeTwin, ObjectID: 7},
+			{ID: 6, Kind: db.ChangeContract, ObjectID: 11},
+			{ID: 7, Kind: db.ChangeContract, ObjectID: 11},
+			// the object ids are per kind, the farm 1 isn't the node 1
+			{ID: 9, Kind: db.ChangeFarm, ObjectID: 1},
+		},
+	}
+}
+
+// changedIDs returns the ids of the changed nodes, farms, twins and contracts
+func changedIDs(changes types.Changes) [][]int {
+	ids := make([][]int, 4)
+	for _, node := range changes.Nodes {
+		ids[0] = append(ids[0], node.NodeID)
+	}
+	for _, farm := range changes.Farms {
+		ids[1] = append(ids[1], farm.FarmID)
+	}
+	for _, twin := range changes.Twins {
+		ids[2] = append(ids[2], int(twin.TwinID))
+	}
+	for _, contract := range changes.Contracts {
+		ids[3] = append(ids[3], int(contract.ContractID))
+	}
+	return ids
+}
+
+func TestChangesAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		since      uint64
+		size       uint64
+		checkpoint string
+		more       bool
+		ids        [][]int
+	}{
+		{
+			name:       "all changes",
+			since:      0,
+			size:       100,
+			checkpoint: "9",
+			ids:        [][]int{{1, 3}, {1, 2}, {7}, {11}},
+		},
+		{
+			name:       "first page",
+			since:      0,
+			size:       3,
+			checkpoint: "3",
+			more:       true,
+			ids:        [][]int{{1, 3}, {2}, nil, nil},
+		},
+		{
+			name:       "page with duplicates",
+			since:      3,
+			size:       4,
+			checkpoint: "7",
+			more:       true,
+			ids:        [][]int{{1}, nil, {7}, {11}},
+		},
+		{
+			name:       "exact last page",
+			since:      7,
+			size:       1,
+			checkpoint: "9",
+			ids:        [][]int{nil, {1}, nil, nil},
+		},
+		{
+			name:       "no changes",
+			since:      9,
+			size:       10,
+			checkpoint: "9",
+			ids:        [][]int{nil, nil, nil, nil},
+		},
+		{
+			name:       "checkpoint between change ids",
+			since:      8,
+			size:       10,
+			checkpoint: "9",
+			ids:        [][]int{nil, {1}, nil, nil},
+		},
+	}
+	for _, test := range tests {
+		database := changesDatabase()
+		changes, err := testApp(database, nil).changesAfter(test.since, test.size)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err.Error())
+		}
+		if changes.Checkpoint != test.checkpoint {
+			t.Fatalf("%s: checkpoint mismatch: expected: %s, found: %s", test.name, test.checkpoint, changes.Checkpoint)
+		}
+		if changes.More != test.more {
+			t.Fatalf("%s: more mismatch: expected: %t, found: %t", test.name, test.more, changes.More)
+		}
+		if ids := changedIDs(changes); !reflect.DeepEqual(ids, test.ids) {
+			t.Fatalf("%s: changed ids mismatch: expected: %v, found: %v", test.name, test.ids, ids)
+		}
+		if changes.Nodes == nil || changes.Farms == nil || changes.Twins == nil || changes.Contracts == nil {
+			t.Fatalf("%s: the changes lists should be empty not null: %+v", test.name, changes)
+		}
+		if test.ids[0] == nil && database.queries["GetNodes"] != 0 {
+			t.Fatalf("%s: nodes queried without node changes", test.name)
+		}
+	}
+}
+
+func TestChangesAfterFollowsCheckpoints(t *testing.T) {
+	app := testApp(changesDatabase(), nil)
+	var since uint64
+	seen := make(map[string]int)
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatalf("the checkpoints don't advance")
+		}
+		changes, err := app.changesAfter(since, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+		for _, node := range changes.Nodes {
+			seen[fmt.Sprintf("%s-%d", db.ChangeNode, node.NodeID)]++
+		}
+		next, err := strconv.ParseUint(changes.Checkpoint, 10, 64)
+		if err != nil {
+			t.Fatalf("invalid checkpoint %s: %s", changes.Checkpoint, err.Error())
+		}
+		if next < since || (changes.More && next == since) {
+			t.Fatalf("checkpoint mismatch: expected a checkpoint after %d, found: %d", since, next)
+		}
+		since = next
+		if !changes.More {
+			break
+		}
+	}
+	if since != 9 {
+		t.Fatalf("last checkpoint mismatch: expected: 9, found: %d", since)
+	}
+	// the node 1 changed in 2 pages so it's returned twice
+	expected := map[string]int{"node-1": 2, "node-3": 1}
+	if !reflect.DeepEqual(seen, expected) {
+		t.Fatalf("node changes mismatch: expected: %v, found: %v", expected, seen)
+	}
+}
+
+func TestChangeTrackerPrunes(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		pruned    bool
+	}{
+		{name: "retention", retention: 24 * time.Hour, pruned: true},
+		{name: "no retention", retention: 0, pruned: false},
+	}
+	for _, test := range tests {
+		database := &fakeDatabase{}
+		ctx, cancel := context.WithCancel(context.Background())
+		// the tracker records the changes right away then returns
+		cancel()
+		NewChangeTracker(database, time.Hour, test.retention).Run(ctx)
+		if len(database.recorded) != 1 {
+			t.Fatalf("%s: runs mismatch: expected: 1, found: %d", test.name, len(database.recorded))
+		}
+		if !test.pruned {
+			if len(database.pruned) != 0 {
+				t.Fatalf("%s: changes pruned without retention", test.name)
+			}
+			continue
+		}
+		expected := []int64{database.recorded[0] - int64(test.retention.Seconds())}
+		if !reflect.DeepEqual(database.pruned, expected) {
+			t.Fatalf("%s: prune mismatch: expected: %v, found: %v", test.name, expected, database.pruned)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package explorer
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+)
+
+const (
+	// compareMinNodes is the min number of nodes to compare
+	compareMinNodes = 2
+	// compareMaxNodes is the max number of nodes to compare
+	compareMaxNodes = 10
+)
+
+// compareNodeFields are the node fields queried to compare the nodes
+var compareNodeFields = []string{"nodeId", "farmId", "twinId", "status", "uptime", "location", "total_resources", "used_resources", "certificationType", "dedicated", "rentedByTwinId"}
+
+const (
+	// compareHigher marks the attributes with the highest value as the best
+	compareHigher = iota
+	// compareLower marks the attributes with the lowest value as the best
+	compareLower
+	// compareNone marks no value as the best
+	compareNone
+)
+
+// comparedAttribute is an attribute of the comparison table with the value of a node and how the values are compared.
+// the values of the attributes with a best value are float64, or bool with true as the best value. the unknown values
+// are nil and they're never the best
+type comparedAttribute struct {
+	name    string
+	compare int
+	value   func(node types.ComparedNode) interface{}
+}
+
+var comparedAttributes = []comparedAttribute{
+	{"total_cru", compareHigher, func(n types.ComparedNode) interface{} { return float64(n.TotalResources.CRU) }},
+	{"total_mru", compareHigher, func(n types.ComparedNode) interface{} { return float64(n.TotalResources.MRU) }},
+	{"total_sru", compareHigher, func(n types.ComparedNode) interface{} { return float64(n.TotalResources.SRU) }},
+	{"total_hru", compareHigher, func(n types.ComparedNode) interface{} { return float64(n.TotalResources.HRU) }},
+	{"free_cru", compareHigher, func(n types.ComparedNode) interface{} { return float64(n.FreeResources.CRU) }},
+	{"free_mru", compareHigher, func(n types.ComparedNode) interface{} { return float64(n.FreeResources.MRU) }},
+	{"free_sru", compareHigher, func(n types.ComparedNode) interface{} { return float64(n.FreeResources.SRU) }},
+	{"free_hru", compareHigher, func(n types.ComparedNode) interface{} { return float64(n.FreeResources.HRU) }},
+	{"uptime", compareHigher, func(n types.ComparedNode) interface{} { return float64(n.Uptime) }},
+	{"up", compareHigher, func(n types.ComparedNode) interface{} { return n.Status == db.NodeUp }},
+	{"available", compareHigher, func(n types.ComparedNode) interface{} { return n.Available }},
+	{"rentable", compareHigher, func(n types.ComparedNode) interface{} { return n.Rentable }},
+	{"rented_by_twin_id", compareNone, func(n types.ComparedNode) interface{} { return n.RentedByTwinID }},
+	{"country", compareNone, func(n types.ComparedNode) interface{} { return n.Location.Country }},
+	{"city", compareNone, func(n types.ComparedNode) interface{} { return n.Location.City }},
+	{"certified", compareHigher, func(n types.ComparedNode) interface{} { return n.CertificationType == "Certified" }},
+	{"farm_certification_type", compareNone, func(n types.ComparedNode) interface{} { return n.FarmCertificationType }},
+	{"dedicated", compareNone, func(n types.ComparedNode) interface{} { return n.Dedicated }},
+	{"hourly_price_usd", compareLower, priced(func(n types.ComparedNode) float64 { return n.Price.HourlyUSD })},
+	{"monthly_price_usd", compareLower, priced(func(n types.ComparedNode) float64 { return n.Price.MonthlyUSD })},
+	{"monthly_price_tft", compareLower, priced(func(n types.ComparedNode) float64 { return n.Price.MonthlyTFT })},
+	{"hourly_price_per_core_usd", compareLower, priced(func(n types.ComparedNode) float64 { return n.PricePerCore.HourlyUSD })},
+	{"hourly_price_per_gb_usd", compareLower, priced(func(n types.ComparedNode) float64 { return n.PricePerGB.HourlyUSD })},
+}
+
+// priced returns the price of a node, it's unknown if the node has no pricing policy or no resources for the price per unit
+func priced(price func(node types.ComparedNode) float64) func(node types.ComparedNode) interface{} {
+	return func(node types.ComparedNode) interface{} {
+		if value := price(node); node.PricingPolicyID != 0 && value != 0 {
+			return value
+		}
+		return nil
+	}
+}
+
+// nodeIDsParam returns the ids of the nodes to compare in the node_ids query parameter
+func nodeIDsParam(r *http.Request) ([]uint64, error) {
+	var nodeIDs []uint64
+	if err := parseParams(r, nil, nil, nil, map[string]*[]uint64{"node_ids": &nodeIDs}); err != nil {
+		return nil, err
+	}
+	var errs paramErrors
+	if isStrict(r) {
+		errs.add(validateParams(r, []string{"node_ids"}, nil))
+	}
+	if len(nodeIDs) < compareMinNodes || len(nodeIDs) > compareMaxNodes {
+		errs.add(paramError("node_ids", "number of nodes must be between %d and %d", compareMinNodes, compareMaxNodes))
+	}
+	seen := make(map[uint64]struct{}, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		if _, ok := seen[nodeID]; ok {
+			errs.add(paramError("node_ids", "node %d is repeated", nodeID))
+			break
+		}
+		seen[nodeID] = struct{}{}
+	}
+	return nodeIDs, errs.err()
+}
+
+// nodeComparison returns the comparison of the nodes in the given order
+func (a *App) nodeComparison(nodeIDs []uint64) (types.NodeComparison, error) {
+	comparison := types.NodeComparison{}
+	filter := types.NodeFilter{NodeIDs: nodeIDs}
+	limit := types.Limit{Page: 1, Size: uint64(len(nodeIDs))}
+	dbNodes, _, err := a.db.GetNodes(filter, limit, compareNodeFields...)
+	if err != nil {
+		return comparison, errors.Wrap(err, "couldn't get nodes")
+	}
+	rentable := true
+	filter.Rentable = &rentable
+	rentableNodes, _, err := a.db.GetNodes(filter, limit, "nodeId")
+	if err != nil {
+		return comparison, errors.Wrap(err, "couldn't get rentable nodes")
+	}
+	farms, err := a.nodesFarms(dbNodes)
+	if err != nil {
+		return comparison, err
+	}
+	policies, tftPrice, err := a.rentPricing()
+	if err != nil {
+		return comparison, err
+	}
+
+	isRentable := make(map[int64]bool, len(rentableNodes))
+	for _, node := range rentableNodes {
+		isRentable[node.NodeID] = true
+	}
+	nodes := make(map[uint64]types.ComparedNode, len(dbNodes))
+	for _, dbNode := range dbNodes {
+		node := nodeFromDBNode(dbNode)
+		farm := farms[node.FarmID]
+		compared := types.ComparedNode{
+			NodeID:                node.NodeID,
+			FarmID:                node.FarmID,
+			FarmName:              farm.Name,
+			Status:                node.Status,
+			Uptime:                node.Uptime,
+			TotalResources:        node.TotalResources,
+			FreeResources:         freeResources(node.TotalResources, node.UsedResources),
+			Location:              node.Location,
+			CertificationType:     node.CertificationType,
+			FarmCertificationType: farm.Certification,
+			Dedicated:             node.Dedicated,
+			RentedByTwinID:        node.RentedByTwinID,
+			Rentable:              isRentable[dbNode.NodeID],
+			Available:             node.Status == db.NodeUp && node.RentedByTwinID == 0,
+		}
+		if policy, ok := policies[uint32(farm.PricingPolicyID)]; ok {
+			priced := newRentableNode(node, farm, policy, tftPrice)
+			compared.PricingPolicyID = priced.PricingPolicyID
+			compared.Price = priced.Price
+			compared.PricePerCore = priced.PricePerCore
+			compared.PricePerGB = priced.PricePerGB
+		}
+		nodes[uint64(node.NodeID)] = compared
+	}
+	var missing []string
+	for _, nodeID := range nodeIDs {
+		node, ok := nodes[nodeID]
+		if !ok {
+			missing = append(missing, fmt.Sprint(nodeID))
+			continue
+		}
+		comparison.Nodes = append(comparison.Nodes, node)
+	}
+	if len(missing) != 0 {
+		return comparison, types.NewError(types.ErrCodeNodeNotFound, fmt.Sprintf("nodes %s not found", strings.Join(missing, ", ")))
+	}
+	for _, attribute := range comparedAttributes {
+		comparison.Attributes = append(comparison.Attributes, compareAttribute(comparison.Nodes, attribute))
+	}
+	return comparison, nil
+}
+
+// compareAttribute returns the values of the attribute for the nodes and the nodes with the best value
+func compareAttribute(nodes []types.ComparedNode, attribute comparedAttribute) types.ComparedAttribute {
+	compared := types.ComparedAttribute{
+		Name:   attribute.name,
+		Values: make([]interface{}, len(nodes)),
+		Best:   []int{},
+	}
+	for idx, node := range nodes {
+		compared.Values[idx] = attribute.value(node)
+	}
+	if attribute.compare == compareNone {
+		return compared
+	}
+	// score returns a score the best value has the highest of, it's -inf for the unknown values
+	score := func(value interface{}) float64 {
+		var s float64
+		switch v := value.(type) {
+		case float64:
+			s = v
+		case bool:
+			if v {
+				s = 1
+			}
+		default:
+			return math.Inf(-1)
+		}
+		if attribute.compare == compareLower {
+			return -s
+		}
+		return s
+	}
+	best := score(compared.Values[0])
+	allEqual := true
+	for _, value := range compared.Values[1:] {
+		if s := score(value); s != best {
+			allEqual = false
+			if s > best {
+				best = s
+			}
+		}
+	}
+	if allEqual || math.IsInf(best, -1) {
+		return compared
+	}
+	for idx, value := range compared.Values {
+		if score(value) == best {
+			compared.Best = append(compared.Best, nodes[idx].NodeID)
+		}
+	}
+	return compared
+}
+
+// freeResources returns the total resources minus the used resources
+func freeResources(total, used types.Capacity) types.Capacity {
+	sub := func(total, used uint64) uint64 {
+		if used > total {
+			return 0
+		}
+		return total - used
+	}
+	return types.Capacity{
+		CRU: sub(total.CRU, used.CRU),
+		MRU: gridtypes.Unit(sub(uint64(total.MRU), uint64(used.MRU))),
+		SRU: gridtypes.Unit(sub(uint64(total.SRU), uint64(used.SRU))),
+		HRU: gridtypes.Unit(sub(uint64(total.HRU), uint64(used.HRU))),
+	}
+}
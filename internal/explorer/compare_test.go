@@ -0,0 +1,119 @@
+package explorer
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+func TestCompareAttribute(t *testing.T) {
+	tests := []struct {
+		name    string
+		compare int
+		values  []interface{}
+		best    []int
+	}{
+		{"highest", compareHigher, []interface{}{1.0, 3.0, 2.0}, []int{2}},
+		{"lowest", compareLower, []interface{}{1.0, 3.0, 2.0}, []int{1}},
+		{"tie on the highest", compareHigher, []interface{}{3.0, 1.0, 3.0}, []int{1, 3}},
+		{"tie on the lowest", compareLower, []interface{}{1.0, 1.0, 2.0}, []int{1, 2}},
+		{"all equal", compareHigher, []interface{}{2.0, 2.0, 2.0}, []int{}},
+		{"all equal lowest", compareLower, []interface{}{0.0, 0.0}, []int{}},
+		{"zero is the lowest", compareLower, []interface{}{0.0, 1.0}, []int{1}},
+		{"true is the best", compareHigher, []interface{}{false, true, true}, []int{2, 3}},
+		{"all false", compareHigher, []interface{}{false, false}, []int{}},
+		{"unknown is never the highest", compareHigher, []interface{}{nil, 1.0, 2.0}, []int{3}},
+		{"unknown is never the lowest", compareLower, []interface{}{nil, 1.0, 2.0}, []int{2}},
+		{"known values equal with an unknown", compareLower, []interface{}{5.0, nil, 5.0}, []int{1, 3}},
+		{"all unknown", compareLower, []interface{}{nil, nil, nil}, []int{}},
+		{"unknown types", compareHigher, []interface{}{"a", "b"}, []int{}},
+		{"no best value", compareNone, []interface{}{1.0, 3.0, 2.0}, []int{}},
+	}
+	for _, test := range tests {
+		var nodes []types.ComparedNode
+		for idx := range test.values {
+			nodes = append(nodes, types.ComparedNode{NodeID: idx + 1})
+		}
+		values := test.values
+		attribute := comparedAttribute{
+			name:    test.name,
+			compare: test.compare,
+			value:   func(n types.ComparedNode) interface{} { return values[n.NodeID-1] },
+		}
+		compared := compareAttribute(nodes, attribute)
+		if compared.Name != test.name || !reflect.DeepEqual(compared.Values, test.values) {
+			t.Fatalf("%s: values mismatch: expected: %v, found: %v", test.name, test.values, compared.Values)
+		}
+		if !reflect.DeepEqual(compared.Best, test.best) {
+			t.Fatalf("%s: best mismatch: expected: %v, found: %v", test.name, test.best, compared.Best)
+		}
+	}
+}
+
+func TestPriced(t *testing.T) {
+	price := priced(func(n types.ComparedNode) float64 { return n.PricePerCore.HourlyUSD })
+	tests := []struct {
+		name     string
+		node     types.ComparedNode
+		expected interface{}
+	}{
+		{"priced", types.ComparedNode{PricingPolicyID: 1, PricePerCore: types.Price{HourlyUSD: 0.5}}, 0.5},
+		{"no pricing policy", types.ComparedNode{PricePerCore: types.Price{HourlyUSD: 0.5}}, nil},
+		{"no cores", types.ComparedNode{PricingPolicyID: 1}, nil},
+	}
+	for _, test := range tests {
+		if value := price(test.node); value != test.expected {
+			t.Fatalf("%s: price mismatch: expected: %v, found: %v", test.name, test.expected, value)
+		}
+	}
+}
+
+func TestNodeIDsParam(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		nodeIDs []uint64
+		valid   bool
+	}{
+		{"min nodes", "node_ids=1,2", []uint64{1, 2}, true},
+		{"max nodes", "node_ids=1,2,3,4,5,6,7,8,9,10", []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, true},
+		{"requested order", "node_ids=9,3,5", []uint64{9, 3, 5}, true},
+		{"unknown parameter without strict", "node_ids=1,2&size=5", []uint64{1, 2}, true},
+		{"no nodes", "", nil, false},
+		{"one node", "node_ids=1", nil, false},
+		{"too many nodes", "node_ids=1,2,3,4,5,6,7,8,9,10,11", nil, false},
+		{"repeated node", "node_ids=1,2,1", nil, false},
+		{"repeated max nodes", "node_ids=1,2,3,4,5,6,7,8,9,9", nil, false},
+		{"invalid node id", "node_ids=1,two", nil, false},
+		{"unknown parameter in strict mode", "node_ids=1,2&size=5&strict=true", nil, false},
+	}
+	for _, test := range tests {
+		nodeIDs, err := nodeIDsParam(httptest.NewRequest("GET", "/nodes/compare?"+test.query, nil))
+		if !test.valid {
+			if !types.IsErrorCode(err, types.ErrCodeInvalidParam) {
+				t.Fatalf("%s: error mismatch: expected an invalid parameter, found: %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err.Error())
+		}
+		if !reflect.DeepEqual(nodeIDs, test.nodeIDs) {
+			t.Fatalf("%s: node ids mismatch: expected: %v, found: %v", test.name, test.nodeIDs, nodeIDs)
+		}
+	}
+}
+
+func TestNodeIDsParamErrors(t *testing.T) {
+	// the count and the repeated nodes are reported together
+	_, err := nodeIDsParam(httptest.NewRequest("GET", "/nodes/compare?node_ids=1,1,1,1,1,1,1,1,1,1,1", nil))
+	if params := invalidParams(t, err); !reflect.DeepEqual(params, []string{"node_ids", "node_ids"}) {
+		t.Fatalf("invalid params mismatch: expected: [node_ids node_ids], found: %v", params)
+	}
+	if !strings.Contains(err.Error(), "between 2 and 10") || !strings.Contains(err.Error(), "node 1 is repeated") {
+		t.Fatalf("error mismatch: %s", err.Error())
+	}
+}
@@ -2,9 +2,9 @@ package explorer
 
 import (
 	"encoding/json"
-	"time"
 
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
 	"github.com/threefoldtech/grid_proxy_server/pkg/types"
 	"github.com/threefoldtech/zos/pkg/gridtypes"
@@ -23,6 +23,7 @@ func nodeFromDBNode(info db.Node) types.Node {
 		Created:         info.Created,
 		FarmingPolicyID: int(info.FarmingPolicyID),
 		UpdatedAt:       info.UpdatedAt,
+		Status:          info.Status,
 		TotalResources: types.Capacity{
 			CRU: uint64(info.TotalCru),
 			SRU: gridtypes.Unit(info.TotalSru),
@@ -53,11 +54,9 @@ func nodeFromDBNode(info db.Node) types.Node {
 		RentContractID:    uint(info.RentContractID),
 		RentedByTwinID:    uint(info.RentedByTwinID),
 		SerialNumber:      info.SerialNumber,
-	}
-	if node.UpdatedAt >= time.Now().Add(-3*time.Hour).Unix() {
-		node.Status = "up"
-	} else {
-		node.Status = "down"
+		GPUs:              nodeGPUsFromDBNode(info),
+		Secure:            info.Secure,
+		Virtualized:       info.Virtualized,
 	}
 	return node
 }
@@ -72,6 +71,10 @@ func farmFromDBFarm(info db.Farm) (types.Farm, error) {
 		StellarAddress:    info.StellarAddress,
 		Dedicated:         info.Dedicated,
 	}
+	if info.PublicIps == "" {
+		// public ips weren't requested
+		return farm, nil
+	}
 	if err := json.Unmarshal([]byte(info.PublicIps), &farm.PublicIps); err != nil {
 		return farm, errors.Wrap(err, "couldn't unmarshal public ips returned from db")
 	}
@@ -92,6 +95,7 @@ func nodeWithNestedCapacityFromDBNode(info db.Node) types.NodeWithNestedCapacity
 		Created:         info.Created,
 		FarmingPolicyID: int(info.FarmingPolicyID),
 		UpdatedAt:       info.UpdatedAt,
+		Status:          info.Status,
 		Capacity: types.CapacityResult{
 
 			Total: types.Capacity{
@@ -125,11 +129,10 @@ func nodeWithNestedCapacityFromDBNode(info db.Node) types.NodeWithNestedCapacity
 		RentContractID:    uint(info.RentContractID),
 		RentedByTwinID:    uint(info.RentedByTwinID),
 		SerialNumber:      info.SerialNumber,
-	}
-	if node.UpdatedAt >= time.Now().Add(-3*time.Hour).Unix() {
-		node.Status = "up"
-	} else {
-		node.Status = "down"
+		GPUs:              nodeGPUsFromDBNode(info),
+		Secure:            info.Secure,
+		Virtualized:       info.Virtualized,
+		Interfaces:        nodeInterfacesFromDBNode(info),
 	}
 	return node
 }
@@ -166,3 +169,122 @@ func contractFromDBContract(info db.DBContract) (types.Contract, error) {
 	}
 	return contract, nil
 }
+
+// nodeGPUsFromDBNode returns the gpus of the node, the gpus aren't set if they weren't queried
+func nodeGPUsFromDBNode(info db.Node) []types.NodeGPU {
+	if info.Gpus == "" {
+		return nil
+	}
+	gpus := []types.NodeGPU{}
+	if err := json.Unmarshal([]byte(info.Gpus), &gpus); err != nil {
+		log.Err(err).Int64("node", info.NodeID).Msg("couldn't parse node gpus")
+	}
+	return gpus
+}
+
+// nodeInterfacesFromDBNode returns the network interfaces of the node, they aren't set if they weren't queried
+func nodeInterfacesFromDBNode(info db.Node) []types.NodeNetworkInterface {
+	if info.Interfaces == "" {
+		return nil
+	}
+	interfaces := []types.NodeNetworkInterface{}
+	if err := json.Unmarshal([]byte(info.Interfaces), &interfaces); err != nil {
+		log.Err(err).Int64("node", info.NodeID).Msg("couldn't parse node interfaces")
+	}
+	return interfaces
+}
+
+func nodeContractFromDBNodeContract(info db.NodeContract) types.NodeContract {
+	return types.NodeContract{
+		ContractID:        info.ContractID,
+		TwinID:            info.TwinID,
+		State:             info.State,
+		CreatedAt:         info.CreatedAt,
+		DeploymentData:    info.DeploymentData,
+		DeploymentHash:    info.DeploymentHash,
+		NumberOfPublicIps: info.NumberOfPublicIps,
+		Resources: types.Capacity{
+			CRU: info.Cru,
+			SRU: gridtypes.Unit(info.Sru),
+			HRU: gridtypes.Unit(info.Hru),
+			MRU: gridtypes.Unit(info.Mru),
+		},
+	}
+}
+
+func pricingPolicyFromDBPricingPolicy(info db.PricingPolicy) types.PricingPolicy {
+	return types.PricingPolicy{
+		ID:                    info.PricingPolicyID,
+		Name:                  info.Name,
+		SU:                    types.PolicyPrice{Value: info.SU, Unit: info.SUUnit},
+		CU:                    types.PolicyPrice{Value: info.CU, Unit: info.CUUnit},
+		NU:                    types.PolicyPrice{Value: info.NU, Unit: info.NUUnit},
+		IPU:                   types.PolicyPrice{Value: info.IPU, Unit: info.IPUUnit},
+		FoundationAccount:     info.FoundationAccount,
+		CertifiedSalesAccount: info.CertifiedSalesAccount,
+		DedicatedNodeDiscount: info.DedicatedNodeDiscount,
+	}
+}
+
+func farmingPolicyFromDBFarmingPolicy(info db.FarmingPolicy) types.FarmingPolicy {
+	return types.FarmingPolicy{
+		ID:                info.FarmingPolicyID,
+		Name:              info.Name,
+		CU:                info.CU,
+		SU:                info.SU,
+		NU:                info.NU,
+		IPv4:              info.IPv4,
+		MinimalUptime:     info.MinimalUptime,
+		PolicyCreated:     info.PolicyCreated,
+		PolicyEnd:         info.PolicyEnd,
+		Immutable:         info.Immutable,
+		Default:           info.Default,
+		NodeCertification: info.NodeCertification,
+		FarmCertification: info.FarmCertification,
+	}
+}
+
+func statsSnapshotFromDBStatsSnapshot(info db.StatsSnapshot) types.StatsSnapshot {
+	return types.StatsSnapshot{
+		Timestamp:   info.Timestamp,
+		Nodes:       info.Nodes,
+		UpNodes:     info.UpNodes,
+		Farms:       info.Farms,
+		Countries:   info.Countries,
+		TotalCRU:    info.TotalCru,
+		TotalSRU:    info.TotalSru,
+		TotalMRU:    info.TotalMru,
+		TotalHRU:    info.TotalHru,
+		PublicIPs:   info.PublicIps,
+		AccessNodes: info.AccessNodes,
+		Gateways:    info.Gateways,
+		Twins:       info.Twins,
+		Contracts:   info.Contracts,
+	}
+}
+
+func webhookSubscriptionFromDBWebhookSubscription(info db.WebhookSubscription) (types.WebhookSubscription, error) {
+	subscription := types.WebhookSubscription{
+		ID:        info.ID,
+		URL:       info.URL,
+		CreatedAt: info.CreatedAt,
+	}
+	if err := json.Unmarshal([]byte(info.Filter), &subscription.Filter); err != nil {
+		return subscription, errors.Wrap(err, "couldn't decode webhook filter")
+	}
+	return subscription, nil
+}
+
+func webhookDeliveryFromDBWebhookDelivery(info db.WebhookDelivery) types.WebhookDelivery {
+	return types.WebhookDelivery{
+		ID:         info.ID,
+		WebhookID:  info.SubscriptionID,
+		EventID:    info.EventID,
+		EventType:  info.EventType,
+		Attempt:    info.Attempt,
+		StatusCode: info.StatusCode,
+		Success:    info.Success,
+		Error:      info.Error,
+		Timestamp:  info.Timestamp,
+	}
+}
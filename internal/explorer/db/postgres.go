@@ -12,6 +12,7 @@ import (
 	"github.com/threefoldtech/zos/pkg/gridtypes"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 
 	"github.com/pkg/errors"
@@ -23,6 +24,12 @@ var (
 	ErrNodeNotFound = errors.New("node not found")
 	// ErrFarmNotFound farm not found
 	ErrFarmNotFound = errors.New("farm not found")
+	// ErrPricingPolicyNotFound pricing policy not found
+	ErrPricingPolicyNotFound = errors.New("pricing policy not found")
+	// ErrFarmingPolicyNotFound farming policy not found
+	ErrFarmingPolicyNotFound = errors.New("farming policy not found")
+	// ErrWebhookNotFound webhook subscription not found
+	ErrWebhookNotFound = errors.New("webhook not found")
 	//ErrViewNotFound
 	ErrNodeResourcesViewNotFound = errors.New("ERROR: relation \"nodes_resources_view\" does not exist (SQLSTATE 42P01)")
 )
@@ -32,8 +39,51 @@ const (
 	reportInterval  = time.Hour
 	// the number of missed reports to mark the node down
 	// if node reports every 5 mins, it's marked down if the last report is more than 15 mins in the past
+
+	// standbyInterval is the default time a node powered off by the farmerbot is in standby
+	// since its last report, the farmerbot wakes the nodes up at least once a day
+	standbyInterval = 36 * time.Hour
 )
 
+const (
+	// NodeUp the node reported recently
+	NodeUp = "up"
+	// NodeDown the node didn't report recently
+	NodeDown = "down"
+	// NodeStandby the node is powered off on purpose by the farmerbot
+	NodeStandby = "standby"
+)
+
+// NodeStatusConfig has the thresholds used to compute the node status from its reports
+type NodeStatusConfig struct {
+	// UpInterval is the max time since the last report of an up node
+	UpInterval time.Duration
+	// StandbyInterval is the max time since the last report of a powered off node to be in standby
+	StandbyInterval time.Duration
+}
+
+// DefaultNodeStatusConfig returns the default node status thresholds
+func DefaultNodeStatusConfig() NodeStatusConfig {
+	return NodeStatusConfig{
+		UpInterval:      nodeStateFactor * reportInterval,
+		StandbyInterval: standbyInterval,
+	}
+}
+
+// statusColumn returns the sql expression computing the node status, a node is in standby
+// if its power state or target is down and it reported in the standby interval
+func (c NodeStatusConfig) statusColumn(now time.Time) string {
+	return fmt.Sprintf(`(CASE
+		WHEN (node.power->>'state' = 'Down' OR node.power->>'target' = 'Down') AND COALESCE(node.updated_at, 0) >= %d THEN '%s'
+		WHEN COALESCE(node.updated_at, 0) >= %d THEN '%s'
+		ELSE '%s'
+	END)`,
+		now.Add(-c.StandbyInterval).Unix(), NodeStandby,
+		now.Add(-c.UpInterval).Unix(), NodeUp,
+		NodeDown,
+	)
+}
+
 const (
 	setupPostgresql = `
 	CREATE OR REPLACE VIEW nodes_resources_view AS SELECT
@@ -103,16 +153,131 @@ const (
 		END;
 	RETURN v_dec_value;
 	END;
-	$$ LANGUAGE plpgsql;`
+	$$ LANGUAGE plpgsql;
+
+	CREATE TABLE IF NOT EXISTS grid_stats_history (
+		timestamp BIGINT PRIMARY KEY,
+		nodes BIGINT NOT NULL,
+		up_nodes BIGINT NOT NULL,
+		farms BIGINT NOT NULL,
+		countries BIGINT NOT NULL,
+		total_cru BIGINT NOT NULL,
+		total_sru BIGINT NOT NULL,
+		total_mru BIGINT NOT NULL,
+		total_hru BIGINT NOT NULL,
+		public_ips BIGINT NOT NULL,
+		access_nodes BIGINT NOT NULL,
+		gateways BIGINT NOT NULL,
+		twins BIGINT NOT NULL,
+		contracts BIGINT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_subscription (
+		id BIGSERIAL PRIMARY KEY,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		filter TEXT NOT NULL,
+		created_at BIGINT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_delivery (
+		id BIGSERIAL PRIMARY KEY,
+		subscription_id BIGINT NOT NULL REFERENCES webhook_subscription (id) ON DELETE CASCADE,
+		event_id TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		status_code INTEGER NOT NULL,
+		success BOOLEAN NOT NULL,
+		error TEXT NOT NULL,
+		timestamp BIGINT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS webhook_delivery_subscription_id ON webhook_delivery (subscription_id, id);
+
+	CREATE TABLE IF NOT EXISTS change_fingerprint (
+		kind TEXT NOT NULL,
+		object_id BIGINT NOT NULL,
+		hash TEXT NOT NULL,
+		PRIMARY KEY (kind, object_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS change_log (
+		id BIGSERIAL PRIMARY KEY,
+		kind TEXT NOT NULL,
+		object_id BIGINT NOT NULL,
+		timestamp BIGINT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS change_log_timestamp ON change_log (timestamp);`
+
+	// recordChangesQuery hashes the rows of the nodes, farms, twins and contracts with their related rows, and logs
+	// the objects with a hash different than the one stored by the previous run. it's formatted with the node
+	// status column so the nodes going down are logged too. the node uptime and updated_at aren't hashed as
+	// they change with every uptime report, the node changes only when its status does
+	recordChangesQuery = `
+	WITH current AS (
+		SELECT 'node' AS kind, node.node_id AS object_id, md5(ROW(
+			node.node_id,
+			node.farm_id,
+			node.twin_id,
+			node.grid_version,
+			node.country,
+			node.city,
+			node.location_id,
+			node.created,
+			node.farming_policy_id,
+			node.certification,
+			node.serial_number,
+			node.secure,
+			node.virtualized,
+			%s,
+			node_resources_total.*,
+			public_config.*,
+			(SELECT json_agg(interfaces ORDER BY interfaces.id) FROM interfaces WHERE interfaces.node_id = node.id)
+		)::text) AS hash
+		FROM node
+		LEFT JOIN node_resources_total ON node_resources_total.node_id = node.id
+		LEFT JOIN public_config ON public_config.node_id = node.id
+		UNION ALL
+		SELECT 'farm', farm.farm_id, md5(ROW(
+			farm.*,
+			(SELECT json_agg(public_ip ORDER BY public_ip.id) FROM public_ip WHERE public_ip.farm_id = farm.id)
+		)::text)
+		FROM farm
+		UNION ALL
+		SELECT 'twin', twin.twin_id, md5(twin::text)
+		FROM twin
+		UNION ALL
+		SELECT 'contract', node_contract.contract_id, md5(ROW(node_contract.*, contract_resources.*)::text)
+		FROM node_contract
+		LEFT JOIN contract_resources ON node_contract.resources_used_id = contract_resources.id
+		UNION ALL
+		SELECT 'contract', rent_contract.contract_id, md5(rent_contract::text)
+		FROM rent_contract
+		UNION ALL
+		SELECT 'contract', name_contract.contract_id, md5(name_contract::text)
+		FROM name_contract
+	), changed AS (
+		INSERT INTO change_fingerprint (kind, object_id, hash)
+		SELECT kind, object_id, hash FROM current
+		ON CONFLICT (kind, object_id) DO UPDATE SET hash = EXCLUDED.hash
+		WHERE change_fingerprint.hash != EXCLUDED.hash
+		RETURNING kind, object_id
+	)
+	INSERT INTO change_log (kind, object_id, timestamp)
+	SELECT kind, object_id, ? FROM changed ORDER BY kind, object_id`
 )
 
+// changesLockKey is the key of the advisory lock held while recording the changes, it keeps the change log
+// ids in commit order when many instances share the database
+const changesLockKey = 0x6368616e676573
+
 // PostgresDatabase postgres db client
 type PostgresDatabase struct {
-	gormDB *gorm.DB
+	gormDB     *gorm.DB
+	nodeStatus NodeStatusConfig
 }
 
 // NewPostgresDatabase returns a new postgres db client
-func NewPostgresDatabase(host string, port int, user, password, dbname string) (Database, error) {
+func NewPostgresDatabase(host string, port int, user, password, dbname string, nodeStatus NodeStatusConfig) (Database, error) {
 	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s "+
 		"password=%s dbname=%s sslmode=disable",
 		host, port, user, password, dbname)
@@ -120,7 +285,7 @@ func NewPostgresDatabase(host string, port int, user, password, dbname string) (
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create orm wrapper around db")
 	}
-	res := PostgresDatabase{gormDB}
+	res := PostgresDatabase{gormDB, nodeStatus}
 	if err := res.initialize(); err != nil {
 		return nil, errors.Wrap(err, "failed to setup tables")
 	}
@@ -168,13 +333,10 @@ func (d *PostgresDatabase) GetCounters(filter types.StatsFilter) (types.Counters
 	}
 
 	condition := "TRUE"
+	var args []interface{}
 	if filter.Status != nil {
-		nodeUpInterval := time.Now().Unix() - nodeStateFactor*int64(reportInterval.Seconds())
-		if *filter.Status == "up" {
-			condition = fmt.Sprintf(`node.updated_at >= %d`, nodeUpInterval)
-		} else if *filter.Status == "down" {
-			condition = fmt.Sprintf(`node.updated_at < %d`, nodeUpInterval)
-		}
+		condition = fmt.Sprintf("%s = ?", d.nodeStatus.statusColumn(time.Now()))
+		args = append(args, *filter.Status)
 	}
 
 	if res := d.gormDB.
@@ -186,16 +348,16 @@ func (d *PostgresDatabase) GetCounters(filter types.StatsFilter) (types.Counters
 			"sum(node_resources_total.mru) as total_mru",
 		).
 		Joins("LEFT JOIN node_resources_total ON node.id = node_resources_total.node_id").
-		Where(condition).
+		Where(condition, args...).
 		Scan(&counters); res.Error != nil {
 		return counters, errors.Wrap(res.Error, "couldn't get nodes total resources")
 	}
 	if res := d.gormDB.Table("node").
-		Where(condition).Count(&counters.Nodes); res.Error != nil {
+		Where(condition, args...).Count(&counters.Nodes); res.Error != nil {
 		return counters, errors.Wrap(res.Error, "couldn't get node count")
 	}
 	if res := d.gormDB.Table("node").
-		Where(condition).Distinct("country").Count(&counters.Countries); res.Error != nil {
+		Where(condition, args...).Distinct("country").Count(&counters.Countries); res.Error != nil {
 		return counters, errors.Wrap(res.Error, "couldn't get country count")
 	}
 	query := d.gormDB.
@@ -206,15 +368,15 @@ func (d *PostgresDatabase) GetCounters(filter types.StatsFilter) (types.Counters
 			`,
 		)
 
-	if res := query.Where(condition).Where("COALESCE(public_config.ipv4, '') != '' OR COALESCE(public_config.ipv6, '') != ''").Count(&counters.AccessNodes); res.Error != nil {
+	if res := query.Where(condition, args...).Where("COALESCE(public_config.ipv4, '') != '' OR COALESCE(public_config.ipv6, '') != ''").Count(&counters.AccessNodes); res.Error != nil {
 		return counters, errors.Wrap(res.Error, "couldn't get access node count")
 	}
-	if res := query.Where(condition).Where("COALESCE(public_config.domain, '') != '' AND (COALESCE(public_config.ipv4, '') != '' OR COALESCE(public_config.ipv6, '') != '')").Count(&counters.Gateways); res.Error != nil {
+	if res := query.Where(condition, args...).Where("COALESCE(public_config.domain, '') != '' AND (COALESCE(public_config.ipv4, '') != '' OR COALESCE(public_config.ipv6, '') != '')").Count(&counters.Gateways); res.Error != nil {
 		return counters, errors.Wrap(res.Error, "couldn't get gateway count")
 	}
 	var distribution []NodesDistribution
 	if res := d.gormDB.Table("node").
-		Select("country, count(node_id) as nodes").Where(condition).Group("country").Scan(&distribution); res.Error != nil {
+		Select("country, count(node_id) as nodes").Where(condition, args...).Group("country").Scan(&distribution); res.Error != nil {
 		return counters, errors.Wrap(res.Error, "couldn't get nodes distribution")
 	}
 	nodesDistribution := map[string]int64{}
@@ -225,9 +387,21 @@ func (d *PostgresDatabase) GetCounters(filter types.StatsFilter) (types.Counters
 	return counters, nil
 }
 
-// GetNode returns node info
-func (d *PostgresDatabase) GetNode(nodeID uint32) (Node, error) {
-	q := d.nodeTableQuery()
+// GetNode returns node info, only the columns needed for the given json fields are queried
+func (d *PostgresDatabase) GetNode(nodeID uint32, fields ...string) (Node, error) {
+	q := d.nodeTableQuery(nodeDetailsFields, fields...)
+	if hasField(fields, "interfaces") {
+		q = q.Joins(
+			`LEFT JOIN
+			(SELECT
+				node_id,
+				json_agg(json_build_object('name', name, 'mac', mac, 'ips', ips) ORDER BY name, id) as interfaces
+			FROM
+				interfaces
+			GROUP BY node_id) interfaces
+			ON interfaces.node_id = node.id`,
+		)
+	}
 	q = q.Where("node.node_id = ?", nodeID)
 	q = q.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
 	var node Node
@@ -283,21 +457,123 @@ func printQuery(query string, args ...interface{}) {
 	}
 	fmt.Printf("node query: %s", query)
 }
-func (d *PostgresDatabase) farmTableQuery() *gorm.DB {
-	return d.gormDB.
+
+// farmFields maps each json field of a farm to the columns it's built from
+var farmFields = []fieldColumns{
+	{"name", []string{"name"}},
+	{"farmId", []string{"farm.farm_id"}},
+	{"twinId", []string{"twin_id"}},
+	{"pricingPolicyId", []string{"pricing_policy_id"}},
+	{"certificationType", []string{"certification"}},
+	{"stellarAddress", []string{"stellar_address"}},
+	{"dedicated", []string{"dedicated_farm as dedicated"}},
+	{"publicIps", []string{"COALESCE(public_ip.public_ips, '[]') as public_ips"}},
+}
+
+// nodeFields maps each json field of a node to the columns it's built from
+var nodeFields = []fieldColumns{
+	{"id", []string{"node.id"}},
+	{"nodeId", []string{"node.node_id"}},
+	{"farmId", []string{"node.farm_id"}},
+	{"twinId", []string{"node.twin_id"}},
+	{"country", []string{"node.country"}},
+	{"gridVersion", []string{"node.grid_version"}},
+	{"city", []string{"node.city"}},
+	{"uptime", []string{"node.uptime"}},
+	{"created", []string{"node.created"}},
+	{"farmingPolicyId", []string{"node.farming_policy_id"}},
+	{"updatedAt", []string{"updated_at"}},
+	{"total_resources", nodeTotalResourcesColumns},
+	{"used_resources", nodeUsedResourcesColumns},
+	{"capacity", append(nodeTotalResourcesColumns, nodeUsedResourcesColumns...)},
+	{"location", []string{
+		"node.country",
+		"node.city",
+		"convert_to_decimal(location.longitude) as longitude",
+		"convert_to_decimal(location.latitude) as latitude",
+	}},
+	{"publicConfig", []string{
+		"public_config.domain",
+		"public_config.gw4",
+		"public_config.gw6",
+		"public_config.ipv4",
+		"public_config.ipv6",
+	}},
+	{"certificationType", []string{"node.certification"}},
+	{"dedicated", []string{"farm.dedicated_farm as dedicated"}},
+	{"rentContractId", []string{"rent_contract.contract_id as rent_contract_id"}},
+	{"rentedByTwinId", []string{"rent_contract.twin_id as rented_by_twin_id"}},
+	{"serialNumber", []string{"node.serial_number"}},
+	{"gpus", []string{"COALESCE(node_gpu.gpus, '[]') as gpus"}},
+	{"secure", []string{"COALESCE(node.secure, false) as secure"}},
+	{"virtualized", []string{"COALESCE(node.virtualized, false) as virtualized"}},
+}
+
+// nodeDetailsFields maps the json fields of a single node, it has the fields that are too
+// expensive to return in the nodes list
+var nodeDetailsFields = append(nodeFields[:len(nodeFields):len(nodeFields)],
+	fieldColumns{"interfaces", []string{"COALESCE(interfaces.interfaces, '[]') as interfaces"}},
+)
+
+var (
+	nodeTotalResourcesColumns = []string{
+		"nodes_resources_view.total_cru",
+		"nodes_resources_view.total_sru",
+		"nodes_resources_view.total_hru",
+		"nodes_resources_view.total_mru",
+	}
+	nodeUsedResourcesColumns = []string{
+		"nodes_resources_view.used_cru",
+		"nodes_resources_view.used_sru",
+		"nodes_resources_view.used_hru",
+		"nodes_resources_view.used_mru",
+	}
+)
+
+// fieldColumns is the list of columns needed to build a json field
+type fieldColumns struct {
+	field   string
+	columns []string
+}
+
+// selectColumns returns the columns needed to build the given fields,
+// all the columns are returned if no fields are given
+func selectColumns(mapping []fieldColumns, fields []string, required ...string) []string {
+	requested := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		requested[field] = true
+	}
+	seen := make(map[string]bool)
+	columns := make([]string, 0, len(mapping))
+	add := func(column string) {
+		if !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+	for _, column := range required {
+		add(column)
+	}
+	for _, m := range mapping {
+		if len(fields) != 0 && !requested[m.field] {
+			continue
+		}
+		for _, column := range m.columns {
+			add(column)
+		}
+	}
+	return columns
+}
+
+func (d *PostgresDatabase) farmTableQuery(fields ...string) *gorm.DB {
+	q := d.gormDB.
 		Table("farm").
-		Select(
-			"farm.farm_id",
-			"name",
-			"twin_id",
-			"pricing_policy_id",
-			"certification",
-			"stellar_address",
-			"dedicated_farm as dedicated",
-			"COALESCE(public_ip.public_ips, '[]') as public_ips",
-		).
-		Joins(
-			`LEFT JOIN
+		Select(selectColumns(farmFields, fields, "farm.farm_id"))
+	if !hasField(fields, "publicIps") {
+		return q
+	}
+	return q.Joins(
+		`LEFT JOIN
 		(SELECT
 			farm_id, 
 			json_agg(json_build_object('id', id, 'ip', ip, 'contractId', contract_id, 'gateway', gateway)) as public_ips
@@ -305,44 +581,15 @@ func (d *PostgresDatabase) farmTableQuery() *gorm.DB {
 			public_ip
 		GROUP by farm_id) public_ip
 		ON public_ip.farm_id = farm.id`,
-		)
+	)
 }
-func (d *PostgresDatabase) nodeTableQuery() *gorm.DB {
-	return d.gormDB.
+func (d *PostgresDatabase) nodeTableQuery(columns []fieldColumns, fields ...string) *gorm.DB {
+	mapping := append(columns[:len(columns):len(columns)], fieldColumns{
+		"status", []string{fmt.Sprintf("%s as status", d.nodeStatus.statusColumn(time.Now()))},
+	})
+	q := d.gormDB.
 		Table("node").
-		Select(
-			"node.id",
-			"node.node_id",
-			"node.farm_id",
-			"node.twin_id",
-			"node.country",
-			"node.grid_version",
-			"node.city",
-			"node.uptime",
-			"node.created",
-			"node.farming_policy_id",
-			"updated_at",
-			"nodes_resources_view.total_cru",
-			"nodes_resources_view.total_sru",
-			"nodes_resources_view.total_hru",
-			"nodes_resources_view.total_mru",
-			"nodes_resources_view.used_cru",
-			"nodes_resources_view.used_sru",
-			"nodes_resources_view.used_hru",
-			"nodes_resources_view.used_mru",
-			"public_config.domain",
-			"public_config.gw4",
-			"public_config.gw6",
-			"public_config.ipv4",
-			"public_config.ipv6",
-			"node.certification",
-			"farm.dedicated_farm as dedicated",
-			"rent_contract.contract_id as rent_contract_id",
-			"rent_contract.twin_id as rented_by_twin_id",
-			"node.serial_number",
-			"convert_to_decimal(location.longitude) as longitude",
-			"convert_to_decimal(location.latitude) as latitude",
-		).
+		Select(selectColumns(mapping, fields, "node.id")).
 		Joins(
 			"LEFT JOIN nodes_resources_view ON node.node_id = nodes_resources_view.node_id",
 		).
@@ -358,20 +605,40 @@ func (d *PostgresDatabase) nodeTableQuery() *gorm.DB {
 		Joins(
 			"LEFT JOIN location ON node.location_id = location.id",
 		)
+	if !hasField(fields, "gpus") {
+		return q
+	}
+	return q.Joins(
+		`LEFT JOIN
+		(SELECT
+			node_twin_id,
+			json_agg(json_build_object('id', id, 'vendor', vendor, 'device', device, 'contract', COALESCE(contract, 0)) ORDER BY id) as gpus
+		FROM
+			node_gpu
+		GROUP BY node_twin_id) node_gpu
+		ON node_gpu.node_twin_id = node.twin_id`,
+	)
+}
+
+// hasField checks if a field is requested, no fields means all of them are
+func hasField(fields []string, field string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
 }
 
-// GetNodes returns nodes filtered and paginated
-func (d *PostgresDatabase) GetNodes(filter types.NodeFilter, limit types.Limit) ([]Node, uint, error) {
-	q := d.nodeTableQuery()
+// GetNodes returns nodes filtered and paginated, only the columns needed for the given json fields are queried
+func (d *PostgresDatabase) GetNodes(filter types.NodeFilter, limit types.Limit, fields ...string) ([]Node, uint, error) {
+	q := d.nodeTableQuery(nodeFields, fields...)
 	q = q.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
 	if filter.Status != nil {
-		// TODO: this shouldn't be in db
-		threshold := time.Now().Unix() - nodeStateFactor*int64(reportInterval.Seconds())
-		if *filter.Status == "down" {
-			q = q.Where("node.updated_at < ? OR node.updated_at IS NULL", threshold)
-		} else {
-			q = q.Where("node.updated_at >= ?", threshold)
-		}
+		q = q.Where(fmt.Sprintf("%s = ?", d.nodeStatus.statusColumn(time.Now())), *filter.Status)
 	}
 	if filter.FreeMRU != nil {
 		q = q.Where("nodes_resources_view.free_mru >= ?", *filter.FreeMRU)
@@ -394,6 +661,39 @@ func (d *PostgresDatabase) GetNodes(filter types.NodeFilter, limit types.Limit)
 	if filter.TotalSRU != nil {
 		q = q.Where("nodes_resources_view.total_sru >= ?", *filter.TotalSRU)
 	}
+	if filter.FreeMRUMax != nil {
+		q = q.Where("nodes_resources_view.free_mru <= ?", *filter.FreeMRUMax)
+	}
+	if filter.FreeHRUMax != nil {
+		q = q.Where("nodes_resources_view.free_hru <= ?", *filter.FreeHRUMax)
+	}
+	if filter.FreeSRUMax != nil {
+		q = q.Where("nodes_resources_view.free_sru <= ?", *filter.FreeSRUMax)
+	}
+	if filter.TotalCRUMax != nil {
+		q = q.Where("nodes_resources_view.total_cru <= ?", *filter.TotalCRUMax)
+	}
+	if filter.TotalHRUMax != nil {
+		q = q.Where("nodes_resources_view.total_hru <= ?", *filter.TotalHRUMax)
+	}
+	if filter.TotalMRUMax != nil {
+		q = q.Where("nodes_resources_view.total_mru <= ?", *filter.TotalMRUMax)
+	}
+	if filter.TotalSRUMax != nil {
+		q = q.Where("nodes_resources_view.total_sru <= ?", *filter.TotalSRUMax)
+	}
+	if filter.UptimeMin != nil {
+		q = q.Where("node.uptime >= ?", *filter.UptimeMin)
+	}
+	if filter.UptimeMax != nil {
+		q = q.Where("node.uptime <= ?", *filter.UptimeMax)
+	}
+	if filter.CreatedAfter != nil {
+		q = q.Where("node.created >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		q = q.Where("node.created < ?", *filter.CreatedBefore)
+	}
 	if filter.Country != nil {
 		q = q.Where("LOWER(node.country) = LOWER(?)", *filter.Country)
 	}
@@ -442,6 +742,23 @@ func (d *PostgresDatabase) GetNodes(filter types.NodeFilter, limit types.Limit)
 	if filter.RentedBy != nil {
 		q = q.Where(`COALESCE(rent_contract.twin_id, 0) = ?`, *filter.RentedBy)
 	}
+	if filter.HasGPU != nil {
+		q = q.Where("? = EXISTS (SELECT 1 FROM node_gpu WHERE node_gpu.node_twin_id = node.twin_id)", *filter.HasGPU)
+	}
+	if filter.GPUVendor != nil || filter.GPUDevice != nil || filter.GPUAvailable != nil {
+		// the conditions must match the same gpu
+		gpu := d.gormDB.Table("node_gpu").Select("1").Where("node_gpu.node_twin_id = node.twin_id")
+		if filter.GPUVendor != nil {
+			gpu = gpu.Where("node_gpu.vendor ILIKE '%' || ? || '%'", *filter.GPUVendor)
+		}
+		if filter.GPUDevice != nil {
+			gpu = gpu.Where("node_gpu.device ILIKE '%' || ? || '%'", *filter.GPUDevice)
+		}
+		if filter.GPUAvailable != nil {
+			gpu = gpu.Where("? = (COALESCE(node_gpu.contract, 0) = 0)", *filter.GPUAvailable)
+		}
+		q = q.Where("EXISTS (?)", gpu)
+	}
 	if filter.AvailableFor != nil {
 		q = q.Where(`COALESCE(rent_contract.twin_id, 0) = ? OR (COALESCE(rent_contract.twin_id, 0) = 0 AND farm.dedicated_farm = false)`, *filter.AvailableFor)
 	}
@@ -451,6 +768,39 @@ func (d *PostgresDatabase) GetNodes(filter types.NodeFilter, limit types.Limit)
 	if filter.CertificationType != nil {
 		q = q.Where("node.certification ILIKE ?", *filter.CertificationType)
 	}
+	if filter.CertificationTypeNot != nil {
+		q = q.Where("COALESCE(node.certification, '') NOT ILIKE ?", *filter.CertificationTypeNot)
+	}
+	if len(filter.ExcludeFarmIDs) != 0 {
+		q = q.Where("node.farm_id NOT IN ?", filter.ExcludeFarmIDs)
+	}
+	if len(filter.ExcludeNodeIDs) != 0 {
+		q = q.Where("node.node_id NOT IN ?", filter.ExcludeNodeIDs)
+	}
+	if len(filter.ExcludeTwinIDs) != 0 {
+		q = q.Where("node.twin_id NOT IN ?", filter.ExcludeTwinIDs)
+	}
+	if filter.CountryNot != nil {
+		q = q.Where("LOWER(COALESCE(node.country, '')) != LOWER(?)", *filter.CountryNot)
+	}
+	if filter.Secure != nil {
+		q = q.Where("COALESCE(node.secure, false) = ?", *filter.Secure)
+	}
+	if filter.Virtualized != nil {
+		q = q.Where("COALESCE(node.virtualized, false) = ?", *filter.Virtualized)
+	}
+	if filter.GridVersion != nil {
+		q = q.Where("node.grid_version = ?", *filter.GridVersion)
+	}
+	if filter.HasInterfaceIP != nil {
+		q = q.Where("? = EXISTS (SELECT 1 FROM interfaces WHERE interfaces.node_id = node.id AND COALESCE(interfaces.ips, '') != '')", *filter.HasInterfaceIP)
+	}
+	if len(filter.NodeIDs) != 0 {
+		q = q.Where("node.node_id IN ?", filter.NodeIDs)
+	}
+	if filter.UpdatedAfter != nil {
+		q = q.Where("node.updated_at >= ?", *filter.UpdatedAfter)
+	}
 
 	var count int64
 	if limit.Randomize || limit.RetCount {
@@ -472,7 +822,7 @@ func (d *PostgresDatabase) GetNodes(filter types.NodeFilter, limit types.Limit)
 		}
 		q = q.Limit(int(limit.Size)).
 			Offset(int(limit.Page-1) * int(limit.Size)).
-			Order("node_id")
+			Order("node.node_id")
 	}
 
 	var nodes []Node
@@ -498,9 +848,9 @@ func (d *PostgresDatabase) shouldRetry(resError error) bool {
 	return false
 }
 
-// GetFarms return farms filtered and paginated
-func (d *PostgresDatabase) GetFarms(filter types.FarmFilter, limit types.Limit) ([]Farm, uint, error) {
-	q := d.farmTableQuery()
+// GetFarms return farms filtered and paginated, only the columns needed for the given json fields are queried
+func (d *PostgresDatabase) GetFarms(filter types.FarmFilter, limit types.Limit, fields ...string) ([]Farm, uint, error) {
+	q := d.farmTableQuery(fields...)
 	if filter.FreeIPs != nil {
 		q = q.Where("(SELECT count(id) from public_ip WHERE public_ip.farm_id = farm.id and public_ip.contract_id = 0) >= ?", *filter.FreeIPs)
 	}
@@ -536,6 +886,34 @@ func (d *PostgresDatabase) GetFarms(filter types.FarmFilter, limit types.Limit)
 	if filter.Dedicated != nil {
 		q = q.Where("dedicated_farm = ?", *filter.Dedicated)
 	}
+	if len(filter.ExcludeFarmIDs) != 0 {
+		q = q.Where("farm.farm_id NOT IN ?", filter.ExcludeFarmIDs)
+	}
+	if len(filter.FarmIDs) != 0 {
+		q = q.Where("farm.farm_id IN ?", filter.FarmIDs)
+	}
+	if len(filter.ExcludeTwinIDs) != 0 {
+		q = q.Where("twin_id NOT IN ?", filter.ExcludeTwinIDs)
+	}
+	if filter.CertificationTypeNot != nil {
+		q = q.Where("COALESCE(certification, '') != ?", *filter.CertificationTypeNot)
+	}
+	filtersNodes := filter.Country != nil || filter.NodeFreeMRU != nil || filter.NodeFreeSRU != nil || filter.NodeCertificationType != nil
+	if filtersNodes || filter.NodeCountMin != nil || filter.UpNodesMin != nil || filter.RentableNodesMin != nil {
+		q = q.Joins("LEFT JOIN (?) farm_nodes ON farm_nodes.farm_id = farm.farm_id", d.farmNodesQuery(filter))
+		if filtersNodes {
+			q = q.Where("COALESCE(farm_nodes.node_count, 0) > 0")
+		}
+		if filter.NodeCountMin != nil {
+			q = q.Where("COALESCE(farm_nodes.node_count, 0) >= ?", *filter.NodeCountMin)
+		}
+		if filter.UpNodesMin != nil {
+			q = q.Where("COALESCE(farm_nodes.up_nodes, 0) >= ?", *filter.UpNodesMin)
+		}
+		if filter.RentableNodesMin != nil {
+			q = q.Where("COALESCE(farm_nodes.rentable_nodes, 0) >= ?", *filter.RentableNodesMin)
+		}
+	}
 	var count int64
 	if limit.Randomize || limit.RetCount {
 		if res := q.Count(&count); res.Error != nil {
@@ -557,6 +935,337 @@ func (d *PostgresDatabase) GetFarms(filter types.FarmFilter, limit types.Limit)
 	return farms, uint(count), nil
 }
 
+// farmNodesQuery counts the nodes, up nodes and rentable nodes of each farm among the nodes
+// satisfying the node filters of the farm filter
+func (d *PostgresDatabase) farmNodesQuery(filter types.FarmFilter) *gorm.DB {
+	q := d.gormDB.
+		Table("node").
+		Select(
+			"node.farm_id",
+			"COUNT(*) as node_count",
+			fmt.Sprintf("COUNT(*) FILTER (WHERE %s = '%s') as up_nodes", d.nodeStatus.statusColumn(time.Now()), NodeUp),
+			"COUNT(*) FILTER (WHERE (node_farm.dedicated_farm = true OR nodes_resources_view.states = 0) AND COALESCE(rent_contract.contract_id, 0) = 0) as rentable_nodes",
+		).
+		Joins("LEFT JOIN nodes_resources_view ON node.node_id = nodes_resources_view.node_id").
+		Joins("LEFT JOIN rent_contract ON rent_contract.state IN ('Created', 'GracePeriod') AND rent_contract.node_id = node.node_id").
+		Joins("LEFT JOIN farm node_farm ON node.farm_id = node_farm.farm_id").
+		Group("node.farm_id")
+	if filter.Country != nil {
+		q = q.Where("LOWER(node.country) = LOWER(?)", *filter.Country)
+	}
+	if filter.NodeFreeMRU != nil {
+		q = q.Where("nodes_resources_view.free_mru >= ?", *filter.NodeFreeMRU)
+	}
+	if filter.NodeFreeSRU != nil {
+		q = q.Where("nodes_resources_view.free_sru >= ?", *filter.NodeFreeSRU)
+	}
+	if filter.NodeCertificationType != nil {
+		q = q.Where("node.certification ILIKE ?", *filter.NodeCertificationType)
+	}
+	return q
+}
+
+// InsertStatsSnapshot stores the grid counters snapshot, a snapshot with the same timestamp is kept as is
+func (d *PostgresDatabase) InsertStatsSnapshot(snapshot StatsSnapshot) error {
+	res := d.gormDB.
+		Table("grid_stats_history").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&snapshot)
+	return errors.Wrap(res.Error, "couldn't insert stats snapshot")
+}
+
+// GetStatsHistory returns the last snapshot of every interval in [from, to), the timestamp
+// of the returned snapshots is the start of their interval
+func (d *PostgresDatabase) GetStatsHistory(from, to, interval int64) ([]StatsSnapshot, error) {
+	if interval <= 0 {
+		return nil, errors.Errorf("invalid stats history interval %d", interval)
+	}
+	var snapshots []StatsSnapshot
+	// the interval is formatted in the query since distinct on has to match the order by expression
+	bucket := fmt.Sprintf("grid_stats_history.timestamp / %d", interval)
+	res := d.gormDB.Raw(fmt.Sprintf(`
+	SELECT * FROM (
+		SELECT DISTINCT ON (%[1]s)
+			%[1]s * %[2]d as timestamp,
+			nodes,
+			up_nodes,
+			farms,
+			countries,
+			total_cru,
+			total_sru,
+			total_mru,
+			total_hru,
+			public_ips,
+			access_nodes,
+			gateways,
+			twins,
+			contracts
+		FROM grid_stats_history
+		WHERE grid_stats_history.timestamp >= ? AND grid_stats_history.timestamp < ?
+		ORDER BY %[1]s, grid_stats_history.timestamp DESC
+	) buckets
+	ORDER BY timestamp`, bucket, interval), from, to).Scan(&snapshots)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "couldn't get stats history")
+	}
+	return snapshots, nil
+}
+
+// GetActiveContractStates returns the states of the contracts that aren't deleted
+func (d *PostgresDatabase) GetActiveContractStates() ([]ContractState, error) {
+	var states []ContractState
+	res := d.gormDB.
+		Table(`(SELECT contract_id, 'node' AS type, twin_id, node_id, state FROM node_contract WHERE state != 'Deleted'
+		UNION ALL
+		SELECT contract_id, 'rent' AS type, twin_id, node_id, state FROM rent_contract WHERE state != 'Deleted'
+		UNION ALL
+		SELECT contract_id, 'name' AS type, twin_id, 0, state FROM name_contract WHERE state != 'Deleted') contracts`).
+		Select(
+			"contracts.contract_id",
+			"contracts.type",
+			"contracts.twin_id",
+			"contracts.node_id",
+			"COALESCE(node.farm_id, 0) as farm_id",
+			"contracts.state",
+		).
+		Joins("LEFT JOIN node ON contracts.node_id != 0 AND node.node_id = contracts.node_id").
+		Scan(&states)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "couldn't get contract states")
+	}
+	return states, nil
+}
+
+// GetReservedPublicIPs returns the public ips used by contracts with the contracts using them
+func (d *PostgresDatabase) GetReservedPublicIPs() ([]PublicIP, error) {
+	var ips []PublicIP
+	res := d.gormDB.
+		Table("public_ip").
+		Select(
+			"public_ip.id",
+			"public_ip.ip",
+			"COALESCE(farm.farm_id, 0) as farm_id",
+			"COALESCE(public_ip.contract_id, 0) as contract_id",
+		).
+		Joins("LEFT JOIN farm ON public_ip.farm_id = farm.id").
+		Where("COALESCE(public_ip.contract_id, 0) != 0").
+		Scan(&ips)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "couldn't get public ips")
+	}
+	return ips, nil
+}
+
+// CreateWebhookSubscription stores the subscription and sets its id and creation time
+func (d *PostgresDatabase) CreateWebhookSubscription(subscription *WebhookSubscription) error {
+	subscription.CreatedAt = time.Now().Unix()
+	res := d.gormDB.Table("webhook_subscription").Create(subscription)
+	return errors.Wrap(res.Error, "couldn't create webhook subscription")
+}
+
+// GetWebhookSubscription returns the webhook subscription with the given id
+func (d *PostgresDatabase) GetWebhookSubscription(id uint64) (WebhookSubscription, error) {
+	var subscription WebhookSubscription
+	res := d.gormDB.Table("webhook_subscription").Where("id = ?", id).Scan(&subscription)
+	if res.Error != nil {
+		return subscription, errors.Wrap(res.Error, "failed to scan returned webhook subscription from database")
+	}
+	if subscription.ID == 0 {
+		return subscription, ErrWebhookNotFound
+	}
+	return subscription, nil
+}
+
+// GetWebhookSubscriptions returns all the webhook subscriptions ordered by id
+func (d *PostgresDatabase) GetWebhookSubscriptions() ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	res := d.gormDB.Table("webhook_subscription").Order("id").Scan(&subscriptions)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "couldn't get webhook subscriptions")
+	}
+	return subscriptions, nil
+}
+
+// DeleteWebhookSubscription deletes the webhook subscription and its delivery log
+func (d *PostgresDatabase) DeleteWebhookSubscription(id uint64) error {
+	res := d.gormDB.Exec("DELETE FROM webhook_subscription WHERE id = ?", id)
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "couldn't delete webhook subscription")
+	}
+	if res.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// InsertWebhookDelivery adds the delivery attempt to the delivery log
+func (d *PostgresDatabase) InsertWebhookDelivery(delivery WebhookDelivery) error {
+	res := d.gormDB.Table("webhook_delivery").Create(&delivery)
+	return errors.Wrap(res.Error, "couldn't insert webhook delivery")
+}
+
+// GetWebhookDeliveries returns the delivery log of the webhook subscription, the latest attempts first
+func (d *PostgresDatabase) GetWebhookDeliveries(subscriptionID uint64, limit types.Limit) ([]WebhookDelivery, uint, error) {
+	q := d.gormDB.
+		Table("webhook_delivery").
+		Select(
+			"id",
+			"subscription_id",
+			"event_id",
+			"event_type",
+			"attempt",
+			"status_code",
+			"success",
+			"error",
+			"timestamp",
+		).
+		Where("subscription_id = ?", subscriptionID)
+	var count int64
+	if limit.RetCount {
+		if res := q.Count(&count); res.Error != nil {
+			return nil, 0, errors.Wrap(res.Error, "couldn't get webhook delivery count")
+		}
+	}
+	var deliveries []WebhookDelivery
+	res := q.
+		Order("id DESC").
+		Limit(int(limit.Size)).
+		Offset(int(limit.Page-1) * int(limit.Size)).
+		Scan(&deliveries)
+	if res.Error != nil {
+		return nil, 0, errors.Wrap(res.Error, "couldn't get webhook deliveries")
+	}
+	return deliveries, uint(count), nil
+}
+
+// RecordChanges logs the nodes, farms, twins and contracts created or modified since the last run with
+// the given timestamp, and returns the number of logged changes. the first run logs all the objects. only one
+// instance records the changes at a time, the run is skipped if another instance is recording them
+func (d *PostgresDatabase) RecordChanges(timestamp int64) (int64, error) {
+	var count int64
+	err := d.gormDB.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if res := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", changesLockKey).Scan(&locked); res.Error != nil {
+			return errors.Wrap(res.Error, "couldn't lock the change log")
+		}
+		if !locked {
+			return nil
+		}
+		res := tx.Exec(fmt.Sprintf(recordChangesQuery, d.nodeStatus.statusColumn(time.Unix(timestamp, 0))), timestamp)
+		if res.Error != nil {
+			return errors.Wrap(res.Error, "couldn't record changes")
+		}
+		count = res.RowsAffected
+		return nil
+	})
+	return count, err
+}
+
+// PruneChanges deletes the changes logged before the given timestamp, and returns the number of deleted changes
+func (d *PostgresDatabase) PruneChanges(before int64) (int64, error) {
+	res := d.gormDB.Exec("DELETE FROM change_log WHERE timestamp < ?", before)
+	if res.Error != nil {
+		return 0, errors.Wrap(res.Error, "couldn't prune changes")
+	}
+	return res.RowsAffected, nil
+}
+
+// GetChanges returns the first size changes logged after the change with the given id
+func (d *PostgresDatabase) GetChanges(after uint64, size uint64) ([]Change, error) {
+	var changes []Change
+	res := d.gormDB.
+		Table("change_log").
+		Select(
+			"id",
+			"kind",
+			"object_id",
+			"timestamp",
+		).
+		Where("id > ?", after).
+		Order("id").
+		Limit(int(size)).
+		Scan(&changes)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "couldn't get changes")
+	}
+	return changes, nil
+}
+
+// GetLastChangeID returns the id of the last logged change, or 0 if there are no changes
+func (d *PostgresDatabase) GetLastChangeID() (uint64, error) {
+	var id uint64
+	res := d.gormDB.Table("change_log").Select("COALESCE(MAX(id), 0)").Scan(&id)
+	if res.Error != nil {
+		return 0, errors.Wrap(res.Error, "couldn't get the last change id")
+	}
+	return id, nil
+}
+
+// spendingKeys are the group key of the bill reports for each grouping
+var spendingKeys = map[string]string{
+	SpendingByDay:      "(contract_bill_report.timestamp - contract_bill_report.timestamp % 86400)",
+	SpendingByMonth:    "EXTRACT(EPOCH FROM date_trunc('month', to_timestamp(contract_bill_report.timestamp) AT TIME ZONE 'UTC'))",
+	SpendingByContract: "contract_bill_report.contract_id",
+	SpendingByNode:     "contracts.node_id",
+}
+
+// billReportsQuery returns the sums of the bill reports of the node, rent and name contracts in the [from, to)
+// range grouped by the given grouping and the discount level
+func (d *PostgresDatabase) billReportsQuery(from, to int64, groupBy string) (*gorm.DB, error) {
+	key, ok := spendingKeys[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown spending grouping %s", groupBy)
+	}
+	return d.gormDB.
+		Table("contract_bill_report").
+		Select(
+			fmt.Sprintf("%s::bigint as key", key),
+			"contract_bill_report.discount_received",
+			"SUM(contract_bill_report.amount_billed)::bigint as amount_billed",
+			"COUNT(*) as reports",
+		).
+		Joins(`JOIN (SELECT contract_id, twin_id, node_id FROM node_contract
+		UNION
+		SELECT contract_id, twin_id, node_id FROM rent_contract
+		UNION
+		SELECT contract_id, twin_id, 0 FROM name_contract) contracts
+		ON contracts.contract_id = contract_bill_report.contract_id`).
+		Where("contract_bill_report.timestamp >= ? AND contract_bill_report.timestamp < ?", from, to).
+		Group("1, 2").
+		Order("1, 2"), nil
+}
+
+// GetTwinSpending returns the sums of the bill reports of the twin contracts in the [from, to) range,
+// grouped by the given grouping and the discount level
+func (d *PostgresDatabase) GetTwinSpending(twinID uint64, from, to int64, groupBy string) ([]SpendingReport, error) {
+	q, err := d.billReportsQuery(from, to, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	var reports []SpendingReport
+	if res := q.Where("contracts.twin_id = ?", twinID).Scan(&reports); res.Error != nil {
+		return nil, errors.Wrap(res.Error, "couldn't get twin spending")
+	}
+	return reports, nil
+}
+
+// GetFarmRevenue returns the sums of the bill reports of the node and rent contracts on the farm nodes
+// in the [from, to) range, grouped by node and the discount level
+func (d *PostgresDatabase) GetFarmRevenue(farmID uint64, from, to int64) ([]SpendingReport, error) {
+	q, err := d.billReportsQuery(from, to, SpendingByNode)
+	if err != nil {
+		return nil, err
+	}
+	var reports []SpendingReport
+	res := q.Joins("JOIN node ON node.node_id = contracts.node_id").
+		Where("node.farm_id = ?", farmID).
+		Scan(&reports)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "couldn't get farm revenue")
+	}
+	return reports, nil
+}
+
 // GetTwins returns twins filtered and paginated
 func (d *PostgresDatabase) GetTwins(filter types.TwinFilter, limit types.Limit) ([]types.Twin, uint, error) {
 	q := d.gormDB.
@@ -579,6 +1288,15 @@ func (d *PostgresDatabase) GetTwins(filter types.TwinFilter, limit types.Limit)
 	if filter.PublicKey != nil {
 		q = q.Where("public_key = ?", *filter.PublicKey)
 	}
+	if len(filter.TwinIDs) != 0 {
+		q = q.Where("twin_id IN ?", filter.TwinIDs)
+	}
+	if filter.RelayContains != nil {
+		q = q.Where("relay ILIKE '%' || ? || '%'", *filter.RelayContains)
+	}
+	if len(filter.AccountIDs) != 0 {
+		q = q.Where("account_id IN ?", filter.AccountIDs)
+	}
 	var count int64
 	if limit.Randomize || limit.RetCount {
 		if res := q.Count(&count); res.Error != nil {
@@ -663,6 +1381,48 @@ func (d *PostgresDatabase) GetContracts(filter types.ContractFilter, limit types
 	if filter.DeploymentHash != nil {
 		q = q.Where("deployment_hash = ?", *filter.DeploymentHash)
 	}
+	if len(filter.States) != 0 {
+		states := make([]string, len(filter.States))
+		for idx, state := range filter.States {
+			states[idx] = strings.ToLower(state)
+		}
+		q = q.Where("LOWER(state) IN ?", states)
+	}
+	if len(filter.Types) != 0 {
+		contractTypes := make([]string, len(filter.Types))
+		for idx, contractType := range filter.Types {
+			contractTypes[idx] = strings.ToLower(contractType)
+		}
+		q = q.Where("type IN ?", contractTypes)
+	}
+	if filter.CreatedAfter != nil {
+		q = q.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		q = q.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if len(filter.NodeIDs) != 0 {
+		q = q.Where("node_id IN ?", filter.NodeIDs)
+	}
+	if len(filter.TwinIDs) != 0 {
+		q = q.Where("twin_id IN ?", filter.TwinIDs)
+	}
+	if filter.FarmID != nil {
+		q = q.Where("node_id IN (SELECT node_id FROM node WHERE farm_id = ?)", *filter.FarmID)
+	}
+	if filter.HasPublicIPs != nil {
+		if *filter.HasPublicIPs {
+			q = q.Where("number_of_public_i_ps > 0")
+		} else {
+			q = q.Where("number_of_public_i_ps = 0")
+		}
+	}
+	if filter.NameContains != nil {
+		q = q.Where("name ILIKE '%' || ? || '%'", *filter.NameContains)
+	}
+	if len(filter.ContractIDs) != 0 {
+		q = q.Where("contracts.contract_id IN ?", filter.ContractIDs)
+	}
 	var count int64
 	if limit.Randomize || limit.RetCount {
 		if res := q.Count(&count); res.Error != nil {
@@ -683,3 +1443,117 @@ func (d *PostgresDatabase) GetContracts(filter types.ContractFilter, limit types
 	}
 	return contracts, uint(count), nil
 }
+
+// GetNodeContracts returns the active node contracts on a node with their used resources
+func (d *PostgresDatabase) GetNodeContracts(nodeID uint32) ([]NodeContract, error) {
+	q := d.gormDB.
+		Table("node_contract").
+		Select(
+			"node_contract.contract_id",
+			"node_contract.twin_id",
+			"node_contract.state",
+			"node_contract.created_at",
+			"node_contract.deployment_data",
+			"node_contract.deployment_hash",
+			"node_contract.number_of_public_i_ps as number_of_public_ips",
+			"COALESCE(contract_resources.cru, 0) as cru",
+			"COALESCE(contract_resources.sru, 0) as sru",
+			"COALESCE(contract_resources.hru, 0) as hru",
+			"COALESCE(contract_resources.mru, 0) as mru",
+		).
+		Joins("LEFT JOIN contract_resources ON node_contract.resources_used_id = contract_resources.id").
+		Where("node_contract.node_id = ?", nodeID).
+		Where("node_contract.state IN ('Created', 'GracePeriod')").
+		Order("node_contract.contract_id")
+	var contracts []NodeContract
+	if res := q.Scan(&contracts); res.Error != nil {
+		return contracts, errors.Wrap(res.Error, "failed to scan returned node contracts from database")
+	}
+	return contracts, nil
+}
+
+func (d *PostgresDatabase) pricingPolicyQuery() *gorm.DB {
+	return d.gormDB.
+		Table("pricing_policy").
+		Select(
+			"pricing_policy_id",
+			"name",
+			"COALESCE((su->>'value')::bigint, 0) as su",
+			"COALESCE(su->>'unit', '') as su_unit",
+			"COALESCE((cu->>'value')::bigint, 0) as cu",
+			"COALESCE(cu->>'unit', '') as cu_unit",
+			"COALESCE((nu->>'value')::bigint, 0) as nu",
+			"COALESCE(nu->>'unit', '') as nu_unit",
+			"COALESCE((ipu->>'value')::bigint, 0) as ipu",
+			"COALESCE(ipu->>'unit', '') as ipu_unit",
+			"foundation_account",
+			"certified_sales_account",
+			"dedicated_node_discount",
+		)
+}
+
+// GetPricingPolicy returns the pricing policy with the given id
+func (d *PostgresDatabase) GetPricingPolicy(policyID uint32) (PricingPolicy, error) {
+	q := d.pricingPolicyQuery().Where("pricing_policy_id = ?", policyID)
+	var policy PricingPolicy
+	if res := q.Scan(&policy); res.Error != nil {
+		return policy, errors.Wrap(res.Error, "failed to scan returned pricing policy from database")
+	}
+	if policy.PricingPolicyID == 0 {
+		return policy, ErrPricingPolicyNotFound
+	}
+	return policy, nil
+}
+
+// GetPricingPolicies returns all the pricing policies ordered by id
+func (d *PostgresDatabase) GetPricingPolicies() ([]PricingPolicy, error) {
+	q := d.pricingPolicyQuery().Order("pricing_policy_id")
+	var policies []PricingPolicy
+	if res := q.Scan(&policies); res.Error != nil {
+		return policies, errors.Wrap(res.Error, "failed to scan returned pricing policies from database")
+	}
+	return policies, nil
+}
+
+func (d *PostgresDatabase) farmingPolicyQuery() *gorm.DB {
+	return d.gormDB.
+		Table("farming_policy").
+		Select(
+			"farming_policy_id",
+			"COALESCE(name, '') as name",
+			"COALESCE(cu, 0) as cu",
+			"COALESCE(su, 0) as su",
+			"COALESCE(nu, 0) as nu",
+			"COALESCE(ipv4, 0) as ipv4",
+			"COALESCE(minimal_uptime, 0) as minimal_uptime",
+			"COALESCE(policy_created, 0) as policy_created",
+			"COALESCE(policy_end, 0) as policy_end",
+			"COALESCE(immutable, false) as immutable",
+			`COALESCE("default", false) as "default"`,
+			"COALESCE(node_certification, '') as node_certification",
+			"COALESCE(farm_certification, '') as farm_certification",
+		)
+}
+
+// GetFarmingPolicy returns the farming policy with the given id
+func (d *PostgresDatabase) GetFarmingPolicy(policyID uint32) (FarmingPolicy, error) {
+	q := d.farmingPolicyQuery().Where("farming_policy_id = ?", policyID)
+	var policy FarmingPolicy
+	if res := q.Scan(&policy); res.Error != nil {
+		return policy, errors.Wrap(res.Error, "failed to scan returned farming policy from database")
+	}
+	if policy.FarmingPolicyID == 0 {
+		return policy, ErrFarmingPolicyNotFound
+	}
+	return policy, nil
+}
+
+// GetFarmingPolicies returns all the farming policies ordered by id
+func (d *PostgresDatabase) GetFarmingPolicies() ([]FarmingPolicy, error) {
+	q := d.farmingPolicyQuery().Order("farming_policy_id")
+	var policies []FarmingPolicy
+	if res := q.Scan(&policies); res.Error != nil {
+		return policies, errors.Wrap(res.Error, "failed to scan returned farming policies from database")
+	}
+	return policies, nil
+}
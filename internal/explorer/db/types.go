@@ -7,12 +7,33 @@ import (
 // Database interface for storing and fetching grid info
 type Database interface {
 	GetCounters(filter types.StatsFilter) (types.Counters, error)
-	GetNode(nodeID uint32) (Node, error)
+	GetNode(nodeID uint32, fields ...string) (Node, error)
 	GetFarm(farmID uint32) (Farm, error)
-	GetNodes(filter types.NodeFilter, limit types.Limit) ([]Node, uint, error)
-	GetFarms(filter types.FarmFilter, limit types.Limit) ([]Farm, uint, error)
+	GetNodes(filter types.NodeFilter, limit types.Limit, fields ...string) ([]Node, uint, error)
+	GetFarms(filter types.FarmFilter, limit types.Limit, fields ...string) ([]Farm, uint, error)
 	GetTwins(filter types.TwinFilter, limit types.Limit) ([]types.Twin, uint, error)
 	GetContracts(filter types.ContractFilter, limit types.Limit) ([]DBContract, uint, error)
+	GetNodeContracts(nodeID uint32) ([]NodeContract, error)
+	GetPricingPolicy(policyID uint32) (PricingPolicy, error)
+	GetPricingPolicies() ([]PricingPolicy, error)
+	GetFarmingPolicy(policyID uint32) (FarmingPolicy, error)
+	GetFarmingPolicies() ([]FarmingPolicy, error)
+	InsertStatsSnapshot(snapshot StatsSnapshot) error
+	GetStatsHistory(from, to, interval int64) ([]StatsSnapshot, error)
+	GetActiveContractStates() ([]ContractState, error)
+	GetReservedPublicIPs() ([]PublicIP, error)
+	CreateWebhookSubscription(subscription *WebhookSubscription) error
+	GetWebhookSubscription(id uint64) (WebhookSubscription, error)
+	GetWebhookSubscriptions() ([]WebhookSubscription, error)
+	DeleteWebhookSubscription(id uint64) error
+	InsertWebhookDelivery(delivery WebhookDelivery) error
+	GetWebhookDeliveries(subscriptionID uint64, limit types.Limit) ([]WebhookDelivery, uint, error)
+	RecordChanges(timestamp int64) (int64, error)
+	PruneChanges(before int64) (int64, error)
+	GetChanges(after uint64, size uint64) ([]Change, error)
+	GetLastChangeID() (uint64, error)
+	GetTwinSpending(twinID uint64, from, to int64, groupBy string) ([]SpendingReport, error)
+	GetFarmRevenue(farmID uint64, from, to int64) ([]SpendingReport, error)
 }
 
 // DBContract is contract info
@@ -30,6 +51,21 @@ type DBContract struct {
 	ContractBillings  string
 }
 
+// NodeContract is an active node contract with the resources it uses
+type NodeContract struct {
+	ContractID        uint
+	TwinID            uint
+	State             string
+	CreatedAt         uint
+	DeploymentData    string
+	DeploymentHash    string
+	NumberOfPublicIps uint
+	Cru               uint64
+	Sru               uint64
+	Hru               uint64
+	Mru               uint64
+}
+
 // Node data about a node which is calculated from the chain
 type Node struct {
 	ID              string
@@ -43,6 +79,7 @@ type Node struct {
 	Created         int64
 	FarmingPolicyID int64
 	UpdatedAt       int64
+	Status          string
 	TotalCru        int64
 	TotalMru        int64
 	TotalSru        int64
@@ -61,10 +98,48 @@ type Node struct {
 	RentContractID  int64
 	RentedByTwinID  int64
 	SerialNumber    string
+	Gpus            string
+	Secure          bool
+	Virtualized     bool
+	Interfaces      string
 	Longitude       *float64
 	Latitude        *float64
 }
 
+// PricingPolicy is the prices of the resources in units of 1e-7 USD per hour
+type PricingPolicy struct {
+	PricingPolicyID       uint32
+	Name                  string
+	SU                    uint64
+	SUUnit                string
+	CU                    uint64
+	CUUnit                string
+	NU                    uint64
+	NUUnit                string
+	IPU                   uint64 `gorm:"column:ipu"`
+	IPUUnit               string `gorm:"column:ipu_unit"`
+	FoundationAccount     string
+	CertifiedSalesAccount string
+	DedicatedNodeDiscount uint8
+}
+
+// FarmingPolicy is the rewards of the resources provided by a node
+type FarmingPolicy struct {
+	FarmingPolicyID   uint32
+	Name              string
+	CU                uint64
+	SU                uint64
+	NU                uint64
+	IPv4              uint64
+	MinimalUptime     uint64
+	PolicyCreated     uint64
+	PolicyEnd         uint64
+	Immutable         bool
+	Default           bool
+	NodeCertification string
+	FarmCertification string
+}
+
 // Farm data about a farm which is calculated from the chain
 type Farm struct {
 	Name            string
@@ -82,3 +157,99 @@ type NodesDistribution struct {
 	Country string `json:"country"`
 	Nodes   int64  `json:"nodes"`
 }
+
+// StatsSnapshot is the grid counters stored at a point in time
+type StatsSnapshot struct {
+	Timestamp   int64
+	Nodes       int64
+	UpNodes     int64
+	Farms       int64
+	Countries   int64
+	TotalCru    int64
+	TotalSru    int64
+	TotalMru    int64
+	TotalHru    int64
+	PublicIps   int64
+	AccessNodes int64
+	Gateways    int64
+	Twins       int64
+	Contracts   int64
+}
+
+// ContractState is the state of a contract, the node and farm are set for node and rent contracts
+type ContractState struct {
+	ContractID uint
+	Type       string
+	TwinID     uint
+	NodeID     uint
+	FarmID     uint
+	State      string
+}
+
+// PublicIP is a public ip of a farm and the contract using it
+type PublicIP struct {
+	ID         string
+	IP         string
+	FarmID     uint
+	ContractID uint
+}
+
+// WebhookSubscription is a url the matching events are posted to, the filter is json encoded
+type WebhookSubscription struct {
+	ID        uint64
+	URL       string
+	Secret    string
+	Filter    string
+	CreatedAt int64
+}
+
+// WebhookDelivery is an attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID             uint64
+	SubscriptionID uint64
+	EventID        string
+	EventType      string
+	Attempt        int
+	StatusCode     int
+	Success        bool
+	Error          string
+	Timestamp      int64
+}
+
+const (
+	// ChangeNode is the kind of the node changes, the object id is the node id
+	ChangeNode = "node"
+	// ChangeFarm is the kind of the farm changes, the object id is the farm id
+	ChangeFarm = "farm"
+	// ChangeTwin is the kind of the twin changes, the object id is the twin id
+	ChangeTwin = "twin"
+	// ChangeContract is the kind of the contract changes, the object id is the contract id
+	ChangeContract = "contract"
+)
+
+// Change is an object created or modified between two runs of RecordChanges
+type Change struct {
+	ID        uint64
+	Kind      string
+	ObjectID  uint64
+	Timestamp int64
+}
+
+const (
+	// SpendingByDay groups the bill reports by the UTC day, the key is the start of the day
+	SpendingByDay = "day"
+	// SpendingByMonth groups the bill reports by the UTC month, the key is the start of the month
+	SpendingByMonth = "month"
+	// SpendingByContract groups the bill reports by contract, the key is the contract id
+	SpendingByContract = "contract"
+	// SpendingByNode groups the bill reports by the node of the contracts, the key is the node id and it's 0 for name contracts
+	SpendingByNode = "node"
+)
+
+// SpendingReport is the sum of the bill reports of a twin in a group with the same discount level
+type SpendingReport struct {
+	Key              uint64
+	DiscountReceived string
+	AmountBilled     uint64
+	Reports          uint64
+}
@@ -0,0 +1,195 @@
+package explorer
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+const (
+	// DefaultEventsPollInterval is the default interval between the database polls detecting the node changes
+	DefaultEventsPollInterval = 30 * time.Second
+	// nodeEventsBufferSize is the number of the last events kept to resume the streams with Last-Event-ID
+	nodeEventsBufferSize = 1000
+	// nodeEventsSubscriberBuffer is the number of events queued for a subscriber before it's dropped
+	nodeEventsSubscriberBuffer = 100
+)
+
+// nodeEventFields are the node fields queried to detect the changes
+var nodeEventFields = []string{"nodeId", "farmId", "twinId", "status", "rentedByTwinId", "total_resources", "used_resources"}
+
+// NodeEvents detects the changes of the nodes between database polls and broadcasts them to the subscribers
+type NodeEvents struct {
+	db       db.Database
+	interval time.Duration
+	// nodes is the state of the nodes in the last poll, it's only used by the polling goroutine
+	nodes map[int]types.NodeState
+
+	mu sync.Mutex
+	// buffer has the last events in order
+	buffer []types.NodeEvent
+	// lastID is the id of the last event, the ids start at the startup time so
+	// the ids a caller got before a restart are older than the new ones
+	lastID      uint64
+	subscribers map[chan types.NodeEvent]struct{}
+}
+
+// NewNodeEvents creates a new node change detector polling the database every interval
+func NewNodeEvents(database db.Database, interval time.Duration) *NodeEvents {
+	return &NodeEvents{
+		db:          database,
+		interval:    interval,
+		lastID:      uint64(time.Now().UnixNano()),
+		subscribers: make(map[chan types.NodeEvent]struct{}),
+	}
+}
+
+// Run polls the database every interval until the context is canceled, the first poll
+// only records the state of the nodes
+func (e *NodeEvents) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		if err := e.poll(time.Now()); err != nil {
+			log.Error().Err(err).Msg("failed to detect node changes")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *NodeEvents) poll(now time.Time) error {
+	dbNodes, _, err := e.db.GetNodes(types.NodeFilter{}, types.Limit{Page: 1, Size: math.MaxInt32}, nodeEventFields...)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get nodes")
+	}
+	nodes := make(map[int]types.NodeState, len(dbNodes))
+	for _, dbNode := range dbNodes {
+		node := nodeFromDBNode(dbNode)
+		current := types.NodeState{
+			Status:         node.Status,
+			RentedByTwinID: node.RentedByTwinID,
+			TotalResources: node.TotalResources,
+			UsedResources:  node.UsedResources,
+		}
+		nodes[node.NodeID] = current
+		previous, ok := e.nodes[node.NodeID]
+		if !ok {
+			continue
+		}
+		event := types.NodeEvent{
+			Timestamp: now.Unix(),
+			NodeID:    node.NodeID,
+			FarmID:    node.FarmID,
+			TwinID:    node.TwinID,
+			Previous:  previous,
+			Current:   current,
+		}
+		if previous.Status != current.Status {
+			event.Type = types.NodeEventStatus
+			e.publish(event)
+		}
+		if previous.RentedByTwinID != current.RentedByTwinID {
+			event.Type = types.NodeEventRent
+			e.publish(event)
+		}
+		if previous.TotalResources != current.TotalResources || previous.UsedResources != current.UsedResources {
+			event.Type = types.NodeEventCapacity
+			e.publish(event)
+		}
+	}
+	e.nodes = nodes
+	return nil
+}
+
+// publish sets the id of the event, buffers it and sends it to the subscribers.
+// a subscriber that can't keep up is dropped, it can resume from the buffer
+func (e *NodeEvents) publish(event types.NodeEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastID++
+	event.ID = e.lastID
+	e.buffer = append(e.buffer, event)
+	if len(e.buffer) > nodeEventsBufferSize {
+		e.buffer = e.buffer[len(e.buffer)-nodeEventsBufferSize:]
+	}
+	for ch := range e.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(e.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns the buffered events after the last event id if it's set, and the channel
+// of the new events. the channel is closed by unsubscribe or if the subscriber is dropped
+func (e *NodeEvents) subscribe(lastEventID *uint64) ([]types.NodeEvent, <-chan types.NodeEvent, func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var backlog []types.NodeEvent
+	if lastEventID != nil {
+		for _, event := range e.buffer {
+			if event.ID > *lastEventID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+	ch := make(chan types.NodeEvent, nodeEventsSubscriberBuffer)
+	e.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, ok := e.subscribers[ch]; ok {
+			delete(e.subscribers, ch)
+			close(ch)
+		}
+	}
+	return backlog, ch, unsubscribe
+}
+
+// nodeEventMatches checks if the event satisfies the filter
+func nodeEventMatches(filter types.NodeEventFilter, event types.NodeEvent) bool {
+	if filter.FarmID != nil && *filter.FarmID != uint64(event.FarmID) {
+		return false
+	}
+	if len(filter.NodeIDs) != 0 && !isIn(filter.NodeIDs, uint64(event.NodeID)) {
+		return false
+	}
+	if filter.TwinID != nil && *filter.TwinID != uint64(event.TwinID) {
+		return false
+	}
+	if len(filter.Types) != 0 && !isOneOf(event.Type, filter.Types) {
+		return false
+	}
+	return true
+}
+
+// lastEventID returns the id of the last event the caller got from the Last-Event-ID header,
+// or the last_event_id parameter for the callers that can't set headers
+func lastEventID(r *http.Request) (*uint64, error) {
+	param, value := "Last-Event-ID", r.Header.Get("Last-Event-ID")
+	if value == "" {
+		param, value = "last_event_id", r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, paramError(param, "invalid event id %s: %s", value, err.Error())
+	}
+	return &id, nil
+}
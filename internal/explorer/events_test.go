@@ -0,0 +1,162 @@
+package explorer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+// receivedEvents returns the events queued on the channel without waiting
+func receivedEvents(ch <-chan types.NodeEvent) []types.NodeEvent {
+	var events []types.NodeEvent
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestNodeEventsPoll(t *testing.T) {
+	database := &fakeDatabase{nodes: []db.Node{
+		{NodeID: 1, FarmID: 10, TwinID: 11, Status: "up", TotalCru: 8},
+		{NodeID: 2, FarmID: 10, TwinID: 12, Status: "up", TotalCru: 8},
+		{NodeID: 3, FarmID: 20, TwinID: 13, Status: "up", TotalCru: 8, UsedCru: 1},
+		{NodeID: 4, FarmID: 20, TwinID: 14, Status: "standby", TotalCru: 8},
+		{NodeID: 5, FarmID: 20, TwinID: 15, Status: "up", TotalCru: 8},
+	}}
+	e := NewNodeEvents(database, time.Minute)
+	_, ch, unsubscribe := e.subscribe(nil)
+	defer unsubscribe()
+	if err := e.poll(time.Unix(100, 0)); err != nil {
+		t.Fatalf("failed to poll: %s", err.Error())
+	}
+	if events := receivedEvents(ch); len(events) != 0 || len(e.buffer) != 0 {
+		t.Fatalf("events of the first poll: %+v", events)
+	}
+
+	database.nodes[0].Status = "down"
+	database.nodes[1].RentedByTwinID = 7
+	database.nodes[2].UsedCru = 4
+	database.nodes[3].Status = "up"
+	database.nodes[3].RentedByTwinID = 8
+	database.nodes = append(database.nodes, db.Node{NodeID: 6, FarmID: 20, TwinID: 16, Status: "up"})
+	if err := e.poll(time.Unix(200, 0)); err != nil {
+		t.Fatalf("failed to poll: %s", err.Error())
+	}
+	events := receivedEvents(ch)
+	expected := []struct {
+		nodeID    int
+		eventType string
+	}{
+		{1, types.NodeEventStatus},
+		{2, types.NodeEventRent},
+		{3, types.NodeEventCapacity},
+		{4, types.NodeEventStatus},
+		{4, types.NodeEventRent},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("events count mismatch: expected: %d, found: %d: %+v", len(expected), len(events), events)
+	}
+	for idx, event := range events {
+		if event.NodeID != expected[idx].nodeID || event.Type != expected[idx].eventType || event.Timestamp != 200 {
+			t.Fatalf("event %d mismatch: expected: %s of node %d, found: %+v", idx, expected[idx].eventType, expected[idx].nodeID, event)
+		}
+		if idx != 0 && event.ID != events[idx-1].ID+1 {
+			t.Fatalf("event ids aren't sequential: %d after %d", event.ID, events[idx-1].ID)
+		}
+	}
+	if status := events[0]; status.Previous.Status != "up" || status.Current.Status != "down" || status.FarmID != 10 || status.TwinID != 11 {
+		t.Fatalf("status event mismatch: %+v", status)
+	}
+	if rent := events[1]; rent.Previous.RentedByTwinID != 0 || rent.Current.RentedByTwinID != 7 {
+		t.Fatalf("rent event mismatch: %+v", rent)
+	}
+	if capacity := events[2]; capacity.Previous.UsedResources.CRU != 1 || capacity.Current.UsedResources.CRU != 4 || capacity.Current.TotalResources.CRU != 8 {
+		t.Fatalf("capacity event mismatch: %+v", capacity)
+	}
+	if !reflect.DeepEqual(e.buffer, events) {
+		t.Fatalf("buffered events mismatch: %+v", e.buffer)
+	}
+
+	if err := e.poll(time.Unix(300, 0)); err != nil {
+		t.Fatalf("failed to poll: %s", err.Error())
+	}
+	if events := receivedEvents(ch); len(events) != 0 {
+		t.Fatalf("events with no changes: %+v", events)
+	}
+}
+
+func TestNodeEventsBacklog(t *testing.T) {
+	e := NewNodeEvents(&fakeDatabase{}, time.Minute)
+	for nodeID := 1; nodeID <= 5; nodeID++ {
+		e.publish(types.NodeEvent{Type: types.NodeEventStatus, NodeID: nodeID})
+	}
+	firstID := e.buffer[0].ID
+	tests := []struct {
+		lastEventID *uint64
+		nodeIDs     []int
+	}{
+		{nil, nil},
+		{&firstID, []int{2, 3, 4, 5}},
+		{&e.buffer[3].ID, []int{5}},
+		{&e.lastID, nil},
+	}
+	for _, test := range tests {
+		backlog, _, unsubscribe := e.subscribe(test.lastEventID)
+		unsubscribe()
+		var nodeIDs []int
+		for _, event := range backlog {
+			nodeIDs = append(nodeIDs, event.NodeID)
+		}
+		if !reflect.DeepEqual(nodeIDs, test.nodeIDs) {
+			t.Fatalf("backlog mismatch: expected the events of nodes %v, found: %v", test.nodeIDs, nodeIDs)
+		}
+	}
+
+	for i := 0; i < nodeEventsBufferSize; i++ {
+		e.publish(types.NodeEvent{Type: types.NodeEventRent, NodeID: 6})
+	}
+	if len(e.buffer) != nodeEventsBufferSize || e.buffer[0].NodeID != 6 || e.buffer[len(e.buffer)-1].ID != e.lastID {
+		t.Fatalf("buffer isn't trimmed to the last %d events: %d events from node %d", nodeEventsBufferSize, len(e.buffer), e.buffer[0].NodeID)
+	}
+	backlog, _, unsubscribe := e.subscribe(&firstID)
+	defer unsubscribe()
+	if len(backlog) != nodeEventsBufferSize {
+		t.Fatalf("backlog of a trimmed buffer mismatch: expected: %d, found: %d", nodeEventsBufferSize, len(backlog))
+	}
+}
+
+func TestNodeEventsSlowSubscriber(t *testing.T) {
+	e := NewNodeEvents(&fakeDatabase{}, time.Minute)
+	_, slow, unsubscribeSlow := e.subscribe(nil)
+	_, fast, unsubscribeFast := e.subscribe(nil)
+	defer unsubscribeFast()
+	var received int
+	for i := 0; i <= nodeEventsSubscriberBuffer; i++ {
+		e.publish(types.NodeEvent{Type: types.NodeEventStatus, NodeID: i})
+		received += len(receivedEvents(fast))
+	}
+	if received != nodeEventsSubscriberBuffer+1 {
+		t.Fatalf("events of the subscriber keeping up mismatch: expected: %d, found: %d", nodeEventsSubscriberBuffer+1, received)
+	}
+	if len(e.subscribers) != 1 {
+		t.Fatalf("slow subscriber isn't dropped: %d subscribers", len(e.subscribers))
+	}
+	queued := 0
+	for range slow {
+		queued++
+	}
+	if queued != nodeEventsSubscriberBuffer {
+		t.Fatalf("queued events of the dropped subscriber mismatch: expected: %d, found: %d", nodeEventsSubscriberBuffer, queued)
+	}
+	unsubscribeSlow()
+}
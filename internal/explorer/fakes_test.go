@@ -0,0 +1,248 @@
+package explorer
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+// fakeDatabase is a database with the queries the tests use, the other queries panic
+type fakeDatabase struct {
+	db.Database
+	nodes []db.Node
+	// nodesLimit is the limit of the last nodes query
+	nodesLimit types.Limit
+	// rentable are the ids of the rentable nodes
+	rentable        map[int64]bool
+	pricingPolicies []db.PricingPolicy
+	farmingPolicies []db.FarmingPolicy
+	// counters are the counters by the status filter, the empty status is of all the nodes
+	counters  map[string]types.Counters
+	snapshots []db.StatsSnapshot
+	// historyArgs are the from, to and interval of the last stats history query
+	historyArgs []int64
+	farms       []db.Farm
+	twins       []types.Twin
+	contracts   []db.DBContract
+	changes     []db.Change
+	// recorded and pruned are the timestamps the changes were recorded at and pruned before
+	recorded []int64
+	pruned   []int64
+	// spending are the bill reports of any twin, spendingArgs are the twin id, from, to and group by of the
+	// last spending query
+	spending     []db.SpendingReport
+	spendingArgs []interface{}
+	// revenue are the bill reports of any farm by node
+	revenue []db.SpendingReport
+	// queries counts the queries by name
+	queries map[string]int
+}
+
+func (d *fakeDatabase) count(query string) {
+	if d.queries == nil {
+		d.queries = make(map[string]int)
+	}
+	d.queries[query]++
+}
+
+func (d *fakeDatabase) GetNode(nodeID uint32, fields ...string) (db.Node, error) {
+	for _, node := range d.nodes {
+		if node.NodeID == int64(nodeID) {
+			return node, nil
+		}
+	}
+	return db.Node{}, db.ErrNodeNotFound
+}
+
+// GetNodes returns the page of the nodes in order, only the node ids, farm ids, rentable, domain and ipv4 filters are supported
+func (d *fakeDatabase) GetNodes(filter types.NodeFilter, limit types.Limit, fields ...string) ([]db.Node, uint, error) {
+	d.count("GetNodes")
+	d.nodesLimit = limit
+	var nodes []db.Node
+	for _, node := range d.nodes {
+		if len(filter.NodeIDs) != 0 && !isIn(filter.NodeIDs, uint64(node.NodeID)) {
+			continue
+		}
+		if len(filter.FarmIDs) != 0 && !isIn(filter.FarmIDs, uint64(node.FarmID)) {
+			continue
+		}
+		if filter.Rentable != nil && *filter.Rentable != d.rentable[node.NodeID] {
+			continue
+		}
+		if filter.Domain != nil && *filter.Domain != (node.Domain != "") {
+			continue
+		}
+		if filter.IPv4 != nil && *filter.IPv4 != (node.Ipv4 != "") {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	count := uint64(len(nodes))
+	start, end := (limit.Page-1)*limit.Size, limit.Page*limit.Size
+	if start > count {
+		start = count
+	}
+	if end > count {
+		end = count
+	}
+	return nodes[start:end], uint(count), nil
+}
+
+func (d *fakeDatabase) GetPricingPolicies() ([]db.PricingPolicy, error) {
+	d.count("GetPricingPolicies")
+	return d.pricingPolicies, nil
+}
+
+func (d *fakeDatabase) GetFarmingPolicies() ([]db.FarmingPolicy, error) {
+	d.count("GetFarmingPolicies")
+	return d.farmingPolicies, nil
+}
+
+func (d *fakeDatabase) GetCounters(filter types.StatsFilter) (types.Counters, error) {
+	d.count("GetCounters")
+	status := ""
+	if filter.Status != nil {
+		status = *filter.Status
+	}
+	return d.counters[status], nil
+}
+
+func (d *fakeDatabase) InsertStatsSnapshot(snapshot db.StatsSnapshot) error {
+	d.snapshots = append(d.snapshots, snapshot)
+	return nil
+}
+
+func (d *fakeDatabase) GetStatsHistory(from, to, interval int64) ([]db.StatsSnapshot, error) {
+	d.historyArgs = []int64{from, to, interval}
+	return d.snapshots, nil
+}
+
+// GetFarms returns the farms in order, only the farm ids filter is supported
+func (d *fakeDatabase) GetFarms(filter types.FarmFilter, limit types.Limit, fields ...string) ([]db.Farm, uint, error) {
+	d.count("GetFarms")
+	var farms []db.Farm
+	for _, farm := range d.farms {
+		if len(filter.FarmIDs) == 0 || isIn(filter.FarmIDs, uint64(farm.FarmID)) {
+			farms = append(farms, farm)
+		}
+	}
+	return farms, uint(len(farms)), nil
+}
+
+// GetTwins returns the twins in order, only the twin id and twin ids filters are supported
+func (d *fakeDatabase) GetTwins(filter types.TwinFilter, limit types.Limit) ([]types.Twin, uint, error) {
+	d.count("GetTwins")
+	var twins []types.Twin
+	for _, twin := range d.twins {
+		if filter.TwinID != nil && *filter.TwinID != uint64(twin.TwinID) {
+			continue
+		}
+		if len(filter.TwinIDs) == 0 || isIn(filter.TwinIDs, uint64(twin.TwinID)) {
+			twins = append(twins, twin)
+		}
+	}
+	return twins, uint(len(twins)), nil
+}
+
+// GetContracts returns the contracts in order, only the contract ids, type, name and states filters are supported
+func (d *fakeDatabase) GetContracts(filter types.ContractFilter, limit types.Limit) ([]db.DBContract, uint, error) {
+	d.count("GetContracts")
+	var contracts []db.DBContract
+	for _, contract := range d.contracts {
+		if len(filter.ContractIDs) != 0 && !isIn(filter.ContractIDs, uint64(contract.ContractID)) {
+			continue
+		}
+		if filter.Type != nil && *filter.Type != contract.Type {
+			continue
+		}
+		if filter.Name != nil && *filter.Name != contract.Name {
+			continue
+		}
+		if len(filter.States) != 0 && !isInStrs(filter.States, contract.State) {
+			continue
+		}
+		contracts = append(contracts, contract)
+	}
+	return contracts, uint(len(contracts)), nil
+}
+
+func (d *fakeDatabase) RecordChanges(timestamp int64) (int64, error) {
+	d.recorded = append(d.recorded, timestamp)
+	return 0, nil
+}
+
+func (d *fakeDatabase) PruneChanges(before int64) (int64, error) {
+	d.pruned = append(d.pruned, before)
+	return 0, nil
+}
+
+func (d *fakeDatabase) GetChanges(after uint64, size uint64) ([]db.Change, error) {
+	var changes []db.Change
+	for _, change := range d.changes {
+		if change.ID > after && uint64(len(changes)) < size {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (d *fakeDatabase) GetTwinSpending(twinID uint64, from, to int64, groupBy string) ([]db.SpendingReport, error) {
+	d.spendingArgs = []interface{}{twinID, from, to, groupBy}
+	return d.spending, nil
+}
+
+func (d *fakeDatabase) GetFarmRevenue(farmID uint64, from, to int64) ([]db.SpendingReport, error) {
+	return d.revenue, nil
+}
+
+// fakeRelay answers the calls with the response of the twin, or with its error
+type fakeRelay struct {
+	mu          sync.Mutex
+	responses   map[uint32]interface{}
+	errs        map[uint32]error
+	delay       time.Duration
+	calls       int
+	running     int
+	maxParallel int
+}
+
+func (r *fakeRelay) Call(ctx context.Context, twin uint32, fn string, data interface{}, result interface{}) error {
+	r.mu.Lock()
+	r.calls++
+	r.running++
+	if r.running > r.maxParallel {
+		r.maxParallel = r.running
+	}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.running--
+		r.mu.Unlock()
+	}()
+	time.Sleep(r.delay)
+
+	if err, ok := r.errs[twin]; ok {
+		return err
+	}
+	encoded, err := json.Marshal(r.responses[twin])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, result)
+}
+
+func testApp(database db.Database, relay *fakeRelay) *App {
+	a := &App{
+		db:       database,
+		lruCache: cache.New(time.Minute, time.Minute),
+	}
+	if relay != nil {
+		a.relayClient = relay
+	}
+	return a
+}
@@ -1,15 +1,21 @@
 package explorer
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
 	"github.com/threefoldtech/grid_proxy_server/internal/explorer/mw"
 	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
 )
 
 func errorReply(err error) mw.Response {
@@ -19,13 +25,25 @@ func errorReply(err error) mw.Response {
 		return mw.NotFound(err)
 	} else if errors.Is(err, ErrBadGateway) {
 		return mw.BadGateway(err)
+	} else if errors.Is(err, ErrBadRequest) || types.IsErrorCode(err, types.ErrCodeInvalidParam) {
+		return mw.BadRequest(err)
 	} else {
 		return mw.Error(err)
 	}
 }
 
+// paramError is returned when a query or path parameter has an invalid value
+func paramError(param string, format string, args ...interface{}) error {
+	return &types.Error{
+		Code:    types.ErrCodeInvalidParam,
+		Param:   param,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
 func getLimit(r *http.Request) (types.Limit, error) {
 	var limit types.Limit
+	var errs paramErrors
 
 	page := r.URL.Query().Get("page")
 	size := r.URL.Query().Get("size")
@@ -39,32 +57,120 @@ func getLimit(r *http.Request) (types.Limit, error) {
 	}
 	parsed, err := strconv.ParseUint(page, 10, 64)
 	if err != nil {
-		return limit, errors.Wrap(ErrBadRequest, fmt.Sprintf("couldn't parse page %s", err.Error()))
+		errs.add(paramError("page", "couldn't parse page %s", err.Error()))
 	}
 	limit.Page = parsed
 
 	parsed, err = strconv.ParseUint(size, 10, 64)
 	if err != nil {
-		return limit, errors.Wrap(ErrBadRequest, fmt.Sprintf("couldn't parse size %s", err.Error()))
+		errs.add(paramError("size", "couldn't parse size %s", err.Error()))
 	}
 	limit.Size = parsed
 
+	strict := isStrict(r)
 	limit.RetCount = false
 	if retCount == "true" {
 		limit.RetCount = true
+	} else if strict && retCount != "" && retCount != "false" {
+		errs.add(paramError("ret_count", "invalid ret_count %s, must be true or false", retCount))
 	}
 
 	limit.Randomize = false
 	if randomize == "true" {
 		limit.Randomize = true
+	} else if strict && randomize != "" && randomize != "false" {
+		errs.add(paramError("randomize", "invalid randomize %s, must be true or false", randomize))
 	}
 
 	// TODO: readd the check once clients are updated
 	// if limit.Size > maxPageSize {
 	// 	return limit, errors.Wrapf(ErrBadRequest, "max page size is %d", maxPageSize)
 	// }
-	return limit, nil
+	return limit, errs.err()
+}
+
+// getFields returns the fields requested with the fields query parameter (e.g. fields=nodeId,location,status)
+// after validating them against the json fields of the returned object
+func getFields(r *http.Request, obj interface{}) ([]string, error) {
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil, nil
+	}
+	valid := jsonFields(obj)
+	var expands []string
+	for _, expand := range strings.Split(r.URL.Query().Get("expand"), ",") {
+		expands = append(expands, strings.TrimSpace(expand))
+	}
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		expand, ok := valid[field]
+		if !ok {
+			return nil, paramError("fields", "unknown field %s", field)
+		}
+		if expand != "" && !isInStrs(expands, expand) {
+			return nil, paramError("fields", "field %s is only returned with expand=%s", field, expand)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
 }
+
+// jsonFields returns the json field names of a struct with the expand they're returned with,
+// it's empty for the fields returned without an expand
+func jsonFields(obj interface{}) map[string]string {
+	res := make(map[string]string)
+	t := reflect.TypeOf(obj)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		res[name] = t.Field(i).Tag.Get("expand")
+	}
+	return res
+}
+
+// selectFields trims the json representation of an object, or a list of objects,
+// to the given fields. the object is returned as is if no fields are given
+func selectFields(obj interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return obj, nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't marshal object")
+	}
+	trim := func(item map[string]json.RawMessage) map[string]json.RawMessage {
+		trimmed := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := item[field]; ok {
+				trimmed[field] = value
+			}
+		}
+		return trimmed
+	}
+	if reflect.ValueOf(obj).Kind() == reflect.Slice {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, errors.Wrap(err, "couldn't unmarshal objects")
+		}
+		res := make([]map[string]json.RawMessage, len(items))
+		for idx, item := range items {
+			res[idx] = trim(item)
+		}
+		return res, nil
+	}
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, errors.Wrap(err, "couldn't unmarshal object")
+	}
+	return trim(item), nil
+}
+
 func parseParams(
 	r *http.Request,
 	ints map[string]**uint64,
@@ -72,12 +178,14 @@ func parseParams(
 	bools map[string]**bool,
 	listOfInts map[string]*[]uint64,
 ) error {
+	var errs paramErrors
 	for param, prop := range ints {
 		value := r.URL.Query().Get(param)
 		if value != "" {
 			parsed, err := strconv.ParseUint(value, 10, 64)
 			if err != nil {
-				return errors.Wrap(ErrBadRequest, fmt.Sprintf("couldn't parse %s %s", param, err.Error()))
+				errs.add(paramError(param, "couldn't parse %s %s", param, err.Error()))
+				continue
 			}
 			*prop = &parsed
 		}
@@ -89,15 +197,17 @@ func parseParams(
 			*prop = &value
 		}
 	}
+	strict := isStrict(r)
 	trueVal := true
 	falseVal := false
 	for param, prop := range bools {
 		value := r.URL.Query().Get(param)
 		if value == "true" {
 			*prop = &trueVal
-		}
-		if value == "false" {
+		} else if value == "false" {
 			*prop = &falseVal
+		} else if strict && value != "" {
+			errs.add(paramError(param, "invalid %s %s, must be true or false", param, value))
 		}
 	}
 	for param, prop := range listOfInts {
@@ -110,60 +220,283 @@ func parseParams(
 			for _, item := range split {
 				parsed, err := strconv.ParseUint(item, 10, 64)
 				if err != nil {
-					return errors.Wrap(ErrBadRequest, fmt.Sprintf("couldn't parse %s %s", param, err.Error()))
+					errs.add(paramError(param, "couldn't parse %s %s", param, err.Error()))
+					break
 				}
 				*prop = append(*prop, parsed)
 			}
 		}
 	}
-	return nil
+	return errs.err()
 }
 
+// parseListOfStrs parses the comma separated lists of strings, empty items are ignored
+func parseListOfStrs(r *http.Request, listOfStrs map[string]*[]string) {
+	for param, prop := range listOfStrs {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		*prop = make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*prop = append(*prop, item)
+			}
+		}
+	}
+}
+
+// isStrict checks if the request asks for strict validation of its query parameters.
+// in strict mode unknown parameters, invalid booleans, invalid enum values and
+// conflicting filters are rejected instead of being ignored. it's on by default in
+// v2 and opt-in in v1, the strict parameter overrides the default in both
+func isStrict(r *http.Request) bool {
+	switch r.URL.Query().Get("strict") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return strings.HasPrefix(r.URL.Path, v2Prefix+"/")
+}
+
+// validateParams checks the query parameters of a strict request against the known
+// parameters of the endpoint and the allowed values of its enum parameters
+func validateParams(r *http.Request, known []string, enums map[string][]string) error {
+	var errs paramErrors
+	knownSet := map[string]struct{}{"strict": {}}
+	for _, param := range known {
+		knownSet[param] = struct{}{}
+	}
+	params := make([]string, 0, len(r.URL.Query()))
+	for param := range r.URL.Query() {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		if _, ok := knownSet[param]; !ok {
+			errs.add(paramError(param, "unknown parameter %s", param))
+			continue
+		}
+		allowed, ok := enums[param]
+		if !ok {
+			continue
+		}
+		value := r.URL.Query().Get(param)
+		if value != "" && !isOneOf(value, allowed) {
+			errs.add(paramError(param, "invalid %s %s, must be one of: %s", param, value, strings.Join(allowed, ", ")))
+		}
+	}
+	return errs.err()
+}
+
+// validateObjectParams checks the query parameters of a strict request for a single object
+// against the known parameters of the endpoint
+func validateObjectParams(r *http.Request, known ...string) error {
+	if !isStrict(r) {
+		return nil
+	}
+	return validateParams(r, known, nil)
+}
+
+// paramNames returns the names of the parameters parsed by parseParams and the extra given ones
+func paramNames(
+	ints map[string]**uint64,
+	strs map[string]**string,
+	bools map[string]**bool,
+	listOfInts map[string]*[]uint64,
+	extra ...string,
+) []string {
+	names := append([]string{}, extra...)
+	for param := range ints {
+		names = append(names, param)
+	}
+	for param := range strs {
+		names = append(names, param)
+	}
+	for param := range bools {
+		names = append(names, param)
+	}
+	for param := range listOfInts {
+		names = append(names, param)
+	}
+	return names
+}
+
+// isOneOf checks case insensitively if the value is one of the allowed ones
+func isOneOf(value string, allowed []string) bool {
+	for _, v := range allowed {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInStrs checks if the value is in the list
+func isInStrs(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEnums sets the enum parameters that are one of their allowed values case insensitively to
+// the allowed value since some filters compare them exactly, the invalid values are left as is
+func normalizeEnums(strs map[string]**string, enums map[string][]string) {
+	for param, allowed := range enums {
+		value, ok := strs[param]
+		if !ok || *value == nil {
+			continue
+		}
+		for _, v := range allowed {
+			if strings.EqualFold(**value, v) {
+				**value = v
+				break
+			}
+		}
+	}
+}
+
+// paramErrors collects the errors of the invalid query parameters to report them all in one response
+type paramErrors []types.Error
+
+// add adds the error, the details of aggregated errors are added one by one
+func (p *paramErrors) add(err error) {
+	if err == nil {
+		return
+	}
+	var typed *types.Error
+	if !errors.As(err, &typed) {
+		*p = append(*p, types.Error{Code: types.ErrCodeBadRequest, Message: err.Error()})
+	} else if len(typed.Details) != 0 {
+		*p = append(*p, typed.Details...)
+	} else {
+		*p = append(*p, *typed)
+	}
+}
+
+// conflict adds an error for two parameters that can't be used together with the given values
+func (p *paramErrors) conflict(param, other, reason string) {
+	p.add(paramError(param, "%s conflicts with %s: %s", param, other, reason))
+}
+
+// err returns the collected errors as one error, nil if there are none
+func (p paramErrors) err() error {
+	switch len(p) {
+	case 0:
+		return nil
+	case 1:
+		return &p[0]
+	}
+	messages := make([]string, len(p))
+	for idx := range p {
+		messages[idx] = p[idx].Message
+	}
+	return &types.Error{
+		Code:    types.ErrCodeInvalidParam,
+		Message: fmt.Sprintf("%d invalid parameters: %s", len(p), strings.Join(messages, "; ")),
+		Details: p,
+	}
+}
+
+var (
+	// limitParams are the pagination parameters parsed by getLimit
+	limitParams = []string{"page", "size", "ret_count", "randomize"}
+	// nodeStatuses are the allowed values of the status filter
+	nodeStatuses = []string{db.NodeUp, db.NodeDown, db.NodeStandby}
+	// certificationTypes are the allowed values of the certification_type filter
+	certificationTypes = []string{"Diy", "Certified"}
+	// contractTypes are the allowed values of the contract type filter
+	contractTypes = []string{"node", "name", "rent"}
+	// contractStates are the allowed values of the contract state filter
+	contractStates = []string{"Created", "GracePeriod", "Deleted"}
+)
+
 // test nodes?status=up&free_ips=0&free_cru=1&free_mru=1&free_hru=1&country=Belgium&city=Unknown&ipv4=true&ipv6=true&domain=false
-// handleNodeRequestsQueryParams takes the request and restore the query paramas, handle errors and set default values if not available
-func (a *App) handleNodeRequestsQueryParams(r *http.Request) (types.NodeFilter, types.Limit, error) {
+// handleNodeRequestsQueryParams takes the request and restore the query paramas, handle errors and set default values if not available.
+// extra are the other parameters of the endpoint accepted in strict mode
+func (a *App) handleNodeRequestsQueryParams(r *http.Request, extra ...string) (types.NodeFilter, types.Limit, error) {
 	var filter types.NodeFilter
 	var limit types.Limit
 	ints := map[string]**uint64{
-		"free_mru":      &filter.FreeMRU,
-		"free_hru":      &filter.FreeHRU,
-		"free_sru":      &filter.FreeSRU,
-		"free_ips":      &filter.FreeIPs,
-		"total_mru":     &filter.TotalMRU,
-		"total_cru":     &filter.TotalCRU,
-		"total_sru":     &filter.TotalSRU,
-		"total_hru":     &filter.TotalHRU,
-		"rented_by":     &filter.RentedBy,
-		"available_for": &filter.AvailableFor,
-		"node_id":       &filter.NodeID,
-		"twin_id":       &filter.TwinID,
+		"free_mru":       &filter.FreeMRU,
+		"free_hru":       &filter.FreeHRU,
+		"free_sru":       &filter.FreeSRU,
+		"free_ips":       &filter.FreeIPs,
+		"total_mru":      &filter.TotalMRU,
+		"total_cru":      &filter.TotalCRU,
+		"total_sru":      &filter.TotalSRU,
+		"total_hru":      &filter.TotalHRU,
+		"free_mru_max":   &filter.FreeMRUMax,
+		"free_hru_max":   &filter.FreeHRUMax,
+		"free_sru_max":   &filter.FreeSRUMax,
+		"total_mru_max":  &filter.TotalMRUMax,
+		"total_cru_max":  &filter.TotalCRUMax,
+		"total_sru_max":  &filter.TotalSRUMax,
+		"total_hru_max":  &filter.TotalHRUMax,
+		"uptime_min":     &filter.UptimeMin,
+		"uptime_max":     &filter.UptimeMax,
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+		"rented_by":      &filter.RentedBy,
+		"available_for":  &filter.AvailableFor,
+		"node_id":        &filter.NodeID,
+		"twin_id":        &filter.TwinID,
+		"grid_version":   &filter.GridVersion,
+		"updated_after":  &filter.UpdatedAfter,
 	}
 	strs := map[string]**string{
-		"status":             &filter.Status,
-		"city":               &filter.City,
-		"city_contains":      &filter.CityContains,
-		"country":            &filter.Country,
-		"country_contains":   &filter.CountryContains,
-		"farm_name":          &filter.FarmName,
-		"farm_name_contains": &filter.FarmNameContains,
-		"certification_type": &filter.CertificationType,
+		"status":                 &filter.Status,
+		"city":                   &filter.City,
+		"city_contains":          &filter.CityContains,
+		"country":                &filter.Country,
+		"country_contains":       &filter.CountryContains,
+		"farm_name":              &filter.FarmName,
+		"farm_name_contains":     &filter.FarmNameContains,
+		"certification_type":     &filter.CertificationType,
+		"gpu_vendor":             &filter.GPUVendor,
+		"gpu_device":             &filter.GPUDevice,
+		"country_not":            &filter.CountryNot,
+		"certification_type_not": &filter.CertificationTypeNot,
 	}
 	bools := map[string]**bool{
-		"ipv4":      &filter.IPv4,
-		"ipv6":      &filter.IPv6,
-		"domain":    &filter.Domain,
-		"dedicated": &filter.Dedicated,
-		"rentable":  &filter.Rentable,
-		"rented":    &filter.Rented,
+		"ipv4":             &filter.IPv4,
+		"ipv6":             &filter.IPv6,
+		"domain":           &filter.Domain,
+		"dedicated":        &filter.Dedicated,
+		"rentable":         &filter.Rentable,
+		"rented":           &filter.Rented,
+		"has_gpu":          &filter.HasGPU,
+		"gpu_available":    &filter.GPUAvailable,
+		"secure":           &filter.Secure,
+		"virtualized":      &filter.Virtualized,
+		"has_interface_ip": &filter.HasInterfaceIP,
 	}
 	listOfInts := map[string]*[]uint64{
-		"farm_ids": &filter.FarmIDs,
+		"farm_ids":         &filter.FarmIDs,
+		"node_ids":         &filter.NodeIDs,
+		"exclude_farm_ids": &filter.ExcludeFarmIDs,
+		"exclude_node_ids": &filter.ExcludeNodeIDs,
+		"exclude_twin_ids": &filter.ExcludeTwinIDs,
 	}
-	if err := parseParams(r, ints, strs, bools, listOfInts); err != nil {
-		return filter, limit, err
+	enums := map[string][]string{
+		"status":                 nodeStatuses,
+		"certification_type":     certificationTypes,
+		"certification_type_not": certificationTypes,
 	}
+	var errs paramErrors
+	errs.add(parseParams(r, ints, strs, bools, listOfInts))
+	normalizeEnums(strs, enums)
 	limit, err := getLimit(r)
-	if err != nil {
+	errs.add(err)
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, strs, bools, listOfInts, append(limitParams, extra...)...), enums))
+		nodeFilterConflicts(&errs, filter)
+	}
+	if err := errs.err(); err != nil {
 		return filter, limit, err
 	}
 	trueval := true
@@ -174,6 +507,75 @@ func (a *App) handleNodeRequestsQueryParams(r *http.Request) (types.NodeFilter,
 	return filter, limit, nil
 }
 
+// nodeFilterConflicts reports the node filters that can't match any node together
+func nodeFilterConflicts(errs *paramErrors, filter types.NodeFilter) {
+	if filter.Rentable != nil && *filter.Rentable && filter.Rented != nil && *filter.Rented {
+		errs.conflict("rentable", "rented", "a rented node isn't rentable")
+	}
+	if filter.Rented != nil && filter.RentedBy != nil && *filter.Rented != (*filter.RentedBy != 0) {
+		errs.conflict("rented_by", "rented", "rented_by must be 0 for non rented nodes and non zero for rented ones")
+	}
+	if filter.Rentable != nil && *filter.Rentable && filter.RentedBy != nil && *filter.RentedBy != 0 {
+		errs.conflict("rentable", "rented_by", "a rented node isn't rentable")
+	}
+	if filter.HasGPU != nil && !*filter.HasGPU && (filter.GPUVendor != nil || filter.GPUDevice != nil || filter.GPUAvailable != nil) {
+		errs.conflict("has_gpu", "gpu filters", "gpu filters match only nodes with gpus")
+	}
+	rangeConflict(errs, "free_mru", filter.FreeMRU, "free_mru_max", filter.FreeMRUMax)
+	rangeConflict(errs, "free_hru", filter.FreeHRU, "free_hru_max", filter.FreeHRUMax)
+	rangeConflict(errs, "free_sru", filter.FreeSRU, "free_sru_max", filter.FreeSRUMax)
+	rangeConflict(errs, "total_mru", filter.TotalMRU, "total_mru_max", filter.TotalMRUMax)
+	rangeConflict(errs, "total_cru", filter.TotalCRU, "total_cru_max", filter.TotalCRUMax)
+	rangeConflict(errs, "total_sru", filter.TotalSRU, "total_sru_max", filter.TotalSRUMax)
+	rangeConflict(errs, "total_hru", filter.TotalHRU, "total_hru_max", filter.TotalHRUMax)
+	rangeConflict(errs, "uptime_min", filter.UptimeMin, "uptime_max", filter.UptimeMax)
+	if filter.Country != nil && filter.CountryNot != nil && strings.EqualFold(*filter.Country, *filter.CountryNot) {
+		errs.conflict("country", "country_not", "the country is excluded")
+	}
+	if filter.CertificationType != nil && filter.CertificationTypeNot != nil && strings.EqualFold(*filter.CertificationType, *filter.CertificationTypeNot) {
+		errs.conflict("certification_type", "certification_type_not", "the certification type is excluded")
+	}
+	if filter.NodeID != nil && isIn(filter.ExcludeNodeIDs, *filter.NodeID) {
+		errs.conflict("node_id", "exclude_node_ids", "the node is excluded")
+	}
+	if filter.TwinID != nil && isIn(filter.ExcludeTwinIDs, *filter.TwinID) {
+		errs.conflict("twin_id", "exclude_twin_ids", "the twin is excluded")
+	}
+	if len(filter.FarmIDs) != 0 && allIn(filter.FarmIDs, filter.ExcludeFarmIDs) {
+		errs.conflict("farm_ids", "exclude_farm_ids", "all the farms are excluded")
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && *filter.CreatedAfter >= *filter.CreatedBefore {
+		errs.conflict("created_after", "created_before", "created_after must be before created_before")
+	}
+}
+
+// isIn checks if the value is in the list
+func isIn(list []uint64, value uint64) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// allIn checks if all the values are in the list
+func allIn(values, list []uint64) bool {
+	for _, value := range values {
+		if !isIn(list, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// rangeConflict reports a minimum filter that is more than its maximum counterpart
+func rangeConflict(errs *paramErrors, minParam string, min *uint64, maxParam string, max *uint64) {
+	if min != nil && max != nil && *min > *max {
+		errs.conflict(minParam, maxParam, fmt.Sprintf("%s can't be more than %s", minParam, maxParam))
+	}
+}
+
 // test farms?free_ips=1&pricing_policy_id=1&version=4&farm_id=23&twin_id=291&name=Farm-1&stellar_address=13VrxhaBZh87ZP8nuYF4LtAhnDPWMfSrMUvHeRAFaqN43W1X
 // handleFarmRequestsQueryParams takes the request and restore the query paramas, handle errors and set default values if not available
 func (a *App) handleFarmRequestsQueryParams(r *http.Request) (types.FarmFilter, types.Limit, error) {
@@ -181,30 +583,60 @@ func (a *App) handleFarmRequestsQueryParams(r *http.Request) (types.FarmFilter,
 	var limit types.Limit
 
 	ints := map[string]**uint64{
-		"free_ips":          &filter.FreeIPs,
-		"total_ips":         &filter.TotalIPs,
-		"pricing_policy_id": &filter.PricingPolicyID,
-		"farm_id":           &filter.FarmID,
-		"twin_id":           &filter.TwinID,
+		"free_ips":           &filter.FreeIPs,
+		"total_ips":          &filter.TotalIPs,
+		"pricing_policy_id":  &filter.PricingPolicyID,
+		"farm_id":            &filter.FarmID,
+		"twin_id":            &filter.TwinID,
+		"node_count_min":     &filter.NodeCountMin,
+		"up_nodes_min":       &filter.UpNodesMin,
+		"rentable_nodes_min": &filter.RentableNodesMin,
+		"node_free_mru":      &filter.NodeFreeMRU,
+		"node_free_sru":      &filter.NodeFreeSRU,
 	}
 	strs := map[string]**string{
-		"name":               &filter.Name,
-		"name_contains":      &filter.NameContains,
-		"certification_type": &filter.CertificationType,
-		"stellar_address":    &filter.StellarAddress,
+		"name":                    &filter.Name,
+		"name_contains":           &filter.NameContains,
+		"certification_type":      &filter.CertificationType,
+		"certification_type_not":  &filter.CertificationTypeNot,
+		"stellar_address":         &filter.StellarAddress,
+		"country":                 &filter.Country,
+		"node_certification_type": &filter.NodeCertificationType,
 	}
 	bools := map[string]**bool{
 		"dedicated": &filter.Dedicated,
 	}
-	if err := parseParams(r, ints, strs, bools, nil); err != nil {
-		return filter, limit, err
+	listOfInts := map[string]*[]uint64{
+		"farm_ids":         &filter.FarmIDs,
+		"exclude_farm_ids": &filter.ExcludeFarmIDs,
+		"exclude_twin_ids": &filter.ExcludeTwinIDs,
 	}
-
+	enums := map[string][]string{
+		"certification_type":      certificationTypes,
+		"certification_type_not":  certificationTypes,
+		"node_certification_type": certificationTypes,
+	}
+	var errs paramErrors
+	errs.add(parseParams(r, ints, strs, bools, listOfInts))
+	normalizeEnums(strs, enums)
 	limit, err := getLimit(r)
-	if err != nil {
-		return filter, limit, err
+	errs.add(err)
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, strs, bools, listOfInts, append(limitParams, "fields", "expand")...), enums))
+		if filter.FreeIPs != nil && filter.TotalIPs != nil && *filter.FreeIPs > *filter.TotalIPs {
+			errs.conflict("free_ips", "total_ips", "free ips can't be more than the total ips")
+		}
+		if filter.FarmID != nil && isIn(filter.ExcludeFarmIDs, *filter.FarmID) {
+			errs.conflict("farm_id", "exclude_farm_ids", "the farm is excluded")
+		}
+		if filter.TwinID != nil && isIn(filter.ExcludeTwinIDs, *filter.TwinID) {
+			errs.conflict("twin_id", "exclude_twin_ids", "the twin is excluded")
+		}
+		if filter.CertificationType != nil && filter.CertificationTypeNot != nil && *filter.CertificationType == *filter.CertificationTypeNot {
+			errs.conflict("certification_type", "certification_type_not", "the certification type is excluded")
+		}
 	}
-	return filter, limit, nil
+	return filter, limit, errs.err()
 }
 
 // test twins?twin_id=7
@@ -216,19 +648,30 @@ func (a *App) handleTwinRequestsQueryParams(r *http.Request) (types.TwinFilter,
 		"twin_id": &filter.TwinID,
 	}
 	strs := map[string]**string{
-		"account_id": &filter.AccountID,
-		"relay":      &filter.Relay,
-		"public_key": &filter.PublicKey,
+		"account_id":     &filter.AccountID,
+		"relay":          &filter.Relay,
+		"public_key":     &filter.PublicKey,
+		"relay_contains": &filter.RelayContains,
 	}
-
-	if err := parseParams(r, ints, strs, nil, nil); err != nil {
-		return filter, limit, err
+	listOfInts := map[string]*[]uint64{
+		"twin_ids": &filter.TwinIDs,
 	}
+	listOfStrs := map[string]*[]string{
+		"account_ids": &filter.AccountIDs,
+	}
+
+	var errs paramErrors
+	errs.add(parseParams(r, ints, strs, nil, listOfInts))
+	parseListOfStrs(r, listOfStrs)
 	limit, err := getLimit(r)
-	if err != nil {
-		return filter, limit, err
+	errs.add(err)
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, strs, nil, listOfInts, append(limitParams, "fields", "account_ids")...), nil))
+		if filter.AccountID != nil && len(filter.AccountIDs) != 0 && !isInStrs(filter.AccountIDs, *filter.AccountID) {
+			errs.conflict("account_id", "account_ids", "the account isn't in account_ids")
+		}
 	}
-	return filter, limit, nil
+	return filter, limit, errs.err()
 }
 
 // test contracts?contract_id=7
@@ -241,23 +684,99 @@ func (a *App) handleContractRequestsQueryParams(r *http.Request) (types.Contract
 		"twin_id":              &filter.TwinID,
 		"node_id":              &filter.NodeID,
 		"number_of_public_ips": &filter.NumberOfPublicIps,
+		"created_after":        &filter.CreatedAfter,
+		"created_before":       &filter.CreatedBefore,
+		"farm_id":              &filter.FarmID,
 	}
 	strs := map[string]**string{
 		"name":            &filter.Name,
+		"name_contains":   &filter.NameContains,
 		"deployment_data": &filter.DeploymentData,
 		"deployment_hash": &filter.DeploymentHash,
 		"type":            &filter.Type,
 		"state":           &filter.State,
 	}
+	bools := map[string]**bool{
+		"has_public_ips": &filter.HasPublicIPs,
+	}
+	listOfInts := map[string]*[]uint64{
+		"node_ids":     &filter.NodeIDs,
+		"twin_ids":     &filter.TwinIDs,
+		"contract_ids": &filter.ContractIDs,
+	}
+	listOfStrs := map[string]*[]string{
+		"states": &filter.States,
+		"types":  &filter.Types,
+	}
 
-	if err := parseParams(r, ints, strs, nil, nil); err != nil {
-		return filter, limit, err
+	enums := map[string][]string{
+		"type":  contractTypes,
+		"state": contractStates,
 	}
+	var errs paramErrors
+	errs.add(parseParams(r, ints, strs, bools, listOfInts))
+	normalizeEnums(strs, enums)
+	parseListOfStrs(r, listOfStrs)
 	limit, err := getLimit(r)
-	if err != nil {
-		return filter, limit, err
+	errs.add(err)
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, strs, bools, listOfInts, append(limitParams, "fields", "states", "types")...), enums))
+		for _, state := range filter.States {
+			if !isOneOf(state, contractStates) {
+				errs.add(paramError("states", "invalid state %s, must be one of: %s", state, strings.Join(contractStates, ", ")))
+			}
+		}
+		for _, contractType := range filter.Types {
+			if !isOneOf(contractType, contractTypes) {
+				errs.add(paramError("types", "invalid type %s, must be one of: %s", contractType, strings.Join(contractTypes, ", ")))
+			}
+		}
+		contractFilterConflicts(&errs, filter)
+	}
+	return filter, limit, errs.err()
+}
+
+// contractFilterConflicts reports the contract filters that can't match any contract together
+func contractFilterConflicts(errs *paramErrors, filter types.ContractFilter) {
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && *filter.CreatedAfter >= *filter.CreatedBefore {
+		errs.conflict("created_after", "created_before", "created_after must be before created_before")
+	}
+	if filter.Type == nil {
+		return
+	}
+	contractType := strings.ToLower(*filter.Type)
+	if contractType != "node" {
+		if filter.DeploymentData != nil {
+			errs.conflict("deployment_data", "type", "only node contracts have deployment data")
+		}
+		if filter.DeploymentHash != nil {
+			errs.conflict("deployment_hash", "type", "only node contracts have deployment hash")
+		}
+		if filter.NumberOfPublicIps != nil && *filter.NumberOfPublicIps != 0 {
+			errs.conflict("number_of_public_ips", "type", "only node contracts have public ips")
+		}
+		if filter.HasPublicIPs != nil && *filter.HasPublicIPs {
+			errs.conflict("has_public_ips", "type", "only node contracts have public ips")
+		}
+	}
+	if contractType == "name" {
+		if filter.NodeID != nil {
+			errs.conflict("node_id", "type", "name contracts aren't deployed on nodes")
+		}
+		if len(filter.NodeIDs) != 0 {
+			errs.conflict("node_ids", "type", "name contracts aren't deployed on nodes")
+		}
+		if filter.FarmID != nil {
+			errs.conflict("farm_id", "type", "name contracts aren't deployed on nodes")
+		}
+	} else {
+		if filter.Name != nil {
+			errs.conflict("name", "type", "only name contracts have names")
+		}
+		if filter.NameContains != nil {
+			errs.conflict("name_contains", "type", "only name contracts have names")
+		}
 	}
-	return filter, limit, nil
 }
 
 // test stats?status=up
@@ -267,20 +786,213 @@ func (a *App) handleStatsRequestsQueryParams(r *http.Request) (types.StatsFilter
 	strs := map[string]**string{
 		"status": &filter.Status,
 	}
-	if err := parseParams(r, nil, strs, nil, nil); err != nil {
-		return filter, err
+	enums := map[string][]string{
+		"status": nodeStatuses,
+	}
+	var errs paramErrors
+	errs.add(parseParams(r, nil, strs, nil, nil))
+	normalizeEnums(strs, enums)
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(nil, strs, nil, nil), enums))
+	}
+	return filter, errs.err()
+}
+
+// handleEventsRequestsQueryParams returns the node events filter and the id of the last event the caller got
+func (a *App) handleEventsRequestsQueryParams(r *http.Request) (types.NodeEventFilter, *uint64, error) {
+	var filter types.NodeEventFilter
+	ints := map[string]**uint64{
+		"farm_id": &filter.FarmID,
+		"twin_id": &filter.TwinID,
+	}
+	listOfInts := map[string]*[]uint64{
+		"node_ids": &filter.NodeIDs,
+	}
+	listOfStrs := map[string]*[]string{
+		"types": &filter.Types,
+	}
+	var errs paramErrors
+	errs.add(parseParams(r, ints, nil, nil, listOfInts))
+	parseListOfStrs(r, listOfStrs)
+	lastID, err := lastEventID(r)
+	errs.add(err)
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, nil, nil, listOfInts, "types", "last_event_id"), nil))
+		for _, eventType := range filter.Types {
+			if !isOneOf(eventType, types.NodeEventTypes) {
+				errs.add(paramError("types", "invalid type %s, must be one of: %s", eventType, strings.Join(types.NodeEventTypes, ", ")))
+			}
+		}
+	}
+	return filter, lastID, errs.err()
+}
+
+// handleStatsHistoryRequestsQueryParams returns the [from, to) range and the bucket interval of the stats history,
+// it defaults to the last statsHistoryDefaultRange with statsHistoryDefaultInterval buckets
+func (a *App) handleStatsHistoryRequestsQueryParams(r *http.Request) (from, to, interval uint64, err error) {
+	var fromParam, toParam, intervalParam *uint64
+	ints := map[string]**uint64{
+		"from":     &fromParam,
+		"to":       &toParam,
+		"interval": &intervalParam,
+	}
+	var errs paramErrors
+	errs.add(parseParams(r, ints, nil, nil, nil))
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, nil, nil, nil), nil))
+	}
+	// the timestamps and the interval are signed in the database
+	for _, param := range []string{"from", "to", "interval"} {
+		if value := *ints[param]; value != nil && *value > math.MaxInt64 {
+			errs.add(paramError(param, "%s can't be more than %d", param, int64(math.MaxInt64)))
+		}
+	}
+	to = uint64(time.Now().Unix())
+	if toParam != nil {
+		to = *toParam
+	}
+	if defaultRange := uint64(statsHistoryDefaultRange.Seconds()); to > defaultRange {
+		from = to - defaultRange
+	}
+	if fromParam != nil {
+		from = *fromParam
+	}
+	interval = uint64(statsHistoryDefaultInterval.Seconds())
+	if intervalParam != nil {
+		interval = *intervalParam
+	}
+	if interval == 0 {
+		errs.add(paramError("interval", "interval must be more than 0"))
+	} else if intervalParam != nil && from < to && interval > to-from {
+		errs.add(paramError("interval", "interval can't be more than the range of %d seconds", to-from))
+	}
+	if from > to {
+		errs.conflict("from", "to", "from can't be after to")
+	}
+	return from, to, interval, errs.err()
+}
+
+// handleChangesRequestsQueryParams returns the checkpoint the changes are requested after, it's nil if
+// it's not given, and the max number of changes to return
+func (a *App) handleChangesRequestsQueryParams(r *http.Request) (since *uint64, size uint64, err error) {
+	var sizeParam *uint64
+	ints := map[string]**uint64{
+		"since": &since,
+		"size":  &sizeParam,
+	}
+	var errs paramErrors
+	errs.add(parseParams(r, ints, nil, nil, nil))
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, nil, nil, nil), nil))
+	}
+	size = changesDefaultSize
+	if sizeParam != nil {
+		size = *sizeParam
+	}
+	if size == 0 || size > changesMaxSize {
+		errs.add(paramError("size", "size must be between 1 and %d", changesMaxSize))
+	}
+	return since, size, errs.err()
+}
+
+// handleRentableRequestsQueryParams returns the rentable nodes filter with the node filters, the max hourly
+// price in USD and the price to sort the nodes by
+func (a *App) handleRentableRequestsQueryParams(r *http.Request) (filter types.NodeFilter, limit types.Limit, maxPrice *float64, sortBy string, err error) {
+	var errs paramErrors
+	filter, limit, err = a.handleNodeRequestsQueryParams(r, "sort_by", "max_price")
+	errs.add(err)
+	if value := r.URL.Query().Get("max_price"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			errs.add(paramError("max_price", "invalid max_price %s, must be a positive price in USD", value))
+		} else {
+			maxPrice = &parsed
+		}
+	}
+	sortBy = strings.ToLower(r.URL.Query().Get("sort_by"))
+	if sortBy != "" && !isOneOf(sortBy, rentableSorts) {
+		errs.add(paramError("sort_by", "invalid sort_by %s, must be one of: %s", sortBy, strings.Join(rentableSorts, ", ")))
 	}
-	return filter, nil
+	if isStrict(r) && filter.Rentable != nil && !*filter.Rentable {
+		errs.conflict("rentable", "rentable nodes", "only the rentable nodes are listed")
+	}
+	// the rentable nodes are sorted by sort_by or by id, randomize is ignored unless the validation is strict
+	if limit.Randomize && isStrict(r) {
+		errs.add(paramError("randomize", "randomize isn't supported, the rentable nodes are sorted by sort_by or by id"))
+	}
+	limit.Randomize = false
+	return filter, limit, maxPrice, sortBy, errs.err()
+}
+
+// handleSpendingRequestsQueryParams returns the [from, to) range of the bill reports and their grouping,
+// the range defaults to all the reports until now grouped by month
+func (a *App) handleSpendingRequestsQueryParams(r *http.Request) (from, to int64, groupBy string, err error) {
+	var fromParam, toParam *uint64
+	ints := map[string]**uint64{
+		"from": &fromParam,
+		"to":   &toParam,
+	}
+	var errs paramErrors
+	errs.add(parseParams(r, ints, nil, nil, nil))
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, nil, nil, nil, "group_by"), nil))
+	}
+	from, to = billingRange(&errs, fromParam, toParam)
+	groupBy = strings.ToLower(r.URL.Query().Get("group_by"))
+	if groupBy == "" {
+		groupBy = db.SpendingByMonth
+	} else if !isOneOf(groupBy, spendingGroupings) {
+		errs.add(paramError("group_by", "invalid group_by %s, must be one of: %s", groupBy, strings.Join(spendingGroupings, ", ")))
+	}
+	return from, to, groupBy, errs.err()
+}
+
+// handleFarmReportRequestsQueryParams returns the [from, to) range of the bill reports and the format of the report,
+// the range defaults to all the reports until now
+func (a *App) handleFarmReportRequestsQueryParams(r *http.Request) (from, to int64, format string, err error) {
+	var fromParam, toParam *uint64
+	ints := map[string]**uint64{
+		"from": &fromParam,
+		"to":   &toParam,
+	}
+	var errs paramErrors
+	errs.add(parseParams(r, ints, nil, nil, nil))
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, nil, nil, nil, "format"), nil))
+	}
+	from, to = billingRange(&errs, fromParam, toParam)
+	format = strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = reportFormatJSON
+	} else if !isOneOf(format, reportFormats) {
+		errs.add(paramError("format", "invalid format %s, must be one of: %s", format, strings.Join(reportFormats, ", ")))
+	}
+	return from, to, format, errs.err()
+}
+
+// billingRange returns the [from, to) range of the bill reports, it defaults to all the reports until now
+func billingRange(errs *paramErrors, fromParam, toParam *uint64) (from, to int64) {
+	to = time.Now().Unix()
+	if toParam != nil {
+		to = int64(*toParam)
+	}
+	if fromParam != nil {
+		from = int64(*fromParam)
+	}
+	if from >= to {
+		errs.conflict("from", "to", "from must be before to")
+	}
+	return from, to
 }
 
 // getNodeData is a helper function that wraps fetch node data
 // it caches the results in redis to save time
-func (a *App) getNodeData(nodeIDStr string) (types.NodeWithNestedCapacity, error) {
+func (a *App) getNodeData(nodeIDStr string, fields ...string) (types.NodeWithNestedCapacity, error) {
 	nodeID, err := strconv.Atoi(nodeIDStr)
 	if err != nil {
-		return types.NodeWithNestedCapacity{}, errors.Wrap(ErrBadGateway, fmt.Sprintf("invalid node id %d: %s", nodeID, err.Error()))
+		return types.NodeWithNestedCapacity{}, paramError("node_id", "invalid node id %s: %s", nodeIDStr, err.Error())
 	}
-	info, err := a.db.GetNode(uint32(nodeID))
+	info, err := a.db.GetNode(uint32(nodeID), fields...)
 	if errors.Is(err, db.ErrNodeNotFound) {
 		return types.NodeWithNestedCapacity{}, ErrNodeNotFound
 	} else if err != nil {
@@ -290,3 +1002,43 @@ func (a *App) getNodeData(nodeIDStr string) (types.NodeWithNestedCapacity, error
 	apiNode := nodeWithNestedCapacityFromDBNode(info)
 	return apiNode, nil
 }
+
+// newNodeContracts returns the contracts on the node with the sum of their resources, the contracts are
+// reconciled if their sum and the resources reserved by zos add up to the node used resources
+func newNodeContracts(node types.NodeWithNestedCapacity, dbContracts []db.NodeContract) types.NodeContracts {
+	res := types.NodeContracts{
+		NodeID:        node.NodeID,
+		Contracts:     make([]types.NodeContract, len(dbContracts)),
+		Reserved:      reservedResources(node.Capacity.Total),
+		UsedResources: node.Capacity.Used,
+	}
+	for idx, contract := range dbContracts {
+		res.Contracts[idx] = nodeContractFromDBNodeContract(contract)
+		addCapacity(&res.Sum, res.Contracts[idx].Resources)
+	}
+	expected := res.Sum
+	addCapacity(&expected, res.Reserved)
+	res.Reconciled = expected == res.UsedResources
+	return res
+}
+
+// reservedResources returns the resources zos reserves for itself on a node with the given total
+// resources, it's 10% of the memory with 2GB at least and 100GB of the ssd
+func reservedResources(total types.Capacity) types.Capacity {
+	mru := gridtypes.Unit(math.Round(float64(total.MRU) / 10))
+	if mru < 2*gridtypes.Gigabyte {
+		mru = 2 * gridtypes.Gigabyte
+	}
+	return types.Capacity{
+		MRU: mru,
+		SRU: 100 * gridtypes.Gigabyte,
+	}
+}
+
+// addCapacity adds the capacity c to the capacity sum
+func addCapacity(sum *types.Capacity, c types.Capacity) {
+	sum.CRU += c.CRU
+	sum.SRU += c.SRU
+	sum.HRU += c.HRU
+	sum.MRU += c.MRU
+}
@@ -0,0 +1,336 @@
+package explorer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/mw"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+)
+
+func TestGetFields(t *testing.T) {
+	tests := []struct {
+		query  string
+		fields []string
+		param  string
+	}{
+		{"", nil, ""},
+		{"fields=nodeId,location,%20status", []string{"nodeId", "location", "status"}, ""},
+		{"fields=nodeId,,status,", []string{"nodeId", "status"}, ""},
+		{"fields=nodeId,node_id", nil, "fields"},
+		{"fields=country.city", nil, "fields"},
+		// the policy is returned only when it's expanded
+		{"fields=nodeId,farmingPolicy", nil, "fields"},
+		{"fields=nodeId,farmingPolicy&expand=", nil, "fields"},
+		{"fields=nodeId,farmingPolicy&expand=policy", []string{"nodeId", "farmingPolicy"}, ""},
+		{"fields=nodeId,farmingPolicy&expand=%20policy%20", []string{"nodeId", "farmingPolicy"}, ""},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/nodes?"+test.query, nil)
+		fields, err := getFields(r, types.Node{})
+		if test.param != "" {
+			if !types.IsErrorCode(err, types.ErrCodeInvalidParam) || err.(*types.Error).Param != test.param {
+				t.Fatalf("%s: error mismatch: expected an invalid %s, found: %v", test.query, test.param, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.query, err.Error())
+		}
+		if !reflect.DeepEqual(fields, test.fields) {
+			t.Fatalf("%s: fields mismatch: expected: %v, found: %v", test.query, test.fields, fields)
+		}
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	longitude := 4.35
+	node := types.Node{
+		NodeID:   1,
+		Status:   "up",
+		Location: types.Location{Country: "Belgium", City: "Brussels", Longitude: &longitude},
+		TotalResources: types.Capacity{
+			CRU: 8,
+		},
+	}
+	unchanged, err := selectFields(node, nil)
+	if err != nil {
+		t.Fatalf("failed to select no fields: %s", err.Error())
+	}
+	if !reflect.DeepEqual(unchanged, node) {
+		t.Fatalf("object changed with no fields: %+v", unchanged)
+	}
+
+	selected, err := selectFields([]types.Node{node, node}, []string{"nodeId", "location", "total_resources"})
+	if err != nil {
+		t.Fatalf("failed to select fields: %s", err.Error())
+	}
+	data, err := json.Marshal(selected)
+	if err != nil {
+		t.Fatalf("failed to marshal selected fields: %s", err.Error())
+	}
+	var nodes []map[string]interface{}
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		t.Fatalf("failed to unmarshal selected fields: %s", err.Error())
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("nodes count mismatch: expected: 2, found: %d", len(nodes))
+	}
+	for _, selected := range nodes {
+		if len(selected) != 3 || selected["nodeId"] != float64(1) {
+			t.Fatalf("selected fields mismatch: %v", selected)
+		}
+		location, ok := selected["location"].(map[string]interface{})
+		if !ok || location["country"] != "Belgium" || location["city"] != "Brussels" || location["longitude"] != longitude {
+			t.Fatalf("nested location mismatch: %v", selected["location"])
+		}
+		resources, ok := selected["total_resources"].(map[string]interface{})
+		if !ok || resources["cru"] != float64(8) || len(resources) != 4 {
+			t.Fatalf("nested resources mismatch: %v", selected["total_resources"])
+		}
+	}
+
+	single, err := selectFields(node, []string{"status", "farmingPolicy"})
+	if err != nil {
+		t.Fatalf("failed to select fields: %s", err.Error())
+	}
+	if trimmed := single.(map[string]json.RawMessage); len(trimmed) != 1 || string(trimmed["status"]) != `"up"` {
+		t.Fatalf("selected fields mismatch: %v", trimmed)
+	}
+}
+
+// invalidParams returns the sorted invalid parameters reported by the error
+func invalidParams(t *testing.T, err error) []string {
+	if err == nil {
+		return nil
+	}
+	if !types.IsErrorCode(err, types.ErrCodeInvalidParam) {
+		t.Fatalf("error code mismatch: expected: %s, found: %v", types.ErrCodeInvalidParam, err)
+	}
+	typed := err.(*types.Error)
+	if len(typed.Details) == 0 {
+		return []string{typed.Param}
+	}
+	params := make([]string, len(typed.Details))
+	for idx, detail := range typed.Details {
+		params[idx] = detail.Param
+	}
+	sort.Strings(params)
+	return params
+}
+
+func TestStrictParams(t *testing.T) {
+	var a App
+	nodes := func(r *http.Request) error {
+		_, _, err := a.handleNodeRequestsQueryParams(r)
+		return err
+	}
+	farms := func(r *http.Request) error {
+		_, _, err := a.handleFarmRequestsQueryParams(r)
+		return err
+	}
+	twins := func(r *http.Request) error {
+		_, _, err := a.handleTwinRequestsQueryParams(r)
+		return err
+	}
+	contracts := func(r *http.Request) error {
+		_, _, err := a.handleContractRequestsQueryParams(r)
+		return err
+	}
+	stats := func(r *http.Request) error {
+		_, err := a.handleStatsRequestsQueryParams(r)
+		return err
+	}
+	tests := []struct {
+		url    string
+		parse  func(r *http.Request) error
+		params []string
+	}{
+		{"/nodes?free_mr=1&ipv4=yes&status=online", nodes, nil},
+		{"/nodes?free_mr=1&strict=true", nodes, []string{"free_mr"}},
+		{"/nodes?free_mr=1&strict=yes", nodes, nil},
+		{"/v2/nodes?free_mr=1", nodes, []string{"free_mr"}},
+		{"/v2/nodes?free_mr=1&strict=false", nodes, nil},
+		{"/v2/nodes?status=UP&certification_type=certified&ipv4=true&page=2", nodes, nil},
+		{"/v2/nodes?ipv4=yes&status=online&certification_type=gold", nodes, []string{"certification_type", "ipv4", "status"}},
+		{"/v2/nodes?size=big&ret_count=yes&randomize=1", nodes, []string{"randomize", "ret_count", "size"}},
+		{"/v2/nodes?rentable=true&rented=true&rented_by=0", nodes, []string{"rentable", "rented_by"}},
+		{"/v2/nodes?free_mru=10&free_mru_max=5&uptime_min=5&uptime_max=5", nodes, []string{"free_mru"}},
+		{"/v2/nodes?node_id=1&exclude_node_ids=1,2&farm_ids=1,2&exclude_farm_ids=2,1", nodes, []string{"farm_ids", "node_id"}},
+		{"/v2/nodes?country=belgium&country_not=Belgium&has_gpu=false&gpu_vendor=nvidia", nodes, []string{"country", "has_gpu"}},
+		{"/v2/nodes?created_after=10&created_before=10", nodes, []string{"created_after"}},
+		{"/v2/farms?free_ips=5&total_ips=2&certification_type=diy&certification_type_not=Diy", farms, []string{"certification_type", "free_ips"}},
+		{"/v2/farms?farm_id=1&exclude_farm_ids=1&twin_id=2&exclude_twin_ids=2&expand=policy", farms, []string{"farm_id", "twin_id"}},
+		{"/v2/twins?account_id=a&account_ids=b,c&relay=r", twins, []string{"account_id"}},
+		{"/v2/twins?account_id=a&account_ids=b,a", twins, nil},
+		{"/v2/contracts?type=Name&state=deleted&states=created,gone&types=rent,other", contracts, []string{"states", "types"}},
+		{"/v2/contracts?type=rent&deployment_data=x&deployment_hash=y&number_of_public_ips=1&has_public_ips=true", contracts, []string{"deployment_data", "deployment_hash", "has_public_ips", "number_of_public_ips"}},
+		{"/v2/contracts?created_after=20&created_before=10&type=node&deployment_hash=y", contracts, []string{"created_after"}},
+		{"/v2/contracts?type=name&node_id=1", contracts, []string{"node_id"}},
+		{"/v2/contracts?type=name&node_ids=1,2&farm_id=3&name=gw", contracts, []string{"farm_id", "node_ids"}},
+		{"/v2/contracts?type=node&name=gw", contracts, []string{"name"}},
+		{"/v2/contracts?type=rent&name_contains=gw", contracts, []string{"name_contains"}},
+		{"/v2/stats?status=Standby", stats, nil},
+		{"/v2/stats?status=offline&farm_id=1", stats, []string{"farm_id", "status"}},
+	}
+	for _, test := range tests {
+		params := invalidParams(t, test.parse(httptest.NewRequest("GET", test.url, nil)))
+		if !reflect.DeepEqual(params, test.params) {
+			t.Fatalf("%s: invalid params mismatch: expected: %v, found: %v", test.url, test.params, params)
+		}
+	}
+}
+
+func TestObjectParams(t *testing.T) {
+	fields := []string{"fields"}
+	tests := []struct {
+		url    string
+		known  []string
+		params []string
+	}{
+		{"/nodes/1?feilds=x", fields, nil},
+		{"/nodes/1?feilds=x&strict=true", fields, []string{"feilds"}},
+		{"/v2/nodes/1?feilds=x", fields, []string{"feilds"}},
+		{"/v2/nodes/1?feilds=x&strict=false", fields, nil},
+		{"/v2/nodes/1?fields=nodeId&strict=true", fields, nil},
+		{"/v2/nodes/1?fields=nodeId&expand=policy", []string{"fields", "expand"}, nil},
+		{"/v2/nodes/1/status?fields=status", nil, []string{"fields"}},
+	}
+	for _, test := range tests {
+		params := invalidParams(t, validateObjectParams(httptest.NewRequest("GET", test.url, nil), test.known...))
+		if !reflect.DeepEqual(params, test.params) {
+			t.Fatalf("%s: invalid params mismatch: expected: %v, found: %v", test.url, test.params, params)
+		}
+	}
+
+	// the handlers reject the unknown parameters before querying
+	var a App
+	handlers := []struct {
+		url     string
+		handler func(r *http.Request) (interface{}, mw.Response)
+	}{
+		{"/v2/nodes/1?feilds=x", a.getNode},
+		{"/v2/gateways/1?feilds=x", a.getGateway},
+		{"/v2/nodes/1/status?fields=status", a.getNodeStatus},
+		{"/v2/nodes/1/contracts?size=5", a.getNodeContracts},
+		{"/v2/twins/by_account/a?relay=r", a.getTwinByAccount},
+	}
+	for _, test := range handlers {
+		_, resp := test.handler(httptest.NewRequest("GET", test.url, nil))
+		if resp.Status() != http.StatusBadRequest || !types.IsErrorCode(resp.Err(), types.ErrCodeInvalidParam) {
+			t.Fatalf("%s: response mismatch: expected an invalid parameter, found: %d %v", test.url, resp.Status(), resp.Err())
+		}
+	}
+}
+
+func TestStrictParamsErrors(t *testing.T) {
+	var a App
+	r := httptest.NewRequest("GET", "/nodes?strict=true&free_mr=1&ipv4=yes&status=online", nil)
+	_, _, err := a.handleNodeRequestsQueryParams(r)
+	if !types.IsErrorCode(err, types.ErrCodeInvalidParam) {
+		t.Fatalf("error code mismatch: expected: %s, found: %v", types.ErrCodeInvalidParam, err)
+	}
+	typed := err.(*types.Error)
+	if len(typed.Details) != 3 || !strings.HasPrefix(typed.Message, "3 invalid parameters: ") {
+		t.Fatalf("aggregated error mismatch: %+v", typed)
+	}
+	for _, detail := range typed.Details {
+		if detail.Code != types.ErrCodeInvalidParam || !strings.Contains(typed.Message, detail.Message) {
+			t.Fatalf("error detail mismatch: %+v of %s", detail, typed.Message)
+		}
+	}
+
+	var errs paramErrors
+	errs.add(nil)
+	if errs.err() != nil {
+		t.Fatalf("error with no invalid params: %v", errs.err())
+	}
+	errs.add(paramError("size", "invalid size"))
+	if err := errs.err(); err.(*types.Error).Param != "size" || len(err.(*types.Error).Details) != 0 {
+		t.Fatalf("single error mismatch: %+v", err)
+	}
+	errs.add(err)
+	if err := errs.err(); len(err.(*types.Error).Details) != 4 {
+		t.Fatalf("details of the added aggregated error aren't flattened: %+v", err)
+	}
+}
+
+func TestNormalizeEnums(t *testing.T) {
+	var a App
+	nodeFilter, _, err := a.handleNodeRequestsQueryParams(httptest.NewRequest("GET", "/v2/nodes?status=UP&certification_type=certified&certification_type_not=DIY", nil))
+	if err != nil {
+		t.Fatalf("failed to parse node params: %s", err.Error())
+	}
+	if *nodeFilter.Status != "up" || *nodeFilter.CertificationType != "Certified" || *nodeFilter.CertificationTypeNot != "Diy" {
+		t.Fatalf("node enums aren't normalized: %s, %s, %s", *nodeFilter.Status, *nodeFilter.CertificationType, *nodeFilter.CertificationTypeNot)
+	}
+	contractFilter, _, err := a.handleContractRequestsQueryParams(httptest.NewRequest("GET", "/contracts?type=NAME&state=gracePeriod", nil))
+	if err != nil {
+		t.Fatalf("failed to parse contract params: %s", err.Error())
+	}
+	if *contractFilter.Type != "name" || *contractFilter.State != "GracePeriod" {
+		t.Fatalf("contract enums aren't normalized: %s, %s", *contractFilter.Type, *contractFilter.State)
+	}
+	farmFilter, _, err := a.handleFarmRequestsQueryParams(httptest.NewRequest("GET", "/farms?certification_type=gold", nil))
+	if err != nil {
+		t.Fatalf("failed to parse farm params: %s", err.Error())
+	}
+	if *farmFilter.CertificationType != "gold" {
+		t.Fatalf("invalid enum value changed: %s", *farmFilter.CertificationType)
+	}
+}
+
+func TestReservedResources(t *testing.T) {
+	tests := []struct {
+		total    types.Capacity
+		reserved types.Capacity
+	}{
+		{types.Capacity{}, types.Capacity{MRU: 2 * gridtypes.Gigabyte, SRU: 100 * gridtypes.Gigabyte}},
+		{types.Capacity{CRU: 4, MRU: 16 * gridtypes.Gigabyte}, types.Capacity{MRU: 2 * gridtypes.Gigabyte, SRU: 100 * gridtypes.Gigabyte}},
+		{types.Capacity{MRU: 20 * gridtypes.Gigabyte}, types.Capacity{MRU: 2 * gridtypes.Gigabyte, SRU: 100 * gridtypes.Gigabyte}},
+		{types.Capacity{CRU: 32, MRU: 256 * gridtypes.Gigabyte, SRU: gridtypes.Terabyte}, types.Capacity{MRU: 27487790694, SRU: 100 * gridtypes.Gigabyte}},
+	}
+	for _, test := range tests {
+		if reserved := reservedResources(test.total); reserved != test.reserved {
+			t.Fatalf("reserved resources of %+v mismatch: expected: %+v, found: %+v", test.total, test.reserved, reserved)
+		}
+	}
+}
+
+func TestNewNodeContracts(t *testing.T) {
+	node := types.NodeWithNestedCapacity{NodeID: 1}
+	node.Capacity.Total = types.Capacity{CRU: 8, MRU: 32 * gridtypes.Gigabyte, SRU: gridtypes.Terabyte}
+	contracts := []db.NodeContract{
+		{ContractID: 1, TwinID: 7, State: "Created", Cru: 2, Mru: uint64(4 * gridtypes.Gigabyte), Sru: uint64(50 * gridtypes.Gigabyte)},
+		{ContractID: 2, TwinID: 8, State: "GracePeriod", Cru: 1, Mru: uint64(gridtypes.Gigabyte), Hru: uint64(gridtypes.Terabyte)},
+	}
+	sum := types.Capacity{CRU: 3, MRU: 5 * gridtypes.Gigabyte, SRU: 50 * gridtypes.Gigabyte, HRU: gridtypes.Terabyte}
+	reserved := types.Capacity{MRU: 3435973837, SRU: 100 * gridtypes.Gigabyte}
+	used := sum
+	addCapacity(&used, reserved)
+
+	node.Capacity.Used = used
+	res := newNodeContracts(node, contracts)
+	if res.NodeID != 1 || len(res.Contracts) != 2 || res.Contracts[1].Resources.HRU != gridtypes.Terabyte || res.Contracts[1].State != "GracePeriod" {
+		t.Fatalf("node contracts mismatch: %+v", res)
+	}
+	if res.Sum != sum || res.Reserved != reserved || !res.Reconciled {
+		t.Fatalf("reconciliation mismatch: sum: %+v, reserved: %+v, used: %+v, reconciled: %t", res.Sum, res.Reserved, res.UsedResources, res.Reconciled)
+	}
+
+	node.Capacity.Used.CRU++
+	if res := newNodeContracts(node, contracts); res.Reconciled {
+		t.Fatalf("node with unaccounted used resources is reconciled: %+v", res)
+	}
+	node.Capacity.Used = reserved
+	if res := newNodeContracts(node, nil); !res.Reconciled || len(res.Contracts) != 0 || res.Sum != (types.Capacity{}) {
+		t.Fatalf("node with no contracts mismatch: %+v", res)
+	}
+}
@@ -12,14 +12,14 @@ import (
 
 // ErrNodeNotFound creates new error type to define node existence or server problem
 var (
-	ErrNodeNotFound    = errors.New("node not found")
-	ErrGatewayNotFound = errors.New("gateway not found")
+	ErrNodeNotFound    = types.NewError(types.ErrCodeNodeNotFound, "node not found")
+	ErrGatewayNotFound = types.NewError(types.ErrCodeGatewayNotFound, "gateway not found")
 )
 
 // ErrBadGateway creates new error type to define node existence or server problem
 var (
-	ErrBadGateway = errors.New("bad gateway")
-	ErrBadRequest = errors.New("bad request")
+	ErrBadGateway = types.NewError(types.ErrCodeBadGateway, "bad gateway")
+	ErrBadRequest = types.NewError(types.ErrCodeBadRequest, "bad request")
 )
 
 // App is the main app objects
@@ -28,6 +28,8 @@ type App struct {
 	lruCache       *cache.Cache
 	releaseVersion string
 	relayClient    rmb.Client
+	priceSource    PriceSource
+	nodeEvents     *NodeEvents
 }
 
 type ErrorMessage struct {
@@ -1,14 +1,21 @@
 package mw
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
 )
 
+// RequestIDHeader carries the id of the request, it's generated if not set by the caller
+const RequestIDHeader = "X-Request-ID"
+
 // Response interface
 type Response interface {
 	Status() int
@@ -23,6 +30,12 @@ type Response interface {
 // Action interface
 type Action func(r *http.Request) (interface{}, Response)
 
+// Raw is a response body written as is with its content type instead of being encoded to json
+type Raw struct {
+	ContentType string
+	Body        []byte
+}
+
 // ProxyAction interface
 type ProxyAction func(r *http.Request) (*http.Response, Response)
 
@@ -34,6 +47,36 @@ func exposeHeaders(w *http.ResponseWriter) {
 	(*w).Header().Set("Access-Control-Expose-Headers", "*")
 }
 
+// requestID returns the id set by the caller or a newly generated one
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		log.Error().Err(err).Msg("failed to generate request id")
+	}
+	return hex.EncodeToString(id)
+}
+
+// errorObject builds the error model of a failed response. the code and param are
+// taken from the error if it wraps a types.Error, otherwise the code is derived from the status
+func errorObject(err error, status int, id string) types.Error {
+	object := types.Error{
+		Code:      types.ErrorCodeFromStatus(status),
+		Message:   err.Error(),
+		RequestID: id,
+		Legacy:    err.Error(),
+	}
+	var typed *types.Error
+	if errors.As(err, &typed) {
+		object.Code = typed.Code
+		object.Param = typed.Param
+		object.Details = typed.Details
+	}
+	return object
+}
+
 // AsProxyHandlerFunc returns the response in `_, response`, and proxy the http response in `response, nil`
 func AsProxyHandlerFunc(a ProxyAction) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +85,7 @@ func AsProxyHandlerFunc(a ProxyAction) http.HandlerFunc {
 			_ = r.Body.Close()
 		}()
 		enableCors(&w)
+		id := requestID(r)
 		response, result := a(r)
 		defer func() {
 			if response != nil {
@@ -67,6 +111,7 @@ func AsProxyHandlerFunc(a ProxyAction) http.HandlerFunc {
 				w.Header().Add(k, v)
 			}
 		}
+		w.Header().Set(RequestIDHeader, id)
 
 		// status code
 		if result != nil {
@@ -81,14 +126,7 @@ func AsProxyHandlerFunc(a ProxyAction) http.HandlerFunc {
 
 		// body
 		if result != nil && result.Err() != nil {
-			// to be consistent with https://github.com/threefoldtech/rmb_go/blob/825c23c921d395294f3d28d5d9f1d009e8fde9d6/models.go#L35
-			object := struct {
-				Status  string `json:",omitempty"`
-				Message string `json:",omitempty"`
-			}{
-				Message: result.Err().Error(),
-				Status:  http.StatusText(result.Status()),
-			}
+			object := errorObject(result.Err(), result.Status(), id)
 			if err := json.NewEncoder(w).Encode(object); err != nil {
 				log.Error().Err(err).Msg("failed to encode return object")
 			}
@@ -109,10 +147,18 @@ func AsHandlerFunc(a Action) http.HandlerFunc {
 		}()
 		enableCors(&w)
 		exposeHeaders(&w)
+		id := requestID(r)
 
 		object, result := a(r)
 
-		w.Header().Set("Content-Type", "application/json")
+		raw, isRaw := object.(Raw)
+		if isRaw && (result == nil || result.Err() == nil) {
+			w.Header().Set("Content-Type", raw.ContentType)
+		} else {
+			isRaw = false
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.Header().Set(RequestIDHeader, id)
 
 		if result == nil {
 			w.WriteHeader(http.StatusOK)
@@ -127,15 +173,17 @@ func AsHandlerFunc(a Action) http.HandlerFunc {
 
 			w.WriteHeader(result.Status())
 			if err := result.Err(); err != nil {
-				log.Error().Msgf("%s", err.Error())
-				object = struct {
-					Error string `json:"error"`
-				}{
-					Error: err.Error(),
-				}
+				log.Error().Str("request_id", id).Msgf("%s", err.Error())
+				object = errorObject(err, result.Status(), id)
 			}
 		}
 
+		if isRaw {
+			if _, err := w.Write(raw.Body); err != nil {
+				log.Error().Err(err).Msg("failed to write returned object")
+			}
+			return
+		}
 		if err := json.NewEncoder(w).Encode(object); err != nil {
 			log.Error().Err(err).Msg("failed to encode return object")
 		}
@@ -9,11 +9,13 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
 )
 
 type errType struct {
-	Status  string `json:",omitempty"`
-	Message string `json:",omitempty"`
+	Code    types.ErrorCode `json:"code"`
+	Message string          `json:"message"`
 }
 
 var (
@@ -35,11 +37,11 @@ var (
 	ErrEaxmple2 = errors.New("another internal grid proxy failure")
 
 	JSONErrExample1 = errType{
-		Status:  http.StatusText(http.StatusInternalServerError),
+		Code:    types.ErrCodeInternal,
 		Message: ErrExample1.Error(),
 	}
 	JSONErrExample2 = errType{
-		Status:  http.StatusText(http.StatusInternalServerError),
+		Code:    types.ErrCodeInternal,
 		Message: ErrEaxmple2.Error(),
 	}
 
@@ -108,6 +110,7 @@ func TestProxyGridProxyError(t *testing.T) {
 	}
 	delete(header, "Access-Control-Allow-Origin")
 	delete(header, "Content-Type")
+	header.Del(RequestIDHeader)
 	if !reflect.DeepEqual(w.Header(), ProxyHeaderExample1) {
 		t.Fatalf("grid proxy header mismatch: expected: %v, found: %v", ProxyHeaderExample1, w.Header())
 	}
@@ -130,6 +133,7 @@ func TestProxyUpstreamError(t *testing.T) {
 		t.Fatalf("invalid Access-Control-Allow-Origin header: %+v", header)
 	}
 	delete(header, "Access-Control-Allow-Origin")
+	header.Del(RequestIDHeader)
 	if w.Result().StatusCode != http.StatusBadRequest {
 		t.Fatalf("upstream error status code mismatch: expected: %d, found: %d", http.StatusBadRequest, w.Result().StatusCode)
 	}
@@ -154,6 +158,7 @@ func TestProxySuccess(t *testing.T) {
 		t.Fatalf("invalid Access-Control-Allow-Origin header: %+v", header)
 	}
 	delete(header, "Access-Control-Allow-Origin")
+	header.Del(RequestIDHeader)
 	if !reflect.DeepEqual(w.Header(), HeaderExample2) {
 		t.Fatalf("upstream success header mismatch: expected: %v, found: %v", HeaderExample2, w.Header())
 	}
@@ -179,6 +184,7 @@ func TestBothResponses(t *testing.T) {
 	}
 	delete(header, "Access-Control-Allow-Origin")
 	delete(header, "Content-Type")
+	header.Del(RequestIDHeader)
 	if !reflect.DeepEqual(w.Header(), ProxyHeaderExample2) {
 		t.Fatalf("both result header mismatch: expected: %v, found: %v", ProxyHeaderExample2, w.Header())
 	}
@@ -190,3 +196,86 @@ func TestBothResponses(t *testing.T) {
 		t.Fatalf("both result error mismatch: expected: %v, found: %v", JSONErrExample2, err)
 	}
 }
+
+func TestErrorModel(t *testing.T) {
+	handler := AsHandlerFunc(func(r *http.Request) (interface{}, Response) {
+		return nil, BadRequest(&types.Error{Code: types.ErrCodeInvalidParam, Param: "size", Message: "couldn't parse size"})
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "some-id")
+	handler(w, req)
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Fatalf("status code mismatch: expected: %d, found: %d", http.StatusBadRequest, w.Result().StatusCode)
+	}
+	if w.Header().Get(RequestIDHeader) != "some-id" {
+		t.Fatalf("request id header mismatch: expected: some-id, found: %s", w.Header().Get(RequestIDHeader))
+	}
+	var err types.Error
+	if err := json.NewDecoder(w.Body).Decode(&err); err != nil {
+		t.Fatalf("failed to decode response body: %s", err.Error())
+	}
+	expected := types.Error{
+		Code:      types.ErrCodeInvalidParam,
+		Message:   "couldn't parse size",
+		Param:     "size",
+		RequestID: "some-id",
+		Legacy:    "couldn't parse size",
+	}
+	if !reflect.DeepEqual(err, expected) {
+		t.Fatalf("error model mismatch: expected: %+v, found: %+v", expected, err)
+	}
+}
+
+func TestErrorModelFromStatus(t *testing.T) {
+	handler := AsHandlerFunc(func(r *http.Request) (interface{}, Response) {
+		return nil, NotFound(ErrExample1)
+	})
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	var err types.Error
+	if err := json.NewDecoder(w.Body).Decode(&err); err != nil {
+		t.Fatalf("failed to decode response body: %s", err.Error())
+	}
+	if err.Code != types.ErrCodeNotFound {
+		t.Fatalf("error code mismatch: expected: %s, found: %s", types.ErrCodeNotFound, err.Code)
+	}
+	if err.RequestID == "" || err.RequestID != w.Header().Get(RequestIDHeader) {
+		t.Fatalf("request id mismatch: body: %s, header: %s", err.RequestID, w.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestRawBody(t *testing.T) {
+	handler := AsHandlerFunc(func(r *http.Request) (interface{}, Response) {
+		return Raw{ContentType: "text/csv", Body: []byte("a,b\n1,2\n")}, Ok().WithHeader("Content-Disposition", "attachment")
+	})
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("content type mismatch: expected: text/csv, found: %s", w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("Content-Disposition") != "attachment" {
+		t.Fatalf("header mismatch: expected: attachment, found: %s", w.Header().Get("Content-Disposition"))
+	}
+	if w.Body.String() != "a,b\n1,2\n" {
+		t.Fatalf("body mismatch: expected: %q, found: %q", "a,b\n1,2\n", w.Body.String())
+	}
+}
+
+func TestRawBodyError(t *testing.T) {
+	handler := AsHandlerFunc(func(r *http.Request) (interface{}, Response) {
+		return Raw{ContentType: "text/csv"}, Error(ErrExample1)
+	})
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("content type mismatch: expected: application/json, found: %s", w.Header().Get("Content-Type"))
+	}
+	var err errType
+	if err := json.NewDecoder(w.Body).Decode(&err); err != nil {
+		t.Fatalf("failed to decode response body: %s", err.Error())
+	}
+	if err != JSONErrExample1 {
+		t.Fatalf("error mismatch: expected: %v, found: %v", JSONErrExample1, err)
+	}
+}
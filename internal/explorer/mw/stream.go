@@ -0,0 +1,115 @@
+package mw
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// streamHeartbeat is the interval of the comments sent to keep idle streams open through proxies
+const streamHeartbeat = 30 * time.Second
+
+// Event is a server-sent event
+type Event struct {
+	// ID is sent back by the caller in the Last-Event-ID header when it reconnects
+	ID   string
+	Type string
+	// Data is sent json encoded
+	Data interface{}
+}
+
+// StreamAction returns the events to stream to the caller, the stream ends when the channel is closed
+// or the caller disconnects. the response is sent instead of streaming if it's set
+type StreamAction func(r *http.Request) (<-chan Event, Response)
+
+// AsStreamHandlerFunc streams the events returned by the action as server-sent events
+func AsStreamHandlerFunc(a StreamAction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enableCors(&w)
+		exposeHeaders(&w)
+		id := requestID(r)
+		w.Header().Set(RequestIDHeader, id)
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeErrorResponse(w, Error(errors.New("streaming is not supported")), id)
+			return
+		}
+		events, result := a(r)
+		if result != nil {
+			writeErrorResponse(w, result, id)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					log.Error().Err(err).Str("request_id", id).Msg("failed to write event")
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes the event in the server-sent events format
+func writeEvent(w http.ResponseWriter, event Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode event data")
+	}
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	if event.Type != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", event.Type); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// writeErrorResponse writes the response that ended the request before streaming
+func writeErrorResponse(w http.ResponseWriter, result Response, id string) {
+	for k, v := range result.Header() {
+		for _, v := range v {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(result.Status())
+	err := result.Err()
+	if err == nil {
+		return
+	}
+	log.Error().Str("request_id", id).Msgf("%s", err.Error())
+	if err := json.NewEncoder(w).Encode(errorObject(err, result.Status(), id)); err != nil {
+		log.Error().Err(err).Msg("failed to encode return object")
+	}
+}
@@ -0,0 +1,59 @@
+package mw
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+func TestStreamEvents(t *testing.T) {
+	handler := AsStreamHandlerFunc(func(r *http.Request) (<-chan Event, Response) {
+		events := make(chan Event, 2)
+		events <- Event{ID: "1", Type: "status", Data: map[string]int{"nodeId": 1}}
+		events <- Event{Data: "no id"}
+		close(events)
+		return events, nil
+	})
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatalf("status code mismatch: expected: %d, found: %d", http.StatusOK, w.Result().StatusCode)
+	}
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("invalid Content-Type header: %+v", w.Header())
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("invalid Access-Control-Allow-Origin header: %+v", w.Header())
+	}
+	expected := "id: 1\nevent: status\ndata: {\"nodeId\":1}\n\ndata: \"no id\"\n\n"
+	if w.Body.String() != expected {
+		t.Fatalf("stream body mismatch: expected: %q, found: %q", expected, w.Body.String())
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	handler := AsStreamHandlerFunc(func(r *http.Request) (<-chan Event, Response) {
+		return nil, BadRequest(&types.Error{Code: types.ErrCodeInvalidParam, Param: "types", Message: "unknown event type"})
+	})
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Fatalf("status code mismatch: expected: %d, found: %d", http.StatusBadRequest, w.Result().StatusCode)
+	}
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("invalid Content-Type header: %+v", w.Header())
+	}
+	var err types.Error
+	if err := json.NewDecoder(w.Body).Decode(&err); err != nil {
+		t.Fatalf("failed to decode response body: %s", err.Error())
+	}
+	if err.Code != types.ErrCodeInvalidParam || err.Param != "types" {
+		t.Fatalf("error model mismatch: %+v", err)
+	}
+	if err.RequestID == "" || err.RequestID != w.Header().Get(RequestIDHeader) {
+		t.Fatalf("request id mismatch: body: %s, header: %s", err.RequestID, w.Header().Get(RequestIDHeader))
+	}
+}
@@ -0,0 +1,126 @@
+package explorer
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+// gatewayFields are the node fields queried to resolve the hostnames
+var gatewayFields = []string{"nodeId", "farmId", "twinId", "status", "publicConfig"}
+
+// maxNameLength is the max length of a dns label
+const maxNameLength = 63
+
+// nameParam returns the gateway name path parameter in lower case, a name is a single dns label
+func nameParam(r *http.Request) (string, error) {
+	value := mux.Vars(r)["name"]
+	name := strings.ToLower(value)
+	if !isValidName(name) {
+		return "", paramError("name", "invalid name %s, must be a single dns label of up to %d letters, digits and hyphens not starting or ending with a hyphen", value, maxNameLength)
+	}
+	return name, nil
+}
+
+// isValidName returns true if the lower case name is a valid dns label
+func isValidName(name string) bool {
+	if name == "" || len(name) > maxNameLength || name[0] == '-' || name[len(name)-1] == '-' {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// nameAvailability returns the availability of the gateway name, a name is reserved by its created or grace period name contract
+func (a *App) nameAvailability(name string) (types.NameAvailability, error) {
+	availability := types.NameAvailability{Name: name, Available: true}
+	contractType := "name"
+	filter := types.ContractFilter{
+		Type:   &contractType,
+		Name:   &name,
+		States: []string{"Created", "GracePeriod"},
+	}
+	contracts, _, err := a.db.GetContracts(filter, types.Limit{Page: 1, Size: 1})
+	if err != nil {
+		return availability, errors.Wrap(err, "couldn't get name contracts")
+	}
+	if len(contracts) == 0 {
+		return availability, nil
+	}
+	availability.Available = false
+	availability.ContractID = contracts[0].ContractID
+	availability.TwinID = contracts[0].TwinID
+	availability.State = contracts[0].State
+	availability.CreatedAt = contracts[0].CreatedAt
+	return availability, nil
+}
+
+// fqdnParam returns the hostname of the fqdn query parameter in lower case without the trailing dot
+func fqdnParam(r *http.Request) (string, error) {
+	fqdn := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(r.URL.Query().Get("fqdn"))), ".")
+	if fqdn == "" {
+		return "", paramError("fqdn", "fqdn is required")
+	}
+	var errs paramErrors
+	if isStrict(r) {
+		errs.add(validateParams(r, []string{"fqdn"}, nil))
+	}
+	return fqdn, errs.err()
+}
+
+// gatewayResolution returns the gateway nodes with a domain the fqdn is equal to or a subdomain of. if the fqdn is a name
+// under a gateway domain, the availability of the name is returned too. the hostnames of the fqdn proxies can't be
+// resolved since they point to a gateway through dns records only
+func (a *App) gatewayResolution(fqdn string) (types.GatewayResolution, error) {
+	resolution := types.GatewayResolution{FQDN: fqdn, Gateways: []types.GatewayCandidate{}}
+	trueval := true
+	filter := types.NodeFilter{Domain: &trueval, IPv4: &trueval}
+	dbNodes, _, err := a.db.GetNodes(filter, types.Limit{Page: 1, Size: math.MaxInt32}, gatewayFields...)
+	if err != nil {
+		return resolution, errors.Wrap(err, "couldn't get gateways")
+	}
+	var name string
+	for _, dbNode := range dbNodes {
+		node := nodeFromDBNode(dbNode)
+		domain := strings.TrimSuffix(strings.ToLower(node.PublicConfig.Domain), ".")
+		if domain == "" || (fqdn != domain && !strings.HasSuffix(fqdn, "."+domain)) {
+			continue
+		}
+		resolution.Gateways = append(resolution.Gateways, types.GatewayCandidate{
+			NodeID:       node.NodeID,
+			FarmID:       node.FarmID,
+			TwinID:       node.TwinID,
+			Status:       node.Status,
+			PublicConfig: node.PublicConfig,
+		})
+		if label := strings.TrimSuffix(fqdn, "."+domain); label != fqdn && isValidName(label) {
+			name = label
+		}
+	}
+	sort.SliceStable(resolution.Gateways, func(i, j int) bool {
+		gi, gj := resolution.Gateways[i], resolution.Gateways[j]
+		if (gi.Status == db.NodeUp) != (gj.Status == db.NodeUp) {
+			return gi.Status == db.NodeUp
+		}
+		return len(gi.PublicConfig.Domain) > len(gj.PublicConfig.Domain)
+	})
+	if name == "" {
+		return resolution, nil
+	}
+	availability, err := a.nameAvailability(name)
+	if err != nil {
+		return resolution, err
+	}
+	resolution.Name = &availability
+	return resolution, nil
+}
@@ -0,0 +1,197 @@
+package explorer
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+func TestNameParam(t *testing.T) {
+	tests := []struct {
+		value string
+		name  string
+		valid bool
+	}{
+		{"foo", "foo", true},
+		{"Foo", "foo", true},
+		{"MY-GATEWAY-1", "my-gateway-1", true},
+		{"a", "a", true},
+		{"123", "123", true},
+		{strings.Repeat("a", 63), strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), "", false},
+		{"", "", false},
+		{"foo.bar", "", false},
+		{"-foo", "", false},
+		{"foo-", "", false},
+		{"foo_bar", "", false},
+		{"foo bar", "", false},
+		{"föo", "", false},
+	}
+	for _, test := range tests {
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/names/name", nil), map[string]string{"name": test.value})
+		name, err := nameParam(r)
+		if !test.valid {
+			if !types.IsErrorCode(err, types.ErrCodeInvalidParam) || err.(*types.Error).Param != "name" {
+				t.Fatalf("%q: error mismatch: expected an invalid name, found: %v", test.value, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", test.value, err.Error())
+		}
+		if name != test.name {
+			t.Fatalf("%q: name mismatch: expected: %s, found: %s", test.value, test.name, name)
+		}
+	}
+}
+
+func TestGetNameNormalizesName(t *testing.T) {
+	database := &fakeDatabase{
+		contracts: []db.DBContract{{ContractID: 3, TwinID: 4, Type: "name", Name: "foo", State: "Created"}},
+	}
+	r := mux.SetURLVars(httptest.NewRequest("GET", "/names/Foo", nil), map[string]string{"name": "Foo"})
+	result, resp := testApp(database, nil).getName(r)
+	if resp.Err() != nil {
+		t.Fatalf("unexpected error: %s", resp.Err().Error())
+	}
+	expected := types.NameAvailability{Name: "foo", Available: false, ContractID: 3, TwinID: 4, State: "Created"}
+	if availability := result.(types.NameAvailability); availability != expected {
+		t.Fatalf("availability mismatch: expected: %+v, found: %+v", expected, availability)
+	}
+}
+
+func TestNameAvailability(t *testing.T) {
+	database := &fakeDatabase{
+		contracts: []db.DBContract{
+			{ContractID: 1, TwinID: 4, Type: "name", Name: "taken", State: "Created", CreatedAt: 10},
+			{ContractID: 2, TwinID: 5, Type: "name", Name: "grace", State: "GracePeriod", CreatedAt: 20},
+			{ContractID: 3, TwinID: 6, Type: "name", Name: "deleted", State: "Deleted", CreatedAt: 30},
+			{ContractID: 4, TwinID: 7, Type: "node", Name: "node", State: "Created", CreatedAt: 40},
+		},
+	}
+	tests := []struct {
+		name     string
+		expected types.NameAvailability
+	}{
+		{"taken", types.NameAvailability{Name: "taken", ContractID: 1, TwinID: 4, State: "Created", CreatedAt: 10}},
+		{"grace", types.NameAvailability{Name: "grace", ContractID: 2, TwinID: 5, State: "GracePeriod", CreatedAt: 20}},
+		{"deleted", types.NameAvailability{Name: "deleted", Available: true}},
+		{"node", types.NameAvailability{Name: "node", Available: true}},
+		{"free", types.NameAvailability{Name: "free", Available: true}},
+	}
+	for _, test := range tests {
+		availability, err := testApp(database, nil).nameAvailability(test.name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err.Error())
+		}
+		if availability != test.expected {
+			t.Fatalf("%s: availability mismatch: expected: %+v, found: %+v", test.name, test.expected, availability)
+		}
+	}
+}
+
+func TestGatewayResolution(t *testing.T) {
+	gateway := func(id int64, status, domain, ipv4 string) db.Node {
+		return db.Node{NodeID: id, FarmID: 1, TwinID: id + 100, Status: status, Domain: domain, Ipv4: ipv4}
+	}
+	database := &fakeDatabase{
+		nodes: []db.Node{
+			gateway(1, "down", "gw.example.com", "1.1.1.1/24"),
+			gateway(2, db.NodeUp, "example.com", "1.1.1.2/24"),
+			gateway(3, db.NodeUp, "GW.Example.com.", "1.1.1.3/24"),
+			gateway(4, db.NodeUp, "other.com", "1.1.1.4/24"),
+			// gateways need a public ipv4
+			gateway(5, db.NodeUp, "gw.example.com", ""),
+			gateway(6, db.NodeUp, "", "1.1.1.6/24"),
+			gateway(7, "down", "ample.com", "1.1.1.7/24"),
+		},
+		contracts: []db.DBContract{{ContractID: 9, TwinID: 3, Type: "name", Name: "taken", State: "Created"}},
+	}
+	tests := []struct {
+		fqdn  string
+		nodes []int
+		name  *types.NameAvailability
+	}{
+		{
+			// the up gateways come first then the most specific domains
+			fqdn:  "foo.gw.example.com",
+			nodes: []int{3, 2, 1},
+			name:  &types.NameAvailability{Name: "foo", Available: true},
+		},
+		{
+			fqdn:  "taken.gw.example.com",
+			nodes: []int{3, 2, 1},
+			name:  &types.NameAvailability{Name: "taken", ContractID: 9, TwinID: 3, State: "Created"},
+		},
+		{
+			// the domain of a gateway is a name under a shorter domain
+			fqdn:  "gw.example.com",
+			nodes: []int{3, 2, 1},
+			name:  &types.NameAvailability{Name: "gw", Available: true},
+		},
+		{
+			fqdn:  "example.com",
+			nodes: []int{2},
+		},
+		{
+			// deeper subdomains match the domain but aren't names
+			fqdn:  "a.b.other.com",
+			nodes: []int{4},
+		},
+		{
+			fqdn:  "a_b.other.com",
+			nodes: []int{4},
+		},
+		{
+			// the domains match on the label boundaries only
+			fqdn:  "notexample.com",
+			nodes: []int{},
+		},
+		{
+			fqdn:  "unknown.org",
+			nodes: []int{},
+		},
+	}
+	for _, test := range tests {
+		resolution, err := testApp(database, nil).gatewayResolution(test.fqdn)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.fqdn, err.Error())
+		}
+		if resolution.FQDN != test.fqdn || resolution.Gateways == nil {
+			t.Fatalf("%s: resolution mismatch: %+v", test.fqdn, resolution)
+		}
+		nodes := []int{}
+		for _, gateway := range resolution.Gateways {
+			nodes = append(nodes, gateway.NodeID)
+		}
+		if !reflect.DeepEqual(nodes, test.nodes) {
+			t.Fatalf("%s: gateways mismatch: expected: %v, found: %v", test.fqdn, test.nodes, nodes)
+		}
+		if !reflect.DeepEqual(resolution.Name, test.name) {
+			t.Fatalf("%s: name mismatch: expected: %+v, found: %+v", test.fqdn, test.name, resolution.Name)
+		}
+	}
+}
+
+func TestResolveGatewayFQDN(t *testing.T) {
+	database := &fakeDatabase{nodes: []db.Node{{NodeID: 1, Status: db.NodeUp, Domain: "gw.example.com", Ipv4: "1.1.1.1/24"}}}
+	r := httptest.NewRequest("GET", "/gateways/resolve?fqdn=%20Foo.GW.Example.com.%20", nil)
+	result, resp := testApp(database, nil).resolveGateway(r)
+	if resp.Err() != nil {
+		t.Fatalf("unexpected error: %s", resp.Err().Error())
+	}
+	resolution := result.(types.GatewayResolution)
+	if resolution.FQDN != "foo.gw.example.com" || len(resolution.Gateways) != 1 || resolution.Name == nil || resolution.Name.Name != "foo" {
+		t.Fatalf("resolution of the normalized fqdn mismatch: %+v", resolution)
+	}
+
+	_, resp = testApp(database, nil).resolveGateway(httptest.NewRequest("GET", "/gateways/resolve", nil))
+	if resp.Status() != 400 {
+		t.Fatalf("status of a missing fqdn mismatch: expected: 400, found: %d", resp.Status())
+	}
+}
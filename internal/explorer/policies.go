@@ -0,0 +1,135 @@
+package explorer
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+const (
+	// policiesCacheTTL is how long the policies embedded with expand=policy are served from the cache,
+	// the policies rarely change on chain
+	policiesCacheTTL = 5 * time.Minute
+	// expandPolicy embeds the policy of the returned nodes or farms
+	expandPolicy = "policy"
+)
+
+// expandsPolicy checks if the request asks to embed the policies with expand=policy
+func expandsPolicy(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("expand")
+	if value == "" {
+		return false, nil
+	}
+	expand := false
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if item != expandPolicy {
+			return false, paramError("expand", "unknown expand %s, must be one of: %s", item, expandPolicy)
+		}
+		expand = true
+	}
+	return expand, nil
+}
+
+// expandFields returns the fields to query and to return when the policy with the given id field is embedded.
+// the fields are returned as is if no fields are requested since all of them are returned
+func expandFields(fields []string, idField, policyField string) ([]string, []string) {
+	if len(fields) == 0 {
+		return fields, fields
+	}
+	dbFields := append([]string{idField}, fields...)
+	return dbFields, append(fields[:len(fields):len(fields)], policyField)
+}
+
+// policyIDParam returns the policy id path parameter
+func policyIDParam(r *http.Request) (uint32, error) {
+	value := mux.Vars(r)["policy_id"]
+	policyID, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, paramError("policy_id", "invalid policy id %s: %s", value, err.Error())
+	}
+	return uint32(policyID), nil
+}
+
+// pricingPolicies returns the pricing policies by id from the cache, or the database
+func (a *App) pricingPolicies() (map[uint32]types.PricingPolicy, error) {
+	key := "pricing-policies"
+	if cached, ok := a.lruCache.Get(key); ok {
+		return cached.(map[uint32]types.PricingPolicy), nil
+	}
+	dbPolicies, err := a.db.GetPricingPolicies()
+	if err != nil {
+		return nil, err
+	}
+	policies := make(map[uint32]types.PricingPolicy, len(dbPolicies))
+	for _, policy := range dbPolicies {
+		policies[policy.PricingPolicyID] = pricingPolicyFromDBPricingPolicy(policy)
+	}
+	a.lruCache.Set(key, policies, policiesCacheTTL)
+	return policies, nil
+}
+
+// farmingPolicies returns the farming policies by id from the cache, or the database
+func (a *App) farmingPolicies() (map[uint32]types.FarmingPolicy, error) {
+	key := "farming-policies"
+	if cached, ok := a.lruCache.Get(key); ok {
+		return cached.(map[uint32]types.FarmingPolicy), nil
+	}
+	dbPolicies, err := a.db.GetFarmingPolicies()
+	if err != nil {
+		return nil, err
+	}
+	policies := make(map[uint32]types.FarmingPolicy, len(dbPolicies))
+	for _, policy := range dbPolicies {
+		policies[policy.FarmingPolicyID] = farmingPolicyFromDBFarmingPolicy(policy)
+	}
+	a.lruCache.Set(key, policies, policiesCacheTTL)
+	return policies, nil
+}
+
+// expandFarmsPolicy embeds the pricing policy of each farm, it's left unset if the policy doesn't exist
+func (a *App) expandFarmsPolicy(farms []types.Farm) error {
+	policies, err := a.pricingPolicies()
+	if err != nil {
+		return err
+	}
+	for idx := range farms {
+		if policy, ok := policies[uint32(farms[idx].PricingPolicyID)]; ok {
+			farms[idx].PricingPolicy = &policy
+		}
+	}
+	return nil
+}
+
+// expandNodesPolicy embeds the farming policy of each node, it's left unset if the policy doesn't exist
+func (a *App) expandNodesPolicy(nodes []types.Node) error {
+	policies, err := a.farmingPolicies()
+	if err != nil {
+		return err
+	}
+	for idx := range nodes {
+		if policy, ok := policies[uint32(nodes[idx].FarmingPolicyID)]; ok {
+			nodes[idx].FarmingPolicy = &policy
+		}
+	}
+	return nil
+}
+
+// expandNodePolicy embeds the farming policy of the node, it's left unset if the policy doesn't exist
+func (a *App) expandNodePolicy(node *types.NodeWithNestedCapacity) error {
+	policies, err := a.farmingPolicies()
+	if err != nil {
+		return err
+	}
+	if policy, ok := policies[uint32(node.FarmingPolicyID)]; ok {
+		node.FarmingPolicy = &policy
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package explorer
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+func TestExpandsPolicy(t *testing.T) {
+	tests := []struct {
+		query  string
+		expand bool
+		err    bool
+	}{
+		{"", false, false},
+		{"expand=policy", true, false},
+		{"expand=policy,%20policy,", true, false},
+		{"expand=,", false, false},
+		{"expand=Policy", false, true},
+		{"expand=policy,farm", false, true},
+	}
+	for _, test := range tests {
+		expand, err := expandsPolicy(httptest.NewRequest("GET", "/farms?"+test.query, nil))
+		if test.err {
+			if !types.IsErrorCode(err, types.ErrCodeInvalidParam) || err.(*types.Error).Param != "expand" {
+				t.Fatalf("%s: error mismatch: expected an invalid expand, found: %v", test.query, err)
+			}
+			continue
+		}
+		if err != nil || expand != test.expand {
+			t.Fatalf("%s: expand mismatch: expected: %t, found: %t, %v", test.query, test.expand, expand, err)
+		}
+	}
+}
+
+func TestExpandFields(t *testing.T) {
+	dbFields, fields := expandFields(nil, "farmingPolicyId", "farmingPolicy")
+	if dbFields != nil || fields != nil {
+		t.Fatalf("fields mismatch with no requested fields: %v, %v", dbFields, fields)
+	}
+	requested := make([]string, 2, 10)
+	copy(requested, []string{"nodeId", "status"})
+	dbFields, fields = expandFields(requested, "farmingPolicyId", "farmingPolicy")
+	if !reflect.DeepEqual(dbFields, []string{"farmingPolicyId", "nodeId", "status"}) {
+		t.Fatalf("queried fields mismatch: %v", dbFields)
+	}
+	if !reflect.DeepEqual(fields, []string{"nodeId", "status", "farmingPolicy"}) {
+		t.Fatalf("returned fields mismatch: %v", fields)
+	}
+	if requested[:3][2] != "" {
+		t.Fatalf("requested fields changed: %v", requested[:3])
+	}
+}
+
+func TestPolicyIDParam(t *testing.T) {
+	tests := []struct {
+		value    string
+		policyID uint32
+		err      bool
+	}{
+		{"1", 1, false},
+		{"4294967295", 4294967295, false},
+		{"4294967296", 0, true},
+		{"-1", 0, true},
+	}
+	for _, test := range tests {
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/pricing_policies/"+test.value, nil), map[string]string{"policy_id": test.value})
+		policyID, err := policyIDParam(r)
+		if test.err != (err != nil) || policyID != test.policyID {
+			t.Fatalf("%s: policy id mismatch: expected: %d, found: %d, %v", test.value, test.policyID, policyID, err)
+		}
+	}
+}
+
+func TestExpandPolicies(t *testing.T) {
+	database := &fakeDatabase{
+		pricingPolicies: []db.PricingPolicy{{PricingPolicyID: 1, Name: "default", CU: 100000, IPU: 40000}},
+		farmingPolicies: []db.FarmingPolicy{{FarmingPolicyID: 3, Name: "diy"}},
+	}
+	a := testApp(database, nil)
+	for i := 0; i < 2; i++ {
+		farms := []types.Farm{{FarmID: 1, PricingPolicyID: 1}, {FarmID: 2, PricingPolicyID: 2}}
+		if err := a.expandFarmsPolicy(farms); err != nil {
+			t.Fatalf("failed to expand farms policy: %s", err.Error())
+		}
+		if policy := farms[0].PricingPolicy; policy == nil || policy.Name != "default" || policy.CU.Value != 100000 || policy.IPU.Value != 40000 {
+			t.Fatalf("pricing policy of farm 1 mismatch: %+v", policy)
+		}
+		if farms[1].PricingPolicy != nil {
+			t.Fatalf("missing pricing policy of farm 2 is set: %+v", farms[1].PricingPolicy)
+		}
+
+		nodes := []types.Node{{NodeID: 1, FarmingPolicyID: 3}, {NodeID: 2, FarmingPolicyID: 4}}
+		if err := a.expandNodesPolicy(nodes); err != nil {
+			t.Fatalf("failed to expand nodes policy: %s", err.Error())
+		}
+		if nodes[0].FarmingPolicy == nil || nodes[0].FarmingPolicy.Name != "diy" || nodes[1].FarmingPolicy != nil {
+			t.Fatalf("farming policies mismatch: %+v, %+v", nodes[0].FarmingPolicy, nodes[1].FarmingPolicy)
+		}
+		node := types.NodeWithNestedCapacity{NodeID: 1, FarmingPolicyID: 3}
+		if err := a.expandNodePolicy(&node); err != nil || node.FarmingPolicy == nil || node.FarmingPolicy.Name != "diy" {
+			t.Fatalf("farming policy of node mismatch: %+v, %v", node.FarmingPolicy, err)
+		}
+	}
+	if database.queries["GetPricingPolicies"] != 1 || database.queries["GetFarmingPolicies"] != 1 {
+		t.Fatalf("policies aren't cached: %v", database.queries)
+	}
+}
@@ -0,0 +1,148 @@
+package explorer
+
+import (
+	"math"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	proxytypes "github.com/threefoldtech/grid_proxy_server/pkg/types"
+	substrate "github.com/threefoldtech/substrate-client"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+)
+
+const (
+	// certifiedFactor is the price factor of deploying on certified nodes
+	certifiedFactor = 1.25
+	// hoursPerMonth is the number of hours used to compute the monthly prices
+	hoursPerMonth = 24 * 30
+	// pricingUnit is the unit of the pricing policy values in USD
+	pricingUnit = 1e-7
+	// tftPriceUnit is the unit of the TFT price on the chain in USD
+	tftPriceUnit = 1e-3
+	// defaultPricingPolicyID is the pricing policy used if no node is given
+	defaultPricingPolicyID = 1
+	// tftPriceCacheTTL is how long the TFT price is served from the cache
+	tftPriceCacheTTL = time.Minute
+)
+
+// stakingDiscounts are the discount levels, a twin gets the highest level its balance
+// covers the number of months of its deployments for
+var stakingDiscounts = []proxytypes.StakingDiscount{
+	{Level: "default", Discount: 20, Months: 1.5},
+	{Level: "bronze", Discount: 30, Months: 3},
+	{Level: "silver", Discount: 40, Months: 6},
+	{Level: "gold", Discount: 60, Months: 18},
+}
+
+// PriceSource gets the current TFT price
+type PriceSource interface {
+	// TFTPrice returns the price of one TFT in USD
+	TFTPrice() (float64, error)
+}
+
+// ChainPriceSource reads the TFT price from the TFT price module of tfchain
+type ChainPriceSource struct {
+	sub *substrate.Substrate
+}
+
+// NewChainPriceSource creates a new price source reading from tfchain
+func NewChainPriceSource(sub *substrate.Substrate) *ChainPriceSource {
+	return &ChainPriceSource{sub: sub}
+}
+
+// TFTPrice returns the price of one TFT in USD
+func (c *ChainPriceSource) TFTPrice() (float64, error) {
+	cl, meta, err := c.sub.GetClient()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get substrate client")
+	}
+	key, err := types.CreateStorageKey(meta, "TFTPriceModule", "TftPrice")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create storage key")
+	}
+	var price types.U32
+	ok, err := cl.RPC.State.GetStorageLatest(key, &price)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get tft price")
+	}
+	if !ok || price == 0 {
+		return 0, errors.New("tft price not found")
+	}
+	return float64(price) * tftPriceUnit, nil
+}
+
+// tftPrice returns the TFT price in USD from the cache, or from the price source
+func (a *App) tftPrice() (float64, error) {
+	if cached, ok := a.lruCache.Get("tft-price"); ok {
+		return cached.(float64), nil
+	}
+	price, err := a.priceSource.TFTPrice()
+	if err != nil {
+		return 0, err
+	}
+	a.lruCache.Set("tft-price", price, tftPriceCacheTTL)
+	return price, nil
+}
+
+// calcCU returns the compute units of the given cores and memory in GB
+func calcCU(cru, mru float64) float64 {
+	cu1 := math.Max(mru/4, cru/2)
+	cu2 := math.Max(mru/8, cru)
+	cu3 := math.Max(mru/2, cru/4)
+	return math.Min(cu1, math.Min(cu2, cu3))
+}
+
+// calcSU returns the storage units of the given hdd and ssd in GB
+func calcSU(hru, sru float64) float64 {
+	return hru/1200 + sru/200
+}
+
+// newPrice returns the price of the given hourly cost in USD
+func newPrice(hourlyUSD, tftPrice float64) proxytypes.Price {
+	return proxytypes.Price{
+		HourlyUSD:  hourlyUSD,
+		MonthlyUSD: hourlyUSD * hoursPerMonth,
+		HourlyTFT:  hourlyUSD / tftPrice,
+		MonthlyTFT: hourlyUSD * hoursPerMonth / tftPrice,
+	}
+}
+
+// estimatePrice computes the price of the estimate resources with the given pricing policy, the balance
+// is in TFT and it's used to find the reached staking discount if it's not nil
+func estimatePrice(estimate *proxytypes.PriceEstimate, policy db.PricingPolicy, tftPrice float64, balance *uint64) {
+	gb := float64(gridtypes.Gigabyte)
+	estimate.PricingPolicyID = policy.PricingPolicyID
+	estimate.TFTPrice = tftPrice
+	estimate.CU = calcCU(float64(estimate.Resources.CRU), float64(estimate.Resources.MRU)/gb)
+	estimate.SU = calcSU(float64(estimate.Resources.HRU)/gb, float64(estimate.Resources.SRU)/gb)
+	estimate.IPU = float64(estimate.PublicIPs)
+
+	hourly := (estimate.CU*float64(policy.CU) + estimate.SU*float64(policy.SU) + estimate.IPU*float64(policy.IPU)) * pricingUnit
+	if estimate.Certified {
+		hourly *= certifiedFactor
+	}
+	if estimate.Dedicated {
+		estimate.DedicatedDiscount = policy.DedicatedNodeDiscount
+		hourly *= 1 - float64(policy.DedicatedNodeDiscount)/100
+	}
+	estimate.Price = newPrice(hourly, tftPrice)
+
+	estimate.Discounts = make([]proxytypes.StakingDiscount, len(stakingDiscounts))
+	for idx, discount := range stakingDiscounts {
+		discount.RequiredBalance = estimate.Price.MonthlyTFT * discount.Months
+		discount.Price = newPrice(hourly*(1-float64(discount.Discount)/100), tftPrice)
+		estimate.Discounts[idx] = discount
+	}
+	if balance == nil {
+		return
+	}
+	estimate.StakingDiscount = &proxytypes.StakingDiscount{Level: "none", Price: estimate.Price}
+	for idx := range estimate.Discounts {
+		if float64(*balance) >= estimate.Discounts[idx].RequiredBalance {
+			reached := estimate.Discounts[idx]
+			estimate.StakingDiscount = &reached
+		}
+	}
+}
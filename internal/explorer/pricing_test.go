@@ -0,0 +1,141 @@
+package explorer
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+	"gorm.io/gorm/schema"
+)
+
+// testPricingPolicy is the default pricing policy of tfchain
+var testPricingPolicy = db.PricingPolicy{PricingPolicyID: 1, SU: 50000, CU: 100000, NU: 15000, IPU: 40000, DedicatedNodeDiscount: 50}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestCalcUnits(t *testing.T) {
+	cuTests := []struct {
+		cru, mru, cu float64
+	}{
+		{0, 0, 0},
+		{1, 2, 0.5},
+		{8, 16, 4},
+		{2, 32, 4},
+		{32, 1, 8},
+		{4, 1024, 128},
+	}
+	for _, test := range cuTests {
+		if cu := calcCU(test.cru, test.mru); !almostEqual(cu, test.cu) {
+			t.Fatalf("cu of %v cores and %vGB mismatch: expected: %v, found: %v", test.cru, test.mru, test.cu, cu)
+		}
+	}
+	suTests := []struct {
+		hru, sru, su float64
+	}{
+		{0, 0, 0},
+		{0, 25, 0.125},
+		{1200, 200, 2},
+		{3000, 500, 5},
+	}
+	for _, test := range suTests {
+		if su := calcSU(test.hru, test.sru); !almostEqual(su, test.su) {
+			t.Fatalf("su of %vGB hdd and %vGB ssd mismatch: expected: %v, found: %v", test.hru, test.sru, test.su, su)
+		}
+	}
+}
+
+func TestEstimatePrice(t *testing.T) {
+	// 1 core, 2GB memory, 25GB ssd and a public ip are 0.5 cu, 0.125 su and 1 ipu,
+	// (0.5 * 100000 + 0.125 * 50000 + 40000) * 1e-7 = 0.009625 USD per hour, 6.93 USD per month
+	resources := types.Capacity{CRU: 1, MRU: 2 * gridtypes.Gigabyte, SRU: 25 * gridtypes.Gigabyte}
+	tests := []struct {
+		name      string
+		certified bool
+		dedicated bool
+		publicIPs uint64
+		monthly   float64
+	}{
+		{"no public ip", false, false, 0, 4.05},
+		{"public ip", false, false, 1, 6.93},
+		{"certified", true, false, 1, 8.6625},
+		{"dedicated", false, true, 1, 3.465},
+		{"certified dedicated", true, true, 1, 4.33125},
+	}
+	for _, test := range tests {
+		estimate := types.PriceEstimate{Resources: resources, PublicIPs: test.publicIPs, Certified: test.certified, Dedicated: test.dedicated}
+		estimatePrice(&estimate, testPricingPolicy, 0.05, nil)
+		if !almostEqual(estimate.CU, 0.5) || !almostEqual(estimate.SU, 0.125) || estimate.IPU != float64(test.publicIPs) {
+			t.Fatalf("%s: units mismatch: cu: %v, su: %v, ipu: %v", test.name, estimate.CU, estimate.SU, estimate.IPU)
+		}
+		price := estimate.Price
+		if !almostEqual(price.MonthlyUSD, test.monthly) || !almostEqual(price.HourlyUSD, test.monthly/hoursPerMonth) ||
+			!almostEqual(price.MonthlyTFT, test.monthly/0.05) || !almostEqual(price.HourlyTFT, test.monthly/hoursPerMonth/0.05) {
+			t.Fatalf("%s: price mismatch: expected: %v USD per month, found: %+v", test.name, test.monthly, price)
+		}
+		if discount := estimate.DedicatedDiscount; (discount == 50) != test.dedicated {
+			t.Fatalf("%s: dedicated discount mismatch: %d", test.name, discount)
+		}
+		if estimate.PricingPolicyID != 1 || estimate.TFTPrice != 0.05 || estimate.StakingDiscount != nil {
+			t.Fatalf("%s: estimate mismatch: %+v", test.name, estimate)
+		}
+	}
+}
+
+func TestEstimatePriceStakingDiscounts(t *testing.T) {
+	resources := types.Capacity{CRU: 1, MRU: 2 * gridtypes.Gigabyte, SRU: 25 * gridtypes.Gigabyte}
+	// the price is 6.93 USD, 138.6 TFT per month
+	required := []float64{207.9, 415.8, 831.6, 2494.8}
+	monthly := []float64{5.544, 4.851, 4.158, 2.772}
+	tests := []struct {
+		balance uint64
+		level   string
+		monthly float64
+	}{
+		{0, "none", 6.93},
+		{207, "none", 6.93},
+		{208, "default", 5.544},
+		{500, "bronze", 4.851},
+		{831, "bronze", 4.851},
+		{832, "silver", 4.158},
+		{2495, "gold", 2.772},
+		{1000000, "gold", 2.772},
+	}
+	for _, test := range tests {
+		balance := test.balance
+		estimate := types.PriceEstimate{Resources: resources, PublicIPs: 1}
+		estimatePrice(&estimate, testPricingPolicy, 0.05, &balance)
+		if len(estimate.Discounts) != len(stakingDiscounts) {
+			t.Fatalf("discounts count mismatch: expected: %d, found: %d", len(stakingDiscounts), len(estimate.Discounts))
+		}
+		for idx, discount := range estimate.Discounts {
+			if !almostEqual(discount.RequiredBalance, required[idx]) || !almostEqual(discount.Price.MonthlyUSD, monthly[idx]) {
+				t.Fatalf("%s discount mismatch: expected: %v TFT for %v USD per month, found: %+v", discount.Level, required[idx], monthly[idx], discount)
+			}
+		}
+		reached := estimate.StakingDiscount
+		if reached == nil || reached.Level != test.level || !almostEqual(reached.Price.MonthlyUSD, test.monthly) {
+			t.Fatalf("balance %d: staking discount mismatch: expected: %s at %v USD per month, found: %+v", test.balance, test.level, test.monthly, reached)
+		}
+	}
+}
+
+func TestPricingPolicyColumns(t *testing.T) {
+	parsed, err := schema.Parse(&db.PricingPolicy{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("failed to parse pricing policy schema: %s", err.Error())
+	}
+	columns := map[string]string{
+		"CU": "cu", "CUUnit": "cu_unit", "SU": "su", "SUUnit": "su_unit", "NU": "nu", "NUUnit": "nu_unit",
+		"IPU": "ipu", "IPUUnit": "ipu_unit", "DedicatedNodeDiscount": "dedicated_node_discount",
+	}
+	for field, column := range columns {
+		if parsed.LookUpField(field).DBName != column {
+			t.Fatalf("column of %s mismatch: expected: %s, found: %s", field, column, parsed.LookUpField(field).DBName)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package explorer
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// relayTimeout is the max time to wait for a node to answer a relay call
+const relayTimeout = 10 * time.Second
+
+// callNode calls the zos command fn on the node with the given twin through the relay,
+// failures of the call are reported as bad gateway errors
+func (a *App) callNode(ctx context.Context, twinID uint32, fn string, data, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, relayTimeout)
+	defer cancel()
+
+	if err := a.relayClient.Call(ctx, twinID, fn, data, result); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errors.Wrapf(ErrBadGateway, "node didn't answer %s in %s", fn, relayTimeout)
+		}
+		return errors.Wrapf(ErrBadGateway, "failed to call %s on node: %s", fn, err)
+	}
+	return nil
+}
+
+// nodeTwinID returns the twin id of the node in the request path
+func (a *App) nodeTwinID(r *http.Request) (uint32, error) {
+	node, err := a.getNodeData(mux.Vars(r)["node_id"], "twinId")
+	if err != nil {
+		return 0, err
+	}
+	return uint32(node.TwinID), nil
+}
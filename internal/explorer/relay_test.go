@@ -0,0 +1,85 @@
+package explorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+func TestCallNode(t *testing.T) {
+	relay := &fakeRelay{
+		responses: map[uint32]interface{}{1: []types.NodeGPU{{ID: "0000:0e:00.0/1002/744c", Vendor: "AMD"}}},
+		errs: map[uint32]error{
+			2: context.DeadlineExceeded,
+			3: fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			4: errors.New("twin not connected"),
+		},
+	}
+	a := testApp(&fakeDatabase{}, relay)
+	var gpus []types.NodeGPU
+	if err := a.callNode(context.Background(), 1, "zos.gpu.list", nil, &gpus); err != nil {
+		t.Fatalf("failed to call node: %s", err.Error())
+	}
+	if len(gpus) != 1 || gpus[0].Vendor != "AMD" {
+		t.Fatalf("result mismatch: %+v", gpus)
+	}
+	tests := []struct {
+		twinID  uint32
+		message string
+	}{
+		{2, "node didn't answer zos.gpu.list in 10s"},
+		{3, "node didn't answer zos.gpu.list in 10s"},
+		{4, "failed to call zos.gpu.list on node: twin not connected"},
+	}
+	for _, test := range tests {
+		err := a.callNode(context.Background(), test.twinID, "zos.gpu.list", nil, &gpus)
+		if !errors.Is(err, ErrBadGateway) || !types.IsErrorCode(err, types.ErrCodeBadGateway) {
+			t.Fatalf("twin %d: error mismatch: expected a bad gateway error, found: %v", test.twinID, err)
+		}
+		if !strings.HasPrefix(err.Error(), test.message) {
+			t.Fatalf("twin %d: message mismatch: expected: %s, found: %s", test.twinID, test.message, err.Error())
+		}
+		if status := errorReply(err).Status(); status != http.StatusBadGateway {
+			t.Fatalf("twin %d: status mismatch: expected: %d, found: %d", test.twinID, http.StatusBadGateway, status)
+		}
+	}
+}
+
+func TestNodeRelayEndpoint(t *testing.T) {
+	database := &fakeDatabase{nodes: []db.Node{{NodeID: 1, TwinID: 11}, {NodeID: 2, TwinID: 12}}}
+	relay := &fakeRelay{
+		responses: map[uint32]interface{}{11: []types.NodeGPU{{ID: "gpu", Contract: 5}}},
+		errs:      map[uint32]error{12: errors.New("twin not connected")},
+	}
+	a := testApp(database, relay)
+	tests := []struct {
+		nodeID string
+		status int
+	}{
+		{"1", http.StatusOK},
+		{"2", http.StatusBadGateway},
+		{"3", http.StatusNotFound},
+		{"one", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/nodes/"+test.nodeID+"/gpus", nil), map[string]string{"node_id": test.nodeID})
+		res, resp := a.getNodeGPUs(r)
+		if resp.Status() != test.status {
+			t.Fatalf("node %s: status mismatch: expected: %d, found: %d: %v", test.nodeID, test.status, resp.Status(), resp.Err())
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+		if gpus := res.([]types.NodeGPU); len(gpus) != 1 || gpus[0].Contract != 5 {
+			t.Fatalf("node %s: gpus mismatch: %+v", test.nodeID, gpus)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package explorer
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+)
+
+const (
+	// rentableCacheTTL is how long the priced rentable nodes of a filter are served from the cache to sort them
+	// or filter them by price
+	rentableCacheTTL = 30 * time.Second
+	// rentableSortPrice sorts the rentable nodes by their price
+	rentableSortPrice = "price"
+	// rentableSortPricePerCore sorts the rentable nodes by their price per core
+	rentableSortPricePerCore = "price_per_core"
+	// rentableSortPricePerGB sorts the rentable nodes by their price per GB of memory
+	rentableSortPricePerGB = "price_per_gb"
+)
+
+// rentableSorts are the allowed values of the rentable nodes sort_by parameter
+var rentableSorts = []string{rentableSortPrice, rentableSortPricePerCore, rentableSortPricePerGB}
+
+// rentableNodeFields are the node fields queried to list the rentable nodes
+var rentableNodeFields = []string{"nodeId", "farmId", "twinId", "status", "location", "total_resources", "gpus", "certificationType", "dedicated"}
+
+// rentableNodes returns the page of the rentable nodes matching the filter with the price of renting them, and
+// the count of the matching nodes if it's requested. the nodes above the max hourly price in USD are left out if
+// it's set, and the nodes are sorted by the price sortBy is set to, the nodes with no cores or memory come last
+// when sorted by the price per unit. the nodes are paginated by the database if they're not sorted or filtered by
+// price, otherwise all the matching nodes are priced and cached for rentableCacheTTL to paginate them
+func (a *App) rentableNodes(filter types.NodeFilter, limit types.Limit, maxPrice *float64, sortBy string) ([]types.RentableNode, uint, error) {
+	rentable := true
+	filter.Rentable = &rentable
+	if maxPrice == nil && sortBy == "" {
+		dbNodes, count, err := a.db.GetNodes(filter, types.Limit{Page: limit.Page, Size: limit.Size, RetCount: limit.RetCount}, rentableNodeFields...)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "couldn't get rentable nodes")
+		}
+		nodes, err := a.priceRentableNodes(dbNodes)
+		return nodes, count, err
+	}
+
+	priced, err := a.pricedRentableNodes(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	nodes := make([]types.RentableNode, 0, len(priced))
+	for _, node := range priced {
+		if maxPrice != nil && node.Price.HourlyUSD > *maxPrice {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	if sortBy != "" {
+		sortRentableNodes(nodes, sortBy)
+	}
+	count := uint64(len(nodes))
+	start := (limit.Page - 1) * limit.Size
+	if start > count {
+		start = count
+	}
+	end := start + limit.Size
+	if end > count {
+		end = count
+	}
+	return nodes[start:end], uint(count), nil
+}
+
+// pricedRentableNodes returns all the rentable nodes matching the filter with their price from the cache, or the database
+func (a *App) pricedRentableNodes(filter types.NodeFilter) ([]types.RentableNode, error) {
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't encode rentable nodes filter")
+	}
+	key := "rentable-" + string(encoded)
+	if cached, ok := a.lruCache.Get(key); ok {
+		return cached.([]types.RentableNode), nil
+	}
+	dbNodes, _, err := a.db.GetNodes(filter, types.Limit{Page: 1, Size: math.MaxInt32}, rentableNodeFields...)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get rentable nodes")
+	}
+	nodes, err := a.priceRentableNodes(dbNodes)
+	if err != nil {
+		return nil, err
+	}
+	a.lruCache.Set(key, nodes, rentableCacheTTL)
+	return nodes, nil
+}
+
+// priceRentableNodes returns the nodes with the price of renting them, the nodes of farms without
+// a pricing policy are left out
+func (a *App) priceRentableNodes(dbNodes []db.Node) ([]types.RentableNode, error) {
+	if len(dbNodes) == 0 {
+		return []types.RentableNode{}, nil
+	}
+	farms, err := a.nodesFarms(dbNodes)
+	if err != nil {
+		return nil, err
+	}
+	policies, tftPrice, err := a.rentPricing()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]types.RentableNode, 0, len(dbNodes))
+	for _, dbNode := range dbNodes {
+		node := nodeFromDBNode(dbNode)
+		farm := farms[node.FarmID]
+		policy, ok := policies[uint32(farm.PricingPolicyID)]
+		if !ok {
+			continue
+		}
+		nodes = append(nodes, newRentableNode(node, farm, policy, tftPrice))
+	}
+	return nodes, nil
+}
+
+// rentPricing returns the pricing policies by id and the TFT price to compute the rent prices
+func (a *App) rentPricing() (map[uint32]db.PricingPolicy, float64, error) {
+	dbPolicies, err := a.db.GetPricingPolicies()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "couldn't get pricing policies")
+	}
+	policies := make(map[uint32]db.PricingPolicy, len(dbPolicies))
+	for _, policy := range dbPolicies {
+		policies[policy.PricingPolicyID] = policy
+	}
+	tftPrice, err := a.tftPrice()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "couldn't get tft price")
+	}
+	return policies, tftPrice, nil
+}
+
+// newRentableNode returns the node with the price of renting its total resources after the dedicated discount
+func newRentableNode(node types.Node, farm db.Farm, policy db.PricingPolicy, tftPrice float64) types.RentableNode {
+	estimate := types.PriceEstimate{
+		Resources: node.TotalResources,
+		Certified: node.CertificationType == "Certified",
+		Dedicated: true,
+	}
+	estimatePrice(&estimate, policy, tftPrice, nil)
+	rentableNode := types.RentableNode{
+		NodeID:                node.NodeID,
+		FarmID:                node.FarmID,
+		TwinID:                node.TwinID,
+		FarmName:              farm.Name,
+		Status:                node.Status,
+		Location:              node.Location,
+		TotalResources:        node.TotalResources,
+		GPUs:                  node.GPUs,
+		CertificationType:     node.CertificationType,
+		FarmCertificationType: farm.Certification,
+		Dedicated:             node.Dedicated,
+		PricingPolicyID:       estimate.PricingPolicyID,
+		DedicatedDiscount:     estimate.DedicatedDiscount,
+		Price:                 estimate.Price,
+	}
+	if cru := float64(node.TotalResources.CRU); cru != 0 {
+		rentableNode.PricePerCore = newPrice(estimate.Price.HourlyUSD/cru, tftPrice)
+	}
+	if mru := float64(node.TotalResources.MRU) / float64(gridtypes.Gigabyte); mru != 0 {
+		rentableNode.PricePerGB = newPrice(estimate.Price.HourlyUSD/mru, tftPrice)
+	}
+	return rentableNode
+}
+
+// nodesFarms returns the farms of the nodes by id
+func (a *App) nodesFarms(nodes []db.Node) (map[int]db.Farm, error) {
+	var farmIDs []uint64
+	seen := make(map[int64]struct{})
+	for _, node := range nodes {
+		if _, ok := seen[node.FarmID]; !ok {
+			seen[node.FarmID] = struct{}{}
+			farmIDs = append(farmIDs, uint64(node.FarmID))
+		}
+	}
+	dbFarms, _, err := a.db.GetFarms(types.FarmFilter{FarmIDs: farmIDs}, types.Limit{Page: 1, Size: uint64(len(farmIDs))}, "name", "farmId", "pricingPolicyId", "certificationType")
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get farms of the nodes")
+	}
+	farms := make(map[int]db.Farm, len(dbFarms))
+	for _, farm := range dbFarms {
+		farms[farm.FarmID] = farm
+	}
+	return farms, nil
+}
+
+// sortRentableNodes sorts the nodes by the given price in ascending order, the order of the nodes with the same price is kept
+func sortRentableNodes(nodes []types.RentableNode, sortBy string) {
+	key := func(node types.RentableNode) float64 {
+		switch sortBy {
+		case rentableSortPricePerCore:
+			if node.TotalResources.CRU == 0 {
+				return math.Inf(1)
+			}
+			return node.PricePerCore.HourlyUSD
+		case rentableSortPricePerGB:
+			if node.TotalResources.MRU == 0 {
+				return math.Inf(1)
+			}
+			return node.PricePerGB.HourlyUSD
+		default:
+			return node.Price.HourlyUSD
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return key(nodes[i]) < key(nodes[j])
+	})
+}
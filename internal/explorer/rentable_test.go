@@ -0,0 +1,300 @@
+package explorer
+
+import (
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+)
+
+// rentableNodeIDs returns the ids of the nodes in order
+func rentableNodeIDs(nodes []types.RentableNode) []int {
+	ids := []int{}
+	for _, node := range nodes {
+		ids = append(ids, node.NodeID)
+	}
+	return ids
+}
+
+func TestSortRentableNodes(t *testing.T) {
+	node := func(id int, cru uint64, mru gridtypes.Unit, price, perCore, perGB float64) types.RentableNode {
+		return types.RentableNode{
+			NodeID:         id,
+			TotalResources: types.Capacity{CRU: cru, MRU: mru},
+			Price:          types.Price{HourlyUSD: price},
+			PricePerCore:   types.Price{HourlyUSD: perCore},
+			PricePerGB:     types.Price{HourlyUSD: perGB},
+		}
+	}
+	nodes := []types.RentableNode{
+		node(1, 8, 16*gridtypes.Gigabyte, 0.02, 0.0025, 0.00125),
+		// no cores nor memory, the per unit prices are left zero
+		node(2, 0, 0, 0.005, 0, 0),
+		node(3, 2, 32*gridtypes.Gigabyte, 0.02, 0.01, 0.000625),
+		node(4, 4, 8*gridtypes.Gigabyte, 0.01, 0.0025, 0.00125),
+		node(5, 0, 4*gridtypes.Gigabyte, 0.005, 0, 0.00125),
+		node(6, 1, 0, 0.001, 0.001, 0),
+	}
+	tests := []struct {
+		sortBy string
+		ids    []int
+	}{
+		{rentableSortPrice, []int{6, 2, 5, 4, 1, 3}},
+		// the nodes without cores come last in their order
+		{rentableSortPricePerCore, []int{6, 1, 4, 3, 2, 5}},
+		// the nodes without memory come last in their order
+		{rentableSortPricePerGB, []int{3, 1, 4, 5, 2, 6}},
+	}
+	for _, test := range tests {
+		sorted := append([]types.RentableNode{}, nodes...)
+		sortRentableNodes(sorted, test.sortBy)
+		if ids := rentableNodeIDs(sorted); !reflect.DeepEqual(ids, test.ids) {
+			t.Fatalf("%s: order mismatch: expected: %v, found: %v", test.sortBy, test.ids, ids)
+		}
+	}
+
+	// all the nodes without cores keep their order
+	sorted := []types.RentableNode{node(3, 0, 0, 0.3, 0, 0), node(1, 0, 0, 0.1, 0, 0), node(2, 0, 0, 0.2, 0, 0)}
+	sortRentableNodes(sorted, rentableSortPricePerCore)
+	if ids := rentableNodeIDs(sorted); !reflect.DeepEqual(ids, []int{3, 1, 2}) {
+		t.Fatalf("order of the nodes without cores mismatch: expected: [3 1 2], found: %v", ids)
+	}
+}
+
+func TestNewRentableNode(t *testing.T) {
+	// 8 cores and 16GB memory are 4 cu, 4 * 100000 * 1e-7 = 0.04 USD per hour, 0.02 after the 50% dedicated discount
+	node := types.Node{
+		NodeID:            1,
+		FarmID:            2,
+		TwinID:            3,
+		Status:            "up",
+		TotalResources:    types.Capacity{CRU: 8, MRU: 16 * gridtypes.Gigabyte},
+		CertificationType: "Diy",
+	}
+	farm := db.Farm{FarmID: 2, Name: "farm", Certification: "Gold", PricingPolicyID: 1}
+	rentable := newRentableNode(node, farm, testPricingPolicy, 0.05)
+	if rentable.NodeID != 1 || rentable.FarmID != 2 || rentable.TwinID != 3 || rentable.FarmName != "farm" ||
+		rentable.FarmCertificationType != "Gold" || rentable.Status != "up" || rentable.PricingPolicyID != 1 {
+		t.Fatalf("rentable node mismatch: %+v", rentable)
+	}
+	if rentable.DedicatedDiscount != 50 {
+		t.Fatalf("dedicated discount mismatch: expected: 50, found: %d", rentable.DedicatedDiscount)
+	}
+	prices := []struct {
+		name     string
+		price    types.Price
+		expected float64
+	}{
+		{"price", rentable.Price, 0.02},
+		{"price per core", rentable.PricePerCore, 0.0025},
+		{"price per gb", rentable.PricePerGB, 0.00125},
+	}
+	for _, test := range prices {
+		if !almostEqual(test.price.HourlyUSD, test.expected) || !almostEqual(test.price.HourlyTFT, test.expected/0.05) ||
+			!almostEqual(test.price.MonthlyUSD, test.expected*hoursPerMonth) {
+			t.Fatalf("%s mismatch: expected: %v USD per hour, found: %+v", test.name, test.expected, test.price)
+		}
+	}
+
+	// certified nodes cost 25% more
+	node.CertificationType = "Certified"
+	if price := newRentableNode(node, farm, testPricingPolicy, 0.05).Price.HourlyUSD; !almostEqual(price, 0.025) {
+		t.Fatalf("certified price mismatch: expected: 0.025, found: %v", price)
+	}
+
+	// the per unit prices of the nodes without cores or memory are left zero
+	node.CertificationType = "Diy"
+	node.TotalResources = types.Capacity{SRU: 500 * gridtypes.Gigabyte}
+	rentable = newRentableNode(node, farm, testPricingPolicy, 0.05)
+	if rentable.PricePerCore != (types.Price{}) || rentable.PricePerGB != (types.Price{}) {
+		t.Fatalf("per unit prices of a node without cores and memory should be zero: %+v, %+v", rentable.PricePerCore, rentable.PricePerGB)
+	}
+	// 500GB ssd are 2.5 su, 2.5 * 50000 * 1e-7 * 0.5 = 0.00625 USD per hour
+	if !almostEqual(rentable.Price.HourlyUSD, 0.00625) {
+		t.Fatalf("price mismatch: expected: 0.00625, found: %v", rentable.Price.HourlyUSD)
+	}
+}
+
+// rentableApp returns an app with 5 rentable nodes priced at 0.02, 0.02, 0.01, 0.00625 and 0.005 USD per hour,
+// and a node that isn't rentable
+func rentableApp() *App {
+	node := func(id int64, cru int64, mru int64, sru int64) db.Node {
+		return db.Node{
+			NodeID:   id,
+			FarmID:   1,
+			TotalCru: cru,
+			TotalMru: mru * int64(gridtypes.Gigabyte),
+			TotalSru: sru * int64(gridtypes.Gigabyte),
+		}
+	}
+	database := &fakeDatabase{
+		nodes: []db.Node{
+			node(1, 8, 16, 0),
+			node(2, 2, 32, 0),
+			node(3, 4, 8, 0),
+			node(4, 0, 0, 500),
+			node(5, 0, 4, 0),
+			node(6, 1, 2, 0),
+		},
+		rentable:        map[int64]bool{1: true, 2: true, 3: true, 4: true, 5: true},
+		farms:           []db.Farm{{FarmID: 1, Name: "farm", PricingPolicyID: 1}},
+		pricingPolicies: []db.PricingPolicy{testPricingPolicy},
+	}
+	app := testApp(database, nil)
+	app.lruCache.Set("tft-price", 0.05, cache.NoExpiration)
+	return app
+}
+
+func TestListRentableNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		ids     []int
+		headers map[string]string
+	}{
+		{
+			name:  "all",
+			query: "",
+			ids:   []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:  "sorted by price",
+			query: "sort_by=price",
+			ids:   []int{5, 4, 3, 1, 2},
+		},
+		{
+			name:  "max price",
+			query: "sort_by=price&max_price=0.015",
+			ids:   []int{5, 4, 3},
+		},
+		{
+			name:  "max price below all the prices",
+			query: "max_price=0.001",
+			ids:   []int{},
+		},
+		{
+			name:    "first page",
+			query:   "sort_by=price&size=2&ret_count=true",
+			ids:     []int{5, 4},
+			headers: map[string]string{"count": "5", "size": "2", "pages": "3"},
+		},
+		{
+			name:    "last page",
+			query:   "sort_by=price&size=2&page=3&ret_count=true",
+			ids:     []int{2},
+			headers: map[string]string{"count": "5", "size": "2", "pages": "3"},
+		},
+		{
+			name:    "page after the last",
+			query:   "sort_by=price&size=2&page=4&ret_count=true",
+			ids:     []int{},
+			headers: map[string]string{"count": "5", "size": "2", "pages": "3"},
+		},
+		{
+			name:  "page far after the last",
+			query: "size=2&page=1000",
+			ids:   []int{},
+		},
+		{
+			name:    "page of the nodes by id",
+			query:   "size=2&page=2&ret_count=true",
+			ids:     []int{3, 4},
+			headers: map[string]string{"count": "5", "size": "2", "pages": "3"},
+		},
+		{
+			name:  "randomize is ignored",
+			query: "randomize=true&size=2",
+			ids:   []int{1, 2},
+		},
+		{
+			name:    "paginated after the max price",
+			query:   "sort_by=price&max_price=0.015&size=2&page=2&ret_count=true",
+			ids:     []int{3},
+			headers: map[string]string{"count": "3", "size": "2", "pages": "2"},
+		},
+	}
+	for _, test := range tests {
+		result, resp := rentableApp().listRentableNodes(httptest.NewRequest("GET", "/rentable?"+test.query, nil))
+		if resp.Err() != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, resp.Err().Error())
+		}
+		nodes, ok := result.([]types.RentableNode)
+		if !ok || nodes == nil {
+			t.Fatalf("%s: result mismatch: expected a list of rentable nodes, found: %#v", test.name, result)
+		}
+		if ids := rentableNodeIDs(nodes); !reflect.DeepEqual(ids, test.ids) {
+			t.Fatalf("%s: nodes mismatch: expected: %v, found: %v", test.name, test.ids, ids)
+		}
+		for header, expected := range test.headers {
+			if found := resp.Header().Get(header); found != expected {
+				t.Fatalf("%s: %s header mismatch: expected: %s, found: %s", test.name, header, expected, found)
+			}
+		}
+	}
+}
+
+func TestListRentableNodesSortsUnpricedUnitsLast(t *testing.T) {
+	result, resp := rentableApp().listRentableNodes(httptest.NewRequest("GET", "/rentable?sort_by=price_per_core", nil))
+	if resp.Err() != nil {
+		t.Fatalf("unexpected error: %s", resp.Err().Error())
+	}
+	nodes := result.([]types.RentableNode)
+	// the node 4 and 5 have no cores
+	if ids := rentableNodeIDs(nodes); !reflect.DeepEqual(ids[3:], []int{4, 5}) {
+		t.Fatalf("nodes without cores should be last: %v", ids)
+	}
+	for _, node := range nodes {
+		if math.IsInf(node.PricePerCore.HourlyUSD, 0) {
+			t.Fatalf("price per core of node %d is infinite", node.NodeID)
+		}
+	}
+}
+
+func TestListRentableNodesQueries(t *testing.T) {
+	// the nodes that aren't sorted or filtered by price are paginated by the database
+	app := rentableApp()
+	database := app.db.(*fakeDatabase)
+	if _, resp := app.listRentableNodes(httptest.NewRequest("GET", "/rentable?size=2&page=2&randomize=true", nil)); resp.Err() != nil {
+		t.Fatalf("unexpected error: %s", resp.Err().Error())
+	}
+	if limit := (types.Limit{Page: 2, Size: 2}); database.nodesLimit != limit {
+		t.Fatalf("nodes query limit mismatch: expected: %+v, found: %+v", limit, database.nodesLimit)
+	}
+
+	// the priced nodes are cached to sort them
+	for _, query := range []string{"sort_by=price", "sort_by=price_per_gb&size=2&page=2", "max_price=0.01"} {
+		if _, resp := app.listRentableNodes(httptest.NewRequest("GET", "/rentable?"+query, nil)); resp.Err() != nil {
+			t.Fatalf("%s: unexpected error: %s", query, resp.Err().Error())
+		}
+		if database.nodesLimit.Randomize || database.nodesLimit.Page != 1 {
+			t.Fatalf("%s: all the nodes should be queried: %+v", query, database.nodesLimit)
+		}
+	}
+	if queries := database.queries["GetNodes"]; queries != 2 {
+		t.Fatalf("nodes queries mismatch: expected: 2, found: %d", queries)
+	}
+}
+
+func TestListRentableNodesRandomize(t *testing.T) {
+	_, resp := rentableApp().listRentableNodes(httptest.NewRequest("GET", "/v2/rentable?randomize=true", nil))
+	if params := invalidParams(t, resp.Err()); !reflect.DeepEqual(params, []string{"randomize"}) {
+		t.Fatalf("invalid params mismatch: expected: [randomize], found: %v", params)
+	}
+
+	// no rentable nodes
+	app := testApp(&fakeDatabase{nodes: []db.Node{{NodeID: 1, FarmID: 1}}}, nil)
+	for _, query := range []string{"randomize=true", "randomize=true&sort_by=price"} {
+		result, resp := app.listRentableNodes(httptest.NewRequest("GET", "/rentable?"+query, nil))
+		if resp.Err() != nil {
+			t.Fatalf("%s: unexpected error: %s", query, resp.Err().Error())
+		}
+		if nodes := result.([]types.RentableNode); nodes == nil || len(nodes) != 0 {
+			t.Fatalf("%s: nodes mismatch: expected an empty list, found: %#v", query, nodes)
+		}
+	}
+}
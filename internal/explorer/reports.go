@@ -0,0 +1,173 @@
+package explorer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+const (
+	// reportFormatJSON returns the reports as json
+	reportFormatJSON = "json"
+	// reportFormatCSV returns the reports as csv
+	reportFormatCSV = "csv"
+)
+
+// reportFormats are the allowed values of the reports format parameter
+var reportFormats = []string{reportFormatJSON, reportFormatCSV}
+
+// farmReportNodeFields are the node fields queried to build the farm reports
+var farmReportNodeFields = []string{"nodeId", "status", "uptime", "rentContractId", "total_resources", "used_resources"}
+
+// farmIDParam returns the farm id path parameter
+func farmIDParam(r *http.Request) (uint64, error) {
+	value := mux.Vars(r)["farm_id"]
+	farmID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, paramError("farm_id", "invalid farm id %s: %s", value, err.Error())
+	}
+	return farmID, nil
+}
+
+// farmReport builds the report of the farm with the revenue in the [from, to) range and the current usage
+func (a *App) farmReport(farm types.Farm, from, to int64) (types.FarmReport, error) {
+	report := types.FarmReport{
+		FarmID:      uint64(farm.FarmID),
+		From:        from,
+		To:          to,
+		Discounts:   []types.SpendingDiscount{},
+		NodeReports: []types.FarmNodeReport{},
+	}
+	filter := types.NodeFilter{FarmIDs: []uint64{uint64(farm.FarmID)}}
+	dbNodes, _, err := a.db.GetNodes(filter, types.Limit{Page: 1, Size: math.MaxInt32}, farmReportNodeFields...)
+	if err != nil {
+		return report, errors.Wrap(err, "couldn't get farm nodes")
+	}
+	revenue, err := a.db.GetFarmRevenue(uint64(farm.FarmID), from, to)
+	if err != nil {
+		return report, errors.Wrap(err, "couldn't get farm bill reports")
+	}
+	nodeRevenue := make(map[uint64]*types.FarmNodeReport)
+	var upUptime int64
+	for _, dbNode := range dbNodes {
+		node := nodeFromDBNode(dbNode)
+		report.NodeReports = append(report.NodeReports, types.FarmNodeReport{
+			NodeID:         uint64(node.NodeID),
+			Status:         node.Status,
+			Uptime:         node.Uptime,
+			Rented:         node.RentContractID != 0,
+			TotalResources: node.TotalResources,
+			UsedResources:  node.UsedResources,
+			Utilization:    utilization(node.TotalResources, node.UsedResources),
+		})
+		report.Nodes++
+		if node.Status == db.NodeUp {
+			report.UpNodes++
+			upUptime += node.Uptime
+		}
+		if node.RentContractID != 0 {
+			report.RentedNodes++
+		}
+		addCapacity(&report.TotalResources, node.TotalResources)
+		addCapacity(&report.UsedResources, node.UsedResources)
+	}
+	for idx := range report.NodeReports {
+		nodeRevenue[report.NodeReports[idx].NodeID] = &report.NodeReports[idx]
+	}
+	for _, billed := range revenue {
+		if node, ok := nodeRevenue[billed.Key]; ok {
+			node.AmountBilled += billed.AmountBilled
+			node.Reports += billed.Reports
+		}
+		report.AmountBilled += billed.AmountBilled
+		report.Reports += billed.Reports
+		report.Discounts = addSpendingDiscount(report.Discounts, billed)
+	}
+	report.Utilization = utilization(report.TotalResources, report.UsedResources)
+	report.RentedRatio = ratio(report.RentedNodes, report.Nodes)
+	if report.UpNodes != 0 {
+		report.AverageUptime = upUptime / int64(report.UpNodes)
+	}
+	for _, ip := range farm.PublicIps {
+		report.PublicIPs++
+		if ip.ContractID != 0 {
+			report.UsedPublicIPs++
+		}
+	}
+	report.PublicIPsRatio = ratio(report.UsedPublicIPs, report.PublicIPs)
+	return report, nil
+}
+
+// utilization returns the ratio of the used resources to the total resources
+func utilization(total, used types.Capacity) types.Utilization {
+	return types.Utilization{
+		CRU: ratio(used.CRU, total.CRU),
+		MRU: ratio(uint64(used.MRU), uint64(total.MRU)),
+		SRU: ratio(uint64(used.SRU), uint64(total.SRU)),
+		HRU: ratio(uint64(used.HRU), uint64(total.HRU)),
+	}
+}
+
+// ratio returns part / total, or 0 if the total is 0
+func ratio(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+// farmReportCSV writes the report with a row per node followed by a total row with the average
+// uptime of the up nodes, the public ips columns are only set in the total row
+func farmReportCSV(report types.FarmReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{
+		"node_id", "status", "uptime", "rented",
+		"total_cru", "used_cru", "cru_utilization",
+		"total_mru", "used_mru", "mru_utilization",
+		"total_sru", "used_sru", "sru_utilization",
+		"total_hru", "used_hru", "hru_utilization",
+		"public_ips", "used_public_ips",
+		"amount_billed", "reports",
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	capacity := func(total, used types.Capacity, utilization types.Utilization) []string {
+		return []string{
+			fmt.Sprint(total.CRU), fmt.Sprint(used.CRU), formatRatio(utilization.CRU),
+			fmt.Sprint(uint64(total.MRU)), fmt.Sprint(uint64(used.MRU)), formatRatio(utilization.MRU),
+			fmt.Sprint(uint64(total.SRU)), fmt.Sprint(uint64(used.SRU)), formatRatio(utilization.SRU),
+			fmt.Sprint(uint64(total.HRU)), fmt.Sprint(uint64(used.HRU)), formatRatio(utilization.HRU),
+		}
+	}
+	for _, node := range report.NodeReports {
+		row := []string{fmt.Sprint(node.NodeID), node.Status, fmt.Sprint(node.Uptime), strconv.FormatBool(node.Rented)}
+		row = append(row, capacity(node.TotalResources, node.UsedResources, node.Utilization)...)
+		row = append(row, "", "", fmt.Sprint(node.AmountBilled), fmt.Sprint(node.Reports))
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	total := []string{"total", "", fmt.Sprint(report.AverageUptime), ""}
+	total = append(total, capacity(report.TotalResources, report.UsedResources, report.Utilization)...)
+	total = append(total, fmt.Sprint(report.PublicIPs), fmt.Sprint(report.UsedPublicIPs), fmt.Sprint(report.AmountBilled), fmt.Sprint(report.Reports))
+	if err := w.Write(total); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// formatRatio formats the ratio with 4 decimals
+func formatRatio(ratio float64) string {
+	return strconv.FormatFloat(ratio, 'f', 4, 64)
+}
@@ -0,0 +1,161 @@
+package explorer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+func TestFarmReport(t *testing.T) {
+	database := &fakeDatabase{
+		nodes: []db.Node{
+			{NodeID: 1, FarmID: 1, Status: db.NodeUp, Uptime: 100, RentContractID: 5,
+				TotalCru: 4, TotalMru: 8, TotalSru: 100, UsedCru: 2, UsedMru: 4, UsedSru: 25},
+			{NodeID: 2, FarmID: 1, Status: db.NodeUp, Uptime: 300,
+				TotalCru: 4, TotalMru: 8, TotalSru: 100, TotalHru: 1000},
+			// the down nodes aren't in the average uptime, and the nodes without resources have no utilization
+			{NodeID: 3, FarmID: 1, Status: "down", Uptime: 1000},
+			{NodeID: 4, FarmID: 2, Status: db.NodeUp, Uptime: 50, TotalCru: 10},
+		},
+		revenue: []db.SpendingReport{
+			{Key: 1, DiscountReceived: "None", AmountBilled: 100, Reports: 1},
+			{Key: 1, DiscountReceived: "Gold", AmountBilled: 50, Reports: 2},
+			{Key: 3, DiscountReceived: "Gold", AmountBilled: 10, Reports: 1},
+			// the node 9 left the farm after it was billed, it's in the farm total only
+			{Key: 9, DiscountReceived: "None", AmountBilled: 5, Reports: 1},
+		},
+	}
+	farm := types.Farm{
+		FarmID:    1,
+		PublicIps: []types.PublicIP{{ContractID: 0}, {ContractID: 7}, {ContractID: 0}},
+	}
+	report, err := testApp(database, nil).farmReport(farm, 100, 200)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if report.FarmID != 1 || report.From != 100 || report.To != 200 {
+		t.Fatalf("report range mismatch: %+v", report)
+	}
+	if report.Nodes != 3 || report.UpNodes != 2 || report.RentedNodes != 1 || !almostEqual(report.RentedRatio, 1.0/3) {
+		t.Fatalf("nodes mismatch: expected: 3 nodes, 2 up and 1 rented, found: %d nodes, %d up and %d rented with ratio %v",
+			report.Nodes, report.UpNodes, report.RentedNodes, report.RentedRatio)
+	}
+	if report.AverageUptime != 200 {
+		t.Fatalf("average uptime mismatch: expected: 200, found: %d", report.AverageUptime)
+	}
+	if total := (types.Capacity{CRU: 8, MRU: 16, SRU: 200, HRU: 1000}); report.TotalResources != total {
+		t.Fatalf("total resources mismatch: expected: %+v, found: %+v", total, report.TotalResources)
+	}
+	if used := (types.Capacity{CRU: 2, MRU: 4, SRU: 25}); report.UsedResources != used {
+		t.Fatalf("used resources mismatch: expected: %+v, found: %+v", used, report.UsedResources)
+	}
+	if utilization := (types.Utilization{CRU: 0.25, MRU: 0.25, SRU: 0.125, HRU: 0}); report.Utilization != utilization {
+		t.Fatalf("utilization mismatch: expected: %+v, found: %+v", utilization, report.Utilization)
+	}
+	if report.PublicIPs != 3 || report.UsedPublicIPs != 1 || !almostEqual(report.PublicIPsRatio, 1.0/3) {
+		t.Fatalf("public ips mismatch: expected: 1 of 3 used, found: %d of %d used with ratio %v",
+			report.UsedPublicIPs, report.PublicIPs, report.PublicIPsRatio)
+	}
+	if report.AmountBilled != 165 || report.Reports != 5 {
+		t.Fatalf("revenue mismatch: expected: 165 in 5 reports, found: %d in %d reports", report.AmountBilled, report.Reports)
+	}
+	discounts := []types.SpendingDiscount{
+		{DiscountReceived: "None", AmountBilled: 105, Reports: 2},
+		{DiscountReceived: "Gold", AmountBilled: 60, Reports: 3},
+	}
+	if !reflect.DeepEqual(report.Discounts, discounts) {
+		t.Fatalf("discounts mismatch: expected: %+v, found: %+v", discounts, report.Discounts)
+	}
+
+	nodes := []types.FarmNodeReport{
+		{
+			NodeID: 1, Status: db.NodeUp, Uptime: 100, Rented: true,
+			TotalResources: types.Capacity{CRU: 4, MRU: 8, SRU: 100},
+			UsedResources:  types.Capacity{CRU: 2, MRU: 4, SRU: 25},
+			Utilization:    types.Utilization{CRU: 0.5, MRU: 0.5, SRU: 0.25},
+			AmountBilled:   150, Reports: 3,
+		},
+		{
+			NodeID: 2, Status: db.NodeUp, Uptime: 300,
+			TotalResources: types.Capacity{CRU: 4, MRU: 8, SRU: 100, HRU: 1000},
+		},
+		{
+			NodeID: 3, Status: "down", Uptime: 1000,
+			AmountBilled: 10, Reports: 1,
+		},
+	}
+	if !reflect.DeepEqual(report.NodeReports, nodes) {
+		t.Fatalf("node reports mismatch: expected: %+v, found: %+v", nodes, report.NodeReports)
+	}
+}
+
+func TestFarmReportEmptyFarm(t *testing.T) {
+	report, err := testApp(&fakeDatabase{}, nil).farmReport(types.Farm{FarmID: 1}, 0, 200)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if report.RentedRatio != 0 || report.PublicIPsRatio != 0 || report.AverageUptime != 0 || report.Utilization != (types.Utilization{}) {
+		t.Fatalf("ratios of a farm without nodes and public ips should be zero: %+v", report)
+	}
+	if report.NodeReports == nil || len(report.NodeReports) != 0 || report.Discounts == nil || len(report.Discounts) != 0 {
+		t.Fatalf("report lists should be empty not null: %+v", report)
+	}
+}
+
+func TestFarmReportCSV(t *testing.T) {
+	report := types.FarmReport{
+		FarmID:         1,
+		AverageUptime:  200,
+		TotalResources: types.Capacity{CRU: 8, MRU: 16, SRU: 200, HRU: 1000},
+		UsedResources:  types.Capacity{CRU: 2, MRU: 4, SRU: 25},
+		Utilization:    types.Utilization{CRU: 0.25, MRU: 0.25, SRU: 0.125},
+		PublicIPs:      3,
+		UsedPublicIPs:  1,
+		AmountBilled:   165,
+		Reports:        5,
+		NodeReports: []types.FarmNodeReport{
+			{
+				NodeID: 1, Status: db.NodeUp, Uptime: 100, Rented: true,
+				TotalResources: types.Capacity{CRU: 4, MRU: 8, SRU: 100},
+				UsedResources:  types.Capacity{CRU: 2, MRU: 4, SRU: 25},
+				Utilization:    types.Utilization{CRU: 0.5, MRU: 0.5, SRU: 0.25},
+				AmountBilled:   150, Reports: 3,
+			},
+			{
+				NodeID: 3, Status: "down", Uptime: 1000,
+				TotalResources: types.Capacity{CRU: 1, HRU: 3},
+				UsedResources:  types.Capacity{CRU: 1},
+				Utilization:    types.Utilization{CRU: 1},
+			},
+		},
+	}
+	body, err := farmReportCSV(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	expected := strings.Join([]string{
+		"node_id,status,uptime,rented," +
+			"total_cru,used_cru,cru_utilization,total_mru,used_mru,mru_utilization," +
+			"total_sru,used_sru,sru_utilization,total_hru,used_hru,hru_utilization," +
+			"public_ips,used_public_ips,amount_billed,reports",
+		"1,up,100,true,4,2,0.5000,8,4,0.5000,100,25,0.2500,0,0,0.0000,,,150,3",
+		"3,down,1000,false,1,1,1.0000,0,0,0.0000,0,0,0.0000,3,0,0.0000,,,0,0",
+		"total,,200,,8,2,0.2500,16,4,0.2500,200,25,0.1250,1000,0,0.0000,3,1,165,5",
+	}, "\n") + "\n"
+	if string(body) != expected {
+		t.Fatalf("csv mismatch: expected:\n%s\nfound:\n%s", expected, body)
+	}
+
+	// a farm without nodes has the header and the total row only
+	body, err = farmReportCSV(types.FarmReport{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n"); len(lines) != 2 ||
+		lines[1] != "total,,0,,0,0,0.0000,0,0,0.0000,0,0,0.0000,0,0,0.0000,0,0,0,0" {
+		t.Fatalf("csv of an empty report mismatch: %q", body)
+	}
+}
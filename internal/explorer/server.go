@@ -1,6 +1,7 @@
 package explorer
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	httpSwagger "github.com/swaggo/http-swagger"
 
@@ -18,11 +20,14 @@ import (
 	"github.com/threefoldtech/grid_proxy_server/internal/explorer/mw"
 	"github.com/threefoldtech/grid_proxy_server/pkg/types"
 	"github.com/threefoldtech/rmb-sdk-go"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
 )
 
 const (
 	// SSDOverProvisionFactor factor by which the ssd are allowed to be overprovisioned
 	SSDOverProvisionFactor = 2
+	// v2Prefix is the path prefix of the v2 endpoints, they're the v1 endpoints with strict validation on by default
+	v2Prefix = "/v2"
 )
 
 // listFarms godoc
@@ -45,6 +50,20 @@ const (
 // @Param certification_type query string false "certificate type Diy or Certified"
 // @Param dedicated query bool false "farm is dedicated"
 // @Param stellar_address query string false "farm stellar_address"
+// @Param exclude_farm_ids query string false "List of farms separated by comma to exclude (e.g. '1,2,3')"
+// @Param exclude_twin_ids query string false "List of farm twins separated by comma to exclude (e.g. '1,2,3')"
+// @Param certification_type_not query string false "Exclude the farms with the certificate type"
+// @Param node_count_min query int false "Min number of nodes in the farm matching the node filters"
+// @Param up_nodes_min query int false "Min number of up nodes in the farm matching the node filters"
+// @Param rentable_nodes_min query int false "Min number of rentable nodes in the farm matching the node filters"
+// @Param country query string false "Farms with a node in the country"
+// @Param node_free_mru query int false "Farms with a node with at least this free memory in bytes"
+// @Param node_free_sru query int false "Farms with a node with at least this free ssd storage in bytes"
+// @Param node_certification_type query string false "Farms with a node with the certificate type Diy or Certified"
+// @Param fields query string false "List of farm fields separated by comma to return (e.g. 'farmId,name')"
+// @Param expand query string false "Set to 'policy' to embed the pricing policy of the farm"
+// @Param strict query bool false "Reject unknown parameters, invalid values and conflicting filters instead of ignoring them"
+// @Param farm_ids query string false "List of farms separated by comma to fetch (e.g. '1,2,3')"
 // @Success 200 {object} []types.Farm
 // @Failure 400 {object} string
 // @Failure 500 {object} string
@@ -54,7 +73,19 @@ func (a *App) listFarms(r *http.Request) (interface{}, mw.Response) {
 	if err != nil {
 		return nil, mw.BadRequest(err)
 	}
-	dbFarms, farmsCount, err := a.db.GetFarms(filter, limit)
+	fields, err := getFields(r, types.Farm{})
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	expand, err := expandsPolicy(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	dbFields := fields
+	if expand {
+		dbFields, fields = expandFields(fields, "pricingPolicyId", "pricingPolicy")
+	}
+	dbFarms, farmsCount, err := a.db.GetFarms(filter, limit, dbFields...)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to query farm")
 		return nil, mw.Error(err)
@@ -67,6 +98,16 @@ func (a *App) listFarms(r *http.Request) (interface{}, mw.Response) {
 		}
 		farms = append(farms, f)
 	}
+	if expand {
+		if err := a.expandFarmsPolicy(farms); err != nil {
+			log.Error().Err(err).Msg("failed to query pricing policies")
+			return nil, mw.Error(err)
+		}
+	}
+	res, err := selectFields(farms, fields)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
 	resp := mw.Ok()
 
 	// return the number of pages and totalCount in the response headers
@@ -76,7 +117,60 @@ func (a *App) listFarms(r *http.Request) (interface{}, mw.Response) {
 			WithHeader("size", fmt.Sprintf("%d", limit.Size)).
 			WithHeader("pages", fmt.Sprintf("%d", int(pages)))
 	}
-	return farms, resp
+	return res, resp
+}
+
+// getFarmReport godoc
+// @Summary Show the revenue and utilization report of a farm
+// @Description Get the amount billed for the node and rent contracts on the farm nodes in a time range, with the current utilization of the nodes resources, the rented nodes ratio, the public ips usage and the uptime of the nodes. The amounts are in units of 1e-7 TFT, and the report has a row per node with a total row if it's requested as csv
+// @Tags GridProxy
+// @Accept  json
+// @Produce  json
+// @Produce  text/csv
+// @Param farm_id path int yes "Farm ID"
+// @Param from query int false "Start of the billing range as unix timestamp, the range starts with the first bill report by default"
+// @Param to query int false "End of the billing range as unix timestamp excluded from the range, defaults to now"
+// @Param format query string false "Set to 'csv' to get the report as csv, defaults to json"
+// @Param strict query bool false "Reject unknown parameters, invalid values and conflicting filters instead of ignoring them"
+// @Success 200 {object} types.FarmReport
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /farms/{farm_id}/report [get]
+func (a *App) getFarmReport(r *http.Request) (interface{}, mw.Response) {
+	farmID, err := farmIDParam(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	from, to, format, err := a.handleFarmReportRequestsQueryParams(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	dbFarm, err := a.db.GetFarm(uint32(farmID))
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	if uint64(dbFarm.FarmID) != farmID {
+		return nil, mw.NotFound(types.NewError(types.ErrCodeNotFound, fmt.Sprintf("farm %d not found", farmID)))
+	}
+	farm, err := farmFromDBFarm(dbFarm)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	report, err := a.farmReport(farm, from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to build farm report")
+		return nil, mw.Error(err)
+	}
+	if format != reportFormatCSV {
+		return report, mw.Ok()
+	}
+	body, err := farmReportCSV(report)
+	if err != nil {
+		return nil, mw.Error(errors.Wrap(err, "couldn't write farm report"))
+	}
+	return mw.Raw{ContentType: "text/csv", Body: body}, mw.Ok().
+		WithHeader("Content-Disposition", fmt.Sprintf("attachment; filename=farm-%d-report.csv", farmID))
 }
 
 // getStats godoc
@@ -85,7 +179,8 @@ func (a *App) listFarms(r *http.Request) (interface{}, mw.Response) {
 // @Tags GridProxy
 // @Accept  json
 // @Produce  json
-// @Param status query string false "Node status filter, 'up': for only up nodes & 'down': for only down nodes."
+// @Param status query string false "Node status filter, 'up': for only up nodes, 'down': for only down nodes & 'standby': for nodes powered off by the farmerbot."
+// @Param strict query bool false "Reject unknown parameters and invalid values instead of ignoring them"
 // @Success 200 {object} []types.Counters
 // @Failure 400 {object} string
 // @Failure 500 {object} string
@@ -102,6 +197,272 @@ func (a *App) getStats(r *http.Request) (interface{}, mw.Response) {
 	return counters, nil
 }
 
+// getStatsHistory godoc
+// @Summary Show the history of the grid stats
+// @Description Get the grid stats snapshots bucketed by time, every bucket has the last snapshot taken in it
+// @Tags GridProxy
+// @Accept  json
+// @Produce  json
+// @Param from query int false "Start of the history as a unix timestamp, defaults to 30 days before to"
+// @Param to query int false "End of the history as a unix timestamp (exclusive), defaults to now"
+// @Param interval query int false "Bucket size in seconds up to the length of the range, defaults to a day"
+// @Param strict query bool false "Reject unknown parameters and invalid values instead of ignoring them"
+// @Success 200 {object} []types.StatsSnapshot
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /stats/history [get]
+func (a *App) getStatsHistory(r *http.Request) (interface{}, mw.Response) {
+	from, to, interval, err := a.handleStatsHistoryRequestsQueryParams(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	dbSnapshots, err := a.db.GetStatsHistory(int64(from), int64(to), int64(interval))
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	snapshots := make([]types.StatsSnapshot, len(dbSnapshots))
+	for idx, snapshot := range dbSnapshots {
+		snapshots[idx] = statsSnapshotFromDBStatsSnapshot(snapshot)
+	}
+	return snapshots, nil
+}
+
+// streamEvents godoc
+// @Summary Stream the node events
+// @Description Stream the node status, rent and capacity changes as server-sent events, the changes are detected between polls of the database.
+// @Description A caller reconnecting with the Last-Event-ID header gets the missed events that are still buffered
+// @Tags GridProxy
+// @Produce  text/event-stream
+// @Param farm_id query int false "Events of the nodes in the farm"
+// @Param node_ids query string false "List of nodes separated by comma to get their events (e.g. '1,2,3')"
+// @Param twin_id query int false "Events of the node with the twin id"
+// @Param types query string false "List of event types separated by comma: status, rent, capacity"
+// @Param last_event_id query int false "Id of the last received event, for the callers that can't set the Last-Event-ID header"
+// @Param strict query bool false "Reject unknown parameters and invalid values instead of ignoring them"
+// @Success 200 {object} types.NodeEvent
+// @Failure 400 {object} string
+// @Failure 503 {object} string
+// @Router /events [get]
+func (a *App) streamEvents(r *http.Request) (<-chan mw.Event, mw.Response) {
+	if a.nodeEvents == nil {
+		return nil, mw.Error(errors.New("node events are disabled"), http.StatusServiceUnavailable)
+	}
+	filter, lastID, err := a.handleEventsRequestsQueryParams(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	backlog, events, unsubscribe := a.nodeEvents.subscribe(lastID)
+	out := make(chan mw.Event)
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+		send := func(event types.NodeEvent) bool {
+			if !nodeEventMatches(filter, event) {
+				return true
+			}
+			select {
+			case out <- mw.Event{ID: fmt.Sprint(event.ID), Type: event.Type, Data: event}:
+				return true
+			case <-r.Context().Done():
+				return false
+			}
+		}
+		for _, event := range backlog {
+			if !send(event) {
+				return
+			}
+		}
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok || !send(event) {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// getChanges godoc
+// @Summary Show the changes after a checkpoint
+// @Description Get the nodes, farms, twins and contracts created or modified after a checkpoint, and the checkpoint of the returned changes.
+// @Description Without since only the current checkpoint is returned, a mirror gets it before its full sync then syncs the changes after it.
+// @Description The changes are detected between polls of the database so an object is returned once even if it changed many times between polls
+// @Description The changes older than the retention of the instance (30 days by default) are pruned, a mirror with an older checkpoint has to do a full sync
+// @Description The changes are logged only if an instance sharing the database runs with -changes-poll-interval, the feed is empty otherwise
+// @Tags GridProxy
+// @Produce  json
+// @Param since query int false "Checkpoint returned by the previous call"
+// @Param size query int false "Max number of changes to return, up to 5000 (default 500)"
+// @Success 200 {object} types.Changes
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /changes [get]
+func (a *App) getChanges(r *http.Request) (interface{}, mw.Response) {
+	since, size, err := a.handleChangesRequestsQueryParams(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	if since == nil {
+		checkpoint, err := a.db.GetLastChangeID()
+		if err != nil {
+			return nil, mw.Error(err)
+		}
+		return types.Changes{
+			Checkpoint: fmt.Sprint(checkpoint),
+			Nodes:      []types.Node{},
+			Farms:      []types.Farm{},
+			Twins:      []types.Twin{},
+			Contracts:  []types.Contract{},
+		}, mw.Ok()
+	}
+	changes, err := a.changesAfter(*since, size)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	return changes, mw.Ok()
+}
+
+// createWebhook godoc
+// @Summary Create a webhook
+// @Description Register a url the matching node, contract and public ip events are posted to. The deliveries are signed with the returned secret
+// @Description in the X-Webhook-Signature-256 header, and retried with backoff if the url doesn't respond with 2xx. The secret is only returned here
+// @Tags GridProxy
+// @Accept  json
+// @Produce  json
+// @Param webhook body types.WebhookSubscription true "Url and filter of the webhook"
+// @Success 201 {object} types.WebhookSubscription
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks [post]
+func (a *App) createWebhook(r *http.Request) (interface{}, mw.Response) {
+	subscription, err := handleWebhookRequestBody(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	filter, err := json.Marshal(subscription.Filter)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	dbSubscription := db.WebhookSubscription{
+		URL:    subscription.URL,
+		Secret: secret,
+		Filter: string(filter),
+	}
+	if err := a.db.CreateWebhookSubscription(&dbSubscription); err != nil {
+		return nil, mw.Error(err)
+	}
+	res, err := webhookSubscriptionFromDBWebhookSubscription(dbSubscription)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	res.Secret = secret
+	return res, mw.Created()
+}
+
+// getWebhook godoc
+// @Summary Show a webhook
+// @Description Get the url and filter of a webhook
+// @Tags GridProxy
+// @Produce  json
+// @Param webhook_id path int yes "Webhook ID"
+// @Param X-Webhook-Secret header string true "Secret returned when the webhook was created"
+// @Success 200 {object} types.WebhookSubscription
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks/{webhook_id} [get]
+func (a *App) getWebhook(r *http.Request) (interface{}, mw.Response) {
+	subscription, err := a.authorizedWebhook(r)
+	if err != nil {
+		return nil, webhookReply(r, err)
+	}
+	res, err := webhookSubscriptionFromDBWebhookSubscription(subscription)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	return res, mw.Ok()
+}
+
+// deleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Stop the deliveries to a webhook and delete its delivery log, the deleted webhook is returned
+// @Tags GridProxy
+// @Produce  json
+// @Param webhook_id path int yes "Webhook ID"
+// @Param X-Webhook-Secret header string true "Secret returned when the webhook was created"
+// @Success 200 {object} types.WebhookSubscription
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks/{webhook_id} [delete]
+func (a *App) deleteWebhook(r *http.Request) (interface{}, mw.Response) {
+	subscription, err := a.authorizedWebhook(r)
+	if err != nil {
+		return nil, webhookReply(r, err)
+	}
+	if err := a.db.DeleteWebhookSubscription(subscription.ID); err != nil {
+		return nil, webhookReply(r, err)
+	}
+	res, err := webhookSubscriptionFromDBWebhookSubscription(subscription)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	return res, mw.Ok()
+}
+
+// listWebhookDeliveries godoc
+// @Summary Show the delivery log of a webhook
+// @Description Get the delivery attempts of a webhook, the latest first. It has pagination
+// @Tags GridProxy
+// @Produce  json
+// @Param webhook_id path int yes "Webhook ID"
+// @Param X-Webhook-Secret header string true "Secret returned when the webhook was created"
+// @Param page query int false "Page number"
+// @Param size query int false "Max result per page"
+// @Param ret_count query bool false "Set deliveries' count on headers"
+// @Success 200 {object} []types.WebhookDelivery
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks/{webhook_id}/deliveries [get]
+func (a *App) listWebhookDeliveries(r *http.Request) (interface{}, mw.Response) {
+	subscription, err := a.authorizedWebhook(r)
+	if err != nil {
+		return nil, webhookReply(r, err)
+	}
+	limit, err := getLimit(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	dbDeliveries, deliveriesCount, err := a.db.GetWebhookDeliveries(subscription.ID, limit)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	deliveries := make([]types.WebhookDelivery, len(dbDeliveries))
+	for idx, delivery := range dbDeliveries {
+		deliveries[idx] = webhookDeliveryFromDBWebhookDelivery(delivery)
+	}
+
+	resp := mw.Ok()
+
+	// return the number of pages and totalCount in the response headers
+	if limit.RetCount {
+		pages := math.Ceil(float64(deliveriesCount) / float64(limit.Size))
+		resp = resp.WithHeader("count", fmt.Sprintf("%d", deliveriesCount)).
+			WithHeader("size", fmt.Sprintf("%d", limit.Size)).
+			WithHeader("pages", fmt.Sprintf("%d", int(pages)))
+	}
+	return deliveries, resp
+}
+
 // getNodes godoc
 // @Summary Show nodes on the grid
 // @Description Get all nodes on the grid, It has pagination
@@ -114,8 +475,19 @@ func (a *App) getStats(r *http.Request) (interface{}, mw.Response) {
 // @Param free_mru query int false "Min free reservable mru in bytes"
 // @Param free_hru query int false "Min free reservable hru in bytes"
 // @Param free_sru query int false "Min free reservable sru in bytes"
+// @Param free_mru_max query int false "Max free reservable mru in bytes"
+// @Param free_hru_max query int false "Max free reservable hru in bytes"
+// @Param free_sru_max query int false "Max free reservable sru in bytes"
+// @Param total_mru_max query int false "Max total mru in bytes"
+// @Param total_cru_max query int false "Max total cru"
+// @Param total_hru_max query int false "Max total hru in bytes"
+// @Param total_sru_max query int false "Max total sru in bytes"
+// @Param uptime_min query int false "Min node uptime in seconds"
+// @Param uptime_max query int false "Max node uptime in seconds"
+// @Param created_after query int false "Nodes created at or after the given unix timestamp"
+// @Param created_before query int false "Nodes created before the given unix timestamp"
 // @Param free_ips query int false "Min number of free ips in the farm of the node"
-// @Param status query string false "Node status filter, 'up': for only up nodes & 'down': for only down nodes."
+// @Param status query string false "Node status filter, 'up': for only up nodes, 'down': for only down nodes & 'standby': for nodes powered off by the farmerbot."
 // @Param city query string false "Node city filter"
 // @Param country query string false "Node country filter"
 // @Param farm_name query string false "Get nodes for specific farm"
@@ -129,6 +501,24 @@ func (a *App) getStats(r *http.Request) (interface{}, mw.Response) {
 // @Param available_for query int false "available for twin id"
 // @Param farm_ids query string false "List of farms separated by comma to fetch nodes from (e.g. '1,2,3')"
 // @Param certification_type query string false "certificate type Diy or Certified"
+// @Param has_gpu query bool false "Set to true to filter nodes with gpus"
+// @Param gpu_vendor query string false "Filter nodes with a gpu from a vendor containing the given name"
+// @Param gpu_device query string false "Filter nodes with a gpu with a device name containing the given name"
+// @Param gpu_available query bool false "Set to true to filter nodes with a gpu not used by a contract, the gpu filters apply to the same gpu"
+// @Param exclude_farm_ids query string false "List of farms separated by comma to exclude their nodes (e.g. '1,2,3')"
+// @Param exclude_node_ids query string false "List of nodes separated by comma to exclude (e.g. '1,2,3')"
+// @Param exclude_twin_ids query string false "List of node twins separated by comma to exclude (e.g. '1,2,3')"
+// @Param country_not query string false "Exclude the nodes in the country"
+// @Param certification_type_not query string false "Exclude the nodes with the certificate type Diy or Certified"
+// @Param secure query bool false "Set to true to filter nodes with secure boot enabled"
+// @Param virtualized query bool false "Set to false to filter nodes that run on bare metal"
+// @Param grid_version query int false "Grid version of the node"
+// @Param has_interface_ip query bool false "Set to true to filter nodes with an interface that has an ip"
+// @Param fields query string false "List of node fields separated by comma to return (e.g. 'nodeId,location,status')"
+// @Param expand query string false "Set to 'policy' to embed the farming policy of the node"
+// @Param strict query bool false "Reject unknown parameters, invalid values and conflicting filters instead of ignoring them"
+// @Param node_ids query string false "List of nodes separated by comma to fetch (e.g. '1,2,3')"
+// @Param updated_after query int false "Nodes that reported at or after the given unix timestamp"
 // @Success 200 {object} []types.Node
 // @Failure 400 {object} string
 // @Failure 500 {object} string
@@ -149,8 +539,19 @@ func (a *App) getNodes(r *http.Request) (interface{}, mw.Response) {
 // @Param free_mru query int false "Min free reservable mru in bytes"
 // @Param free_hru query int false "Min free reservable hru in bytes"
 // @Param free_sru query int false "Min free reservable sru in bytes"
+// @Param free_mru_max query int false "Max free reservable mru in bytes"
+// @Param free_hru_max query int false "Max free reservable hru in bytes"
+// @Param free_sru_max query int false "Max free reservable sru in bytes"
+// @Param total_mru_max query int false "Max total mru in bytes"
+// @Param total_cru_max query int false "Max total cru"
+// @Param total_hru_max query int false "Max total hru in bytes"
+// @Param total_sru_max query int false "Max total sru in bytes"
+// @Param uptime_min query int false "Min node uptime in seconds"
+// @Param uptime_max query int false "Max node uptime in seconds"
+// @Param created_after query int false "Nodes created at or after the given unix timestamp"
+// @Param created_before query int false "Nodes created before the given unix timestamp"
 // @Param free_ips query int false "Min number of free ips in the farm of the node"
-// @Param status query string false "Node status filter, 'up': for only up nodes & 'down': for only down nodes."
+// @Param status query string false "Node status filter, 'up': for only up nodes, 'down': for only down nodes & 'standby': for nodes powered off by the farmerbot."
 // @Param city query string false "Node city filter"
 // @Param country query string false "Node country filter"
 // @Param farm_name query string false "Get nodes for specific farm"
@@ -164,6 +565,24 @@ func (a *App) getNodes(r *http.Request) (interface{}, mw.Response) {
 // @Param available_for query int false "available for twin id"
 // @Param farm_ids query string false "List of farms separated by comma to fetch nodes from (e.g. '1,2,3')"
 // @Param certification_type query string false "certificate type Diy or Certified"
+// @Param has_gpu query bool false "Set to true to filter nodes with gpus"
+// @Param gpu_vendor query string false "Filter nodes with a gpu from a vendor containing the given name"
+// @Param gpu_device query string false "Filter nodes with a gpu with a device name containing the given name"
+// @Param gpu_available query bool false "Set to true to filter nodes with a gpu not used by a contract, the gpu filters apply to the same gpu"
+// @Param exclude_farm_ids query string false "List of farms separated by comma to exclude their nodes (e.g. '1,2,3')"
+// @Param exclude_node_ids query string false "List of nodes separated by comma to exclude (e.g. '1,2,3')"
+// @Param exclude_twin_ids query string false "List of node twins separated by comma to exclude (e.g. '1,2,3')"
+// @Param country_not query string false "Exclude the nodes in the country"
+// @Param certification_type_not query string false "Exclude the nodes with the certificate type Diy or Certified"
+// @Param secure query bool false "Set to true to filter nodes with secure boot enabled"
+// @Param virtualized query bool false "Set to false to filter nodes that run on bare metal"
+// @Param grid_version query int false "Grid version of the node"
+// @Param has_interface_ip query bool false "Set to true to filter nodes with an interface that has an ip"
+// @Param fields query string false "List of node fields separated by comma to return (e.g. 'nodeId,location,status')"
+// @Param expand query string false "Set to 'policy' to embed the farming policy of the node"
+// @Param strict query bool false "Reject unknown parameters, invalid values and conflicting filters instead of ignoring them"
+// @Param node_ids query string false "List of nodes separated by comma to fetch (e.g. '1,2,3')"
+// @Param updated_after query int false "Nodes that reported at or after the given unix timestamp"
 // @Success 200 {object} []types.Node
 // @Failure 400 {object} string
 // @Failure 500 {object} string
@@ -173,11 +592,23 @@ func (a *App) getGateways(r *http.Request) (interface{}, mw.Response) {
 }
 
 func (a *App) listNodes(r *http.Request) (interface{}, mw.Response) {
-	filter, limit, err := a.handleNodeRequestsQueryParams(r)
+	filter, limit, err := a.handleNodeRequestsQueryParams(r, "fields", "expand")
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	fields, err := getFields(r, types.Node{})
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	expand, err := expandsPolicy(r)
 	if err != nil {
 		return nil, mw.BadRequest(err)
 	}
-	dbNodes, nodesCount, err := a.db.GetNodes(filter, limit)
+	dbFields := fields
+	if expand {
+		dbFields, fields = expandFields(fields, "farmingPolicyId", "farmingPolicy")
+	}
+	dbNodes, nodesCount, err := a.db.GetNodes(filter, limit, dbFields...)
 	if err != nil {
 		return nil, mw.Error(err)
 	}
@@ -185,6 +616,16 @@ func (a *App) listNodes(r *http.Request) (interface{}, mw.Response) {
 	for idx, node := range dbNodes {
 		nodes[idx] = nodeFromDBNode(node)
 	}
+	if expand {
+		if err := a.expandNodesPolicy(nodes); err != nil {
+			log.Error().Err(err).Msg("failed to query farming policies")
+			return nil, mw.Error(err)
+		}
+	}
+	res, err := selectFields(nodes, fields)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
 	resp := mw.Ok()
 
 	// return the number of pages and totalCount in the response headers
@@ -194,7 +635,56 @@ func (a *App) listNodes(r *http.Request) (interface{}, mw.Response) {
 			WithHeader("size", fmt.Sprintf("%d", limit.Size)).
 			WithHeader("pages", fmt.Sprintf("%d", int(pages)))
 	}
-	return nodes, resp
+	return res, resp
+}
+
+// getName godoc
+// @Summary Show the availability of a gateway name
+// @Description Check if a gateway name is free, or get the name contract reserving it and its owner twin
+// @Tags GridProxy
+// @Accept  json
+// @Produce  json
+// @Param name path string yes "Gateway name, a dns label of letters, digits and hyphens checked in lower case"
+// @Success 200 {object} types.NameAvailability
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /names/{name} [get]
+func (a *App) getName(r *http.Request) (interface{}, mw.Response) {
+	name, err := nameParam(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	availability, err := a.nameAvailability(name)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to check name availability")
+		return nil, mw.Error(err)
+	}
+	return availability, mw.Ok()
+}
+
+// resolveGateway godoc
+// @Summary Resolve a hostname to gateway nodes
+// @Description Get the gateway nodes with a domain the hostname is equal to or a subdomain of, with the availability of the gateway name if the hostname is a name under a gateway domain. The hostnames of the fqdn proxies point to a gateway through dns records only so they can't be resolved
+// @Tags GridProxy
+// @Accept  json
+// @Produce  json
+// @Param fqdn query string true "Hostname to resolve"
+// @Param strict query bool false "Reject unknown parameters, invalid values and conflicting filters instead of ignoring them"
+// @Success 200 {object} types.GatewayResolution
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /gateways/resolve [get]
+func (a *App) resolveGateway(r *http.Request) (interface{}, mw.Response) {
+	fqdn, err := fqdnParam(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	resolution, err := a.gatewayResolution(fqdn)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to resolve gateway")
+		return nil, mw.Error(err)
+	}
+	return resolution, mw.Ok()
 }
 
 // getNode godoc
@@ -202,6 +692,9 @@ func (a *App) listNodes(r *http.Request) (interface{}, mw.Response) {
 // @Description Get all details for specific node hardware, capacity, DMI, hypervisor
 // @Tags GridProxy
 // @Param node_id path int false "Node ID"
+// @Param fields query string false "List of node fields separated by comma to return (e.g. 'nodeId,location,status')"
+// @Param expand query string false "Set to 'policy' to embed the farming policy of the node"
+// @Param strict query bool false "Reject unknown parameters instead of ignoring them"
 // @Accept  json
 // @Produce  json
 // @Success 200 {object} types.NodeWithNestedCapacity
@@ -210,7 +703,35 @@ func (a *App) listNodes(r *http.Request) (interface{}, mw.Response) {
 // @Failure 500 {object} string
 // @Router /nodes/{node_id} [get]
 func (a *App) getNode(r *http.Request) (interface{}, mw.Response) {
-	return a._getNode(r)
+	if err := validateObjectParams(r, "fields", "expand"); err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	fields, err := getFields(r, types.NodeWithNestedCapacity{})
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	expand, err := expandsPolicy(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	dbFields := fields
+	if expand {
+		dbFields, fields = expandFields(fields, "farmingPolicyId", "farmingPolicy")
+	}
+	node, err := a.getNodeData(mux.Vars(r)["node_id"], dbFields...)
+	if err != nil {
+		return nil, errorReply(err)
+	}
+	if expand {
+		if err := a.expandNodePolicy(&node); err != nil {
+			return nil, mw.Error(err)
+		}
+	}
+	res, err := selectFields(node, fields)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	return res, nil
 }
 
 // getGateway godoc
@@ -218,6 +739,9 @@ func (a *App) getNode(r *http.Request) (interface{}, mw.Response) {
 // @Description Get all details for specific gateway hardware, capacity, DMI, hypervisor
 // @Tags GridProxy
 // @Param node_id path int false "Node ID"
+// @Param fields query string false "List of node fields separated by comma to return (e.g. 'nodeId,location,status')"
+// @Param expand query string false "Set to 'policy' to embed the farming policy of the node"
+// @Param strict query bool false "Reject unknown parameters instead of ignoring them"
 // @Accept  json
 // @Produce  json
 // @Success 200 {object} types.NodeWithNestedCapacity
@@ -226,31 +750,52 @@ func (a *App) getNode(r *http.Request) (interface{}, mw.Response) {
 // @Failure 500 {object} string
 // @Router /gateways/{node_id} [get]
 func (a *App) getGateway(r *http.Request) (interface{}, mw.Response) {
-	node, err := a._getNode(r)
-
+	if err := validateObjectParams(r, "fields", "expand"); err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	fields, err := getFields(r, types.NodeWithNestedCapacity{})
 	if err != nil {
-		return nil, err
-	} else if node.(types.NodeWithNestedCapacity).PublicConfig.Domain == "" {
-		return nil, errorReply(ErrGatewayNotFound)
-	} else {
-		return node, nil
+		return nil, mw.BadRequest(err)
 	}
-}
-
-func (a *App) _getNode(r *http.Request) (interface{}, mw.Response) {
-	nodeID := mux.Vars(r)["node_id"]
-	nodeData, err := a.getNodeData(nodeID)
+	expand, err := expandsPolicy(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	dbFields := fields
+	if expand {
+		dbFields, fields = expandFields(fields, "farmingPolicyId", "farmingPolicy")
+	}
+	if len(fields) != 0 {
+		// the public config is needed to know if the node is a gateway
+		dbFields = append([]string{"publicConfig"}, dbFields...)
+	}
+	node, err := a.getNodeData(mux.Vars(r)["node_id"], dbFields...)
 	if err != nil {
 		return nil, errorReply(err)
 	}
-	return nodeData, nil
+	if node.PublicConfig.Domain == "" {
+		return nil, errorReply(ErrGatewayNotFound)
+	}
+	if expand {
+		if err := a.expandNodePolicy(&node); err != nil {
+			return nil, mw.Error(err)
+		}
+	}
+	res, err := selectFields(node, fields)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	return res, nil
 }
 
 func (a *App) getNodeStatus(r *http.Request) (interface{}, mw.Response) {
+	if err := validateObjectParams(r); err != nil {
+		return nil, mw.BadRequest(err)
+	}
 	response := types.NodeStatus{}
 	nodeID := mux.Vars(r)["node_id"]
 
-	nodeData, err := a.getNodeData(nodeID)
+	nodeData, err := a.getNodeData(nodeID, "status")
 	if err != nil {
 		return nil, errorReply(err)
 	}
@@ -258,30 +803,91 @@ func (a *App) getNodeStatus(r *http.Request) (interface{}, mw.Response) {
 	return response, nil
 }
 
-// listTwins godoc
-// @Summary Show twins on the grid
-// @Description Get all twins on the grid, It has pagination
+// getNodeContracts godoc
+// @Summary Show the contracts on a node
+// @Description Get the active node contracts on a node with the resources they use and the node rent contract. The sum of the contracts resources and the resources reserved by zos should be the node used resources
 // @Tags GridProxy
 // @Accept  json
 // @Produce  json
-// @Param page query int false "Page number"
-// @Param size query int false "Max result per page"
-// @Param ret_count query bool false "Set twins' count on headers based on filter"
-// @Param twin_id query int false "twin id"
-// @Param account_id query string false "account address"
-// @Success 200 {object} []types.Twin
+// @Param node_id path int yes "Node ID"
+// @Param strict query bool false "Reject unknown parameters instead of ignoring them"
+// @Success 200 {object} types.NodeContracts
 // @Failure 400 {object} string
+// @Failure 404 {object} string
 // @Failure 500 {object} string
-// @Router /twins [get]
-func (a *App) listTwins(r *http.Request) (interface{}, mw.Response) {
-	filter, limit, err := a.handleTwinRequestsQueryParams(r)
-	if err != nil {
+// @Router /nodes/{node_id}/contracts [get]
+func (a *App) getNodeContracts(r *http.Request) (interface{}, mw.Response) {
+	if err := validateObjectParams(r); err != nil {
 		return nil, mw.BadRequest(err)
 	}
-	twins, twinsCount, err := a.db.GetTwins(filter, limit)
+	node, err := a.getNodeData(mux.Vars(r)["node_id"], "nodeId", "capacity", "rentContractId")
 	if err != nil {
-		log.Error().Err(err).Msg("failed to query twin")
-		return nil, mw.Error(err)
+		return nil, errorReply(err)
+	}
+	dbContracts, err := a.db.GetNodeContracts(uint32(node.NodeID))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to query node contracts")
+		return nil, mw.Error(err)
+	}
+	res := newNodeContracts(node, dbContracts)
+
+	if node.RentContractID != 0 {
+		contractID := uint64(node.RentContractID)
+		dbContracts, _, err := a.db.GetContracts(types.ContractFilter{ContractID: &contractID}, types.Limit{Size: 1, Page: 1})
+		if err != nil {
+			log.Error().Err(err).Msg("failed to query node rent contract")
+			return nil, mw.Error(err)
+		}
+		if len(dbContracts) != 0 {
+			rentContract, err := contractFromDBContract(dbContracts[0])
+			if err != nil {
+				log.Err(err).Msg("failed to convert db contract to api contract")
+			}
+			res.RentContract = &rentContract
+		}
+	}
+	return res, nil
+}
+
+// listTwins godoc
+// @Summary Show twins on the grid
+// @Description Get all twins on the grid, It has pagination
+// @Tags GridProxy
+// @Accept  json
+// @Produce  json
+// @Param page query int false "Page number"
+// @Param size query int false "Max result per page"
+// @Param ret_count query bool false "Set twins' count on headers based on filter"
+// @Param twin_id query int false "twin id"
+// @Param account_id query string false "account address"
+// @Param fields query string false "List of twin fields separated by comma to return (e.g. 'twinId,relay')"
+// @Param strict query bool false "Reject unknown parameters, invalid values and conflicting filters instead of ignoring them"
+// @Param twin_ids query string false "List of twins separated by comma to fetch (e.g. '1,2,3')"
+// @Param relay query string false "Relay of the twin"
+// @Param public_key query string false "Public key of the twin"
+// @Param relay_contains query string false "Twins with a relay containing the given string"
+// @Param account_ids query string false "List of accounts separated by comma to fetch their twins"
+// @Success 200 {object} []types.Twin
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /twins [get]
+func (a *App) listTwins(r *http.Request) (interface{}, mw.Response) {
+	filter, limit, err := a.handleTwinRequestsQueryParams(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	fields, err := getFields(r, types.Twin{})
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	twins, twinsCount, err := a.db.GetTwins(filter, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to query twin")
+		return nil, mw.Error(err)
+	}
+	res, err := selectFields(twins, fields)
+	if err != nil {
+		return nil, mw.Error(err)
 	}
 
 	resp := mw.Ok()
@@ -293,7 +899,76 @@ func (a *App) listTwins(r *http.Request) (interface{}, mw.Response) {
 			WithHeader("size", fmt.Sprintf("%d", limit.Size)).
 			WithHeader("pages", fmt.Sprintf("%d", int(pages)))
 	}
-	return twins, resp
+	return res, resp
+}
+
+// getTwinByAccount godoc
+// @Summary Show the twin of an account
+// @Description Get the twin of the account, use the account_ids filter of /twins to get the twins of many accounts
+// @Tags GridProxy
+// @Accept  json
+// @Produce  json
+// @Param account_id path string yes "Account address"
+// @Param strict query bool false "Reject unknown parameters instead of ignoring them"
+// @Success 200 {object} types.Twin
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /twins/by_account/{account_id} [get]
+func (a *App) getTwinByAccount(r *http.Request) (interface{}, mw.Response) {
+	if err := validateObjectParams(r); err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	accountID := mux.Vars(r)["account_id"]
+	twins, _, err := a.db.GetTwins(types.TwinFilter{AccountID: &accountID}, types.Limit{Page: 1, Size: 1})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to query twin")
+		return nil, mw.Error(err)
+	}
+	if len(twins) == 0 {
+		return nil, mw.NotFound(types.NewError(types.ErrCodeNotFound, fmt.Sprintf("twin of account %s not found", accountID)))
+	}
+	return twins[0], mw.Ok()
+}
+
+// getTwinSpending godoc
+// @Summary Show the spending of a twin
+// @Description Get the amount billed for the contracts of a twin in a time range grouped by day, month, contract or node. The amounts are in units of 1e-7 TFT, and the discounts are broken down by the discount level received since the bill reports have the level and not the discounted amount
+// @Tags GridProxy
+// @Accept  json
+// @Produce  json
+// @Param twin_id path int yes "Twin ID"
+// @Param from query int false "Start of the range as unix timestamp, the range starts with the first bill report by default"
+// @Param to query int false "End of the range as unix timestamp excluded from the range, defaults to now"
+// @Param group_by query string false "Group the bill reports by 'day', 'month', 'contract' or 'node', defaults to month. The days and months are in UTC"
+// @Param strict query bool false "Reject unknown parameters, invalid values and conflicting filters instead of ignoring them"
+// @Success 200 {object} types.TwinSpending
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /twins/{twin_id}/spending [get]
+func (a *App) getTwinSpending(r *http.Request) (interface{}, mw.Response) {
+	twinID, err := twinIDParam(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	from, to, groupBy, err := a.handleSpendingRequestsQueryParams(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	twins, _, err := a.db.GetTwins(types.TwinFilter{TwinID: &twinID}, types.Limit{Page: 1, Size: 1})
+	if err != nil {
+		return nil, mw.Error(err)
+	}
+	if len(twins) == 0 {
+		return nil, mw.NotFound(types.NewError(types.ErrCodeNotFound, fmt.Sprintf("twin %d not found", twinID)))
+	}
+	spending, err := a.twinSpending(twinID, from, to, groupBy)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get twin spending")
+		return nil, mw.Error(err)
+	}
+	return spending, mw.Ok()
 }
 
 // listContracts godoc
@@ -314,6 +989,18 @@ func (a *App) listTwins(r *http.Request) (interface{}, mw.Response) {
 // @Param deployment_data query string false "contract deployment data in case of 'node' contracts"
 // @Param deployment_hash query string false "contract deployment hash in case of 'node' contracts"
 // @Param number_of_public_ips query int false "Min number of public ips in the 'node' contract"
+// @Param states query string false "List of contract states separated by comma (e.g. 'Created,GracePeriod')"
+// @Param types query string false "List of contract types separated by comma (e.g. 'node,rent')"
+// @Param created_after query int false "Contracts created at or after the given unix timestamp"
+// @Param created_before query int false "Contracts created before the given unix timestamp"
+// @Param node_ids query string false "List of node ids separated by comma (e.g. '1,2,3')"
+// @Param twin_ids query string false "List of twin ids separated by comma (e.g. '1,2,3')"
+// @Param farm_id query int false "Contracts deployed on the nodes of the farm"
+// @Param has_public_ips query bool false "Set to true to get the contracts with public ips only"
+// @Param name_contains query string false "contract name contains in case of 'name' contracts"
+// @Param fields query string false "List of contract fields separated by comma to return (e.g. 'contractId,state')"
+// @Param strict query bool false "Reject unknown parameters, invalid values and conflicting filters instead of ignoring them"
+// @Param contract_ids query string false "List of contracts separated by comma to fetch (e.g. '1,2,3')"
 // @Success 200 {object} []types.Contract
 // @Failure 400 {object} string
 // @Failure 500 {object} string
@@ -323,6 +1010,10 @@ func (a *App) listContracts(r *http.Request) (interface{}, mw.Response) {
 	if err != nil {
 		return nil, mw.BadRequest(err)
 	}
+	fields, err := getFields(r, types.Contract{})
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
 	dbContracts, contractsCount, err := a.db.GetContracts(filter, limit)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to query contract")
@@ -336,6 +1027,10 @@ func (a *App) listContracts(r *http.Request) (interface{}, mw.Response) {
 			log.Err(err).Msg("failed to convert db contract to api contract")
 		}
 	}
+	res, err := selectFields(contracts, fields)
+	if err != nil {
+		return nil, mw.Error(err)
+	}
 	resp := mw.Ok()
 
 	// return the number of pages and totalCount in the response headers
@@ -345,7 +1040,7 @@ func (a *App) listContracts(r *http.Request) (interface{}, mw.Response) {
 			WithHeader("size", fmt.Sprintf("%d", limit.Size)).
 			WithHeader("pages", fmt.Sprintf("%d", int(pages)))
 	}
-	return contracts, resp
+	return res, resp
 }
 
 // ping godoc
@@ -400,19 +1095,461 @@ func (a *App) version(r *http.Request) (interface{}, mw.Response) {
 // @Router /nodes/{node_id}/statistics  [get]
 func (a *App) getNodeStatistics(r *http.Request) (interface{}, mw.Response) {
 	nodeID := mux.Vars(r)["node_id"]
-	node, err := a.getNodeData(nodeID)
+	node, err := a.getNodeData(nodeID, "nodeId", "twinId")
 	if err != nil {
 		return nil, errorReply(err)
 	}
 
-	var res types.NodeStatistics
-	err = a.relayClient.Call(r.Context(), uint32(node.TwinID), "zos.statistics.get", nil, &res)
+	res, err := a.nodeStatistics(r.Context(), uint32(node.NodeID), uint32(node.TwinID))
 	if err != nil {
-		return nil, mw.Error(fmt.Errorf("failed to get get node statistics from relay: %w", err))
+		return nil, errorReply(err)
 	}
 	return res, mw.Ok()
 }
 
+// getNodesStatistics godoc
+// @Summary Show the statistics of many nodes
+// @Description Get the statistics of many nodes at once through the RMB relay, the result of each node has its statistics or the error of getting them. Statistics are cached for a short time
+// @Tags NodeStatistics
+// @Param node_ids query string true "List of node ids separated by comma (e.g. '1,2,3')"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} []types.NodeStatisticsResult
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /nodes/statistics  [get]
+func (a *App) getNodesStatistics(r *http.Request) (interface{}, mw.Response) {
+	var nodeIDs []uint64
+	if err := parseParams(r, nil, nil, nil, map[string]*[]uint64{"node_ids": &nodeIDs}); err != nil {
+		return nil, errorReply(err)
+	}
+	if len(nodeIDs) == 0 {
+		return nil, errorReply(paramError("node_ids", "node_ids is required"))
+	}
+	seen := make(map[uint64]struct{}, len(nodeIDs))
+	ids := make([]uint32, 0, len(nodeIDs))
+	for _, id := range nodeIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, uint32(id))
+	}
+	if len(ids) > maxStatisticsNodes {
+		return nil, errorReply(paramError("node_ids", "max number of nodes is %d", maxStatisticsNodes))
+	}
+	return a.nodesStatistics(r.Context(), ids), mw.Ok()
+}
+
+// compareNodes godoc
+// @Summary Compare nodes
+// @Description Get a side by side comparison of 2 to 10 nodes with their capacity, free resources, uptime, availability, location, certification, rent price and rent state. Each attribute has the values of the nodes in the requested order and the ids of the nodes with the best value
+// @Tags NodeInfo
+// @Param node_ids query string true "List of 2 to 10 node ids separated by comma (e.g. '1,2,3')"
+// @Param strict query bool false "Reject unknown parameters and invalid values instead of ignoring them"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} types.NodeComparison
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /nodes/compare [get]
+func (a *App) compareNodes(r *http.Request) (interface{}, mw.Response) {
+	nodeIDs, err := nodeIDsParam(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	comparison, err := a.nodeComparison(nodeIDs)
+	if types.IsErrorCode(err, types.ErrCodeNodeNotFound) {
+		return nil, mw.NotFound(err)
+	} else if err != nil {
+		log.Error().Err(err).Msg("failed to compare nodes")
+		return nil, mw.Error(err)
+	}
+	return comparison, mw.Ok()
+}
+
+// getNodeVersion godoc
+// @Summary Show node version
+// @Description Get the zos and zinit versions of a node and its hypervisor through the RMB relay
+// @Tags NodeInfo
+// @Param node_id path int yes "Node ID"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} types.NodeVersion
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Failure 502 {object} string
+// @Router /nodes/{node_id}/version [get]
+func (a *App) getNodeVersion(r *http.Request) (interface{}, mw.Response) {
+	twinID, err := a.nodeTwinID(r)
+	if err != nil {
+		return nil, errorReply(err)
+	}
+	var res types.NodeVersion
+	if err := a.callNode(r.Context(), twinID, "zos.system.version", nil, &res); err != nil {
+		return nil, errorReply(err)
+	}
+	if err := a.callNode(r.Context(), twinID, "zos.system.hypervisor", nil, &res.Hypervisor); err != nil {
+		return nil, errorReply(err)
+	}
+	return res, mw.Ok()
+}
+
+// getNodeDMI godoc
+// @Summary Show node hardware info
+// @Description Get the DMI hardware info of a node through the RMB relay
+// @Tags NodeInfo
+// @Param node_id path int yes "Node ID"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} types.DMI
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Failure 502 {object} string
+// @Router /nodes/{node_id}/dmi [get]
+func (a *App) getNodeDMI(r *http.Request) (interface{}, mw.Response) {
+	twinID, err := a.nodeTwinID(r)
+	if err != nil {
+		return nil, errorReply(err)
+	}
+	var res types.DMI
+	if err := a.callNode(r.Context(), twinID, "zos.system.dmi", nil, &res); err != nil {
+		return nil, errorReply(err)
+	}
+	return res, mw.Ok()
+}
+
+// getNodeInterfaces godoc
+// @Summary Show node network interfaces
+// @Description Get the ips of the network interfaces of a node and its public config through the RMB relay
+// @Tags NodeInfo
+// @Param node_id path int yes "Node ID"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} types.NodeInterfaces
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Failure 502 {object} string
+// @Router /nodes/{node_id}/interfaces [get]
+func (a *App) getNodeInterfaces(r *http.Request) (interface{}, mw.Response) {
+	twinID, err := a.nodeTwinID(r)
+	if err != nil {
+		return nil, errorReply(err)
+	}
+	var res types.NodeInterfaces
+	if err := a.callNode(r.Context(), twinID, "zos.network.interfaces", nil, &res.Interfaces); err != nil {
+		return nil, errorReply(err)
+	}
+	var publicConfig types.NodePublicConfig
+	// zos fails the call if the node has no public config
+	if err := a.callNode(r.Context(), twinID, "zos.network.public_config_get", nil, &publicConfig); err != nil {
+		log.Debug().Err(err).Uint32("twin", twinID).Msg("couldn't get node public config")
+	} else {
+		res.PublicConfig = &publicConfig
+	}
+	return res, mw.Ok()
+}
+
+// getNodeGPUs godoc
+// @Summary Show node gpus
+// @Description Get the gpus of a node and the contracts using them through the RMB relay
+// @Tags NodeInfo
+// @Param node_id path int yes "Node ID"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} []types.NodeGPU
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Failure 502 {object} string
+// @Router /nodes/{node_id}/gpus [get]
+func (a *App) getNodeGPUs(r *http.Request) (interface{}, mw.Response) {
+	twinID, err := a.nodeTwinID(r)
+	if err != nil {
+		return nil, errorReply(err)
+	}
+	res := []types.NodeGPU{}
+	if err := a.callNode(r.Context(), twinID, "zos.gpu.list", nil, &res); err != nil {
+		return nil, errorReply(err)
+	}
+	return res, mw.Ok()
+}
+
+// getNodePools godoc
+// @Summary Show node storage pools
+// @Description Get the storage pools of a node with their size and usage through the RMB relay
+// @Tags NodeInfo
+// @Param node_id path int yes "Node ID"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} []types.StoragePool
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Failure 502 {object} string
+// @Router /nodes/{node_id}/pools [get]
+func (a *App) getNodePools(r *http.Request) (interface{}, mw.Response) {
+	twinID, err := a.nodeTwinID(r)
+	if err != nil {
+		return nil, errorReply(err)
+	}
+	res := []types.StoragePool{}
+	if err := a.callNode(r.Context(), twinID, "zos.storage.pools", nil, &res); err != nil {
+		return nil, errorReply(err)
+	}
+	return res, mw.Ok()
+}
+
+// estimatePrice godoc
+// @Summary Estimate the cost of a deployment
+// @Description Estimate the hourly and monthly cost in USD and TFT of deploying the given resources. The pricing policy, certification and dedicated discount of the node are applied if a node is given, and the staking discount levels are computed with the given balance
+// @Tags Pricing
+// @Accept  json
+// @Produce  json
+// @Param cru query int false "Number of cores"
+// @Param mru query int false "Memory in bytes"
+// @Param sru query int false "SSD storage in bytes"
+// @Param hru query int false "HDD storage in bytes"
+// @Param public_ips query int false "Number of public ips"
+// @Param node_id query int false "Node to deploy on"
+// @Param pricing_policy_id query int false "Pricing policy to use if no node is given, defaults to 1"
+// @Param certified query bool false "Set to true to price a certified node if no node is given"
+// @Param dedicated query bool false "Set to true to apply the dedicated node discount, it's always applied on nodes of dedicated farms. The node total resources are priced if no resources are given"
+// @Param balance query int false "Balance of the twin in TFT to find the reached staking discount"
+// @Success 200 {object} types.PriceEstimate
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /pricing/estimate [get]
+func (a *App) estimatePrice(r *http.Request) (interface{}, mw.Response) {
+	var cru, mru, sru, hru, publicIPs, nodeID, policyID, balance *uint64
+	var certified, dedicated *bool
+	ints := map[string]**uint64{
+		"cru":               &cru,
+		"mru":               &mru,
+		"sru":               &sru,
+		"hru":               &hru,
+		"public_ips":        &publicIPs,
+		"node_id":           &nodeID,
+		"pricing_policy_id": &policyID,
+		"balance":           &balance,
+	}
+	bools := map[string]**bool{
+		"certified": &certified,
+		"dedicated": &dedicated,
+	}
+	var errs paramErrors
+	errs.add(parseParams(r, ints, nil, bools, nil))
+	if isStrict(r) {
+		errs.add(validateParams(r, paramNames(ints, nil, bools, nil), nil))
+		if nodeID != nil && (certified != nil || policyID != nil) {
+			errs.conflict("node_id", "certified and pricing_policy_id", "the node certification and pricing policy are used")
+		}
+	}
+	if err := errs.err(); err != nil {
+		return nil, errorReply(err)
+	}
+
+	var estimate types.PriceEstimate
+	if cru != nil {
+		estimate.Resources.CRU = *cru
+	}
+	if mru != nil {
+		estimate.Resources.MRU = gridtypes.Unit(*mru)
+	}
+	if sru != nil {
+		estimate.Resources.SRU = gridtypes.Unit(*sru)
+	}
+	if hru != nil {
+		estimate.Resources.HRU = gridtypes.Unit(*hru)
+	}
+	if publicIPs != nil {
+		estimate.PublicIPs = *publicIPs
+	}
+	estimate.Certified = certified != nil && *certified
+	estimate.Dedicated = dedicated != nil && *dedicated
+	pricingPolicyID := uint32(defaultPricingPolicyID)
+	if policyID != nil {
+		pricingPolicyID = uint32(*policyID)
+	}
+
+	if nodeID != nil {
+		node, err := a.getNodeData(fmt.Sprint(*nodeID), "nodeId", "farmId", "certificationType", "dedicated", "total_resources")
+		if err != nil {
+			return nil, errorReply(err)
+		}
+		farm, err := a.db.GetFarm(uint32(node.FarmID))
+		if err != nil {
+			return nil, mw.Error(err)
+		}
+		estimate.NodeID = uint32(node.NodeID)
+		estimate.Certified = node.CertificationType == "Certified"
+		estimate.Dedicated = estimate.Dedicated || node.Dedicated
+		if estimate.Dedicated && estimate.Resources == (types.Capacity{}) {
+			estimate.Resources = node.Capacity.Total
+		}
+		pricingPolicyID = uint32(farm.PricingPolicyID)
+	}
+
+	policy, err := a.db.GetPricingPolicy(pricingPolicyID)
+	if errors.Is(err, db.ErrPricingPolicyNotFound) {
+		return nil, mw.NotFound(types.NewError(types.ErrCodeNotFound, fmt.Sprintf("pricing policy %d not found", pricingPolicyID)))
+	} else if err != nil {
+		return nil, mw.Error(err)
+	}
+	price, err := a.tftPrice()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get tft price")
+		return nil, mw.Error(errors.Wrap(err, "couldn't get tft price"))
+	}
+	estimatePrice(&estimate, policy, price, balance)
+	return estimate, mw.Ok()
+}
+
+// listRentableNodes godoc
+// @Summary Show the rentable nodes with their rent price
+// @Description Get the nodes available for renting with their total resources, farm certification, location and the hourly and monthly price of renting them after the dedicated node discount. All the nodes filters are supported, It has pagination
+// @Tags Pricing
+// @Accept  json
+// @Produce  json
+// @Param page query int false "Page number"
+// @Param size query int false "Max result per page"
+// @Param ret_count query bool false "Set nodes' count on headers based on filter"
+// @Param sort_by query string false "Sort the nodes by 'price', 'price_per_core' or 'price_per_gb' of memory in ascending order, the nodes are sorted by id by default"
+// @Param max_price query number false "Max hourly price in USD of renting the node"
+// @Param status query string false "Node status filter, 'up': for only up nodes, 'down': for only down nodes & 'standby': for nodes powered off by the farmerbot."
+// @Param country query string false "Node country filter"
+// @Param farm_ids query string false "List of farms separated by comma to fetch nodes from (e.g. '1,2,3')"
+// @Param dedicated query bool false "Set to true to get the nodes of dedicated farms only"
+// @Param certification_type query string false "certificate type Diy or Certified"
+// @Param has_gpu query bool false "Set to true to filter nodes with gpus"
+// @Param total_cru query int false "Min total cru"
+// @Param total_mru query int false "Min total mru in bytes"
+// @Param strict query bool false "Reject unknown parameters, invalid values and conflicting filters instead of ignoring them"
+// @Success 200 {object} []types.RentableNode
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /rentable [get]
+func (a *App) listRentableNodes(r *http.Request) (interface{}, mw.Response) {
+	filter, limit, maxPrice, sortBy, err := a.handleRentableRequestsQueryParams(r)
+	if err != nil {
+		return nil, mw.BadRequest(err)
+	}
+	nodes, count, err := a.rentableNodes(filter, limit, maxPrice, sortBy)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list rentable nodes")
+		return nil, mw.Error(err)
+	}
+	resp := mw.Ok()
+	if limit.RetCount {
+		pages := math.Ceil(float64(count) / float64(limit.Size))
+		resp = resp.WithHeader("count", fmt.Sprintf("%d", count)).
+			WithHeader("size", fmt.Sprintf("%d", limit.Size)).
+			WithHeader("pages", fmt.Sprintf("%d", int(pages)))
+	}
+	return nodes, resp
+}
+
+// listPricingPolicies godoc
+// @Summary Show the pricing policies
+// @Description Get all the pricing policies of the grid, the prices are in units of 1e-7 USD per hour
+// @Tags Policies
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} []types.PricingPolicy
+// @Failure 500 {object} string
+// @Router /pricing_policies [get]
+func (a *App) listPricingPolicies(r *http.Request) (interface{}, mw.Response) {
+	dbPolicies, err := a.db.GetPricingPolicies()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to query pricing policies")
+		return nil, mw.Error(err)
+	}
+	policies := make([]types.PricingPolicy, len(dbPolicies))
+	for idx, policy := range dbPolicies {
+		policies[idx] = pricingPolicyFromDBPricingPolicy(policy)
+	}
+	return policies, mw.Ok()
+}
+
+// getPricingPolicy godoc
+// @Summary Show the details of a pricing policy
+// @Description Get a pricing policy, the prices are in units of 1e-7 USD per hour
+// @Tags Policies
+// @Accept  json
+// @Produce  json
+// @Param policy_id path int yes "Pricing policy ID"
+// @Success 200 {object} types.PricingPolicy
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /pricing_policies/{policy_id} [get]
+func (a *App) getPricingPolicy(r *http.Request) (interface{}, mw.Response) {
+	policyID, err := policyIDParam(r)
+	if err != nil {
+		return nil, errorReply(err)
+	}
+	policy, err := a.db.GetPricingPolicy(policyID)
+	if errors.Is(err, db.ErrPricingPolicyNotFound) {
+		return nil, mw.NotFound(types.NewError(types.ErrCodeNotFound, fmt.Sprintf("pricing policy %d not found", policyID)))
+	} else if err != nil {
+		return nil, mw.Error(err)
+	}
+	return pricingPolicyFromDBPricingPolicy(policy), mw.Ok()
+}
+
+// listFarmingPolicies godoc
+// @Summary Show the farming policies
+// @Description Get all the farming policies of the grid
+// @Tags Policies
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} []types.FarmingPolicy
+// @Failure 500 {object} string
+// @Router /farming_policies [get]
+func (a *App) listFarmingPolicies(r *http.Request) (interface{}, mw.Response) {
+	dbPolicies, err := a.db.GetFarmingPolicies()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to query farming policies")
+		return nil, mw.Error(err)
+	}
+	policies := make([]types.FarmingPolicy, len(dbPolicies))
+	for idx, policy := range dbPolicies {
+		policies[idx] = farmingPolicyFromDBFarmingPolicy(policy)
+	}
+	return policies, mw.Ok()
+}
+
+// getFarmingPolicy godoc
+// @Summary Show the details of a farming policy
+// @Description Get a farming policy
+// @Tags Policies
+// @Accept  json
+// @Produce  json
+// @Param policy_id path int yes "Farming policy ID"
+// @Success 200 {object} types.FarmingPolicy
+// @Failure 400 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /farming_policies/{policy_id} [get]
+func (a *App) getFarmingPolicy(r *http.Request) (interface{}, mw.Response) {
+	policyID, err := policyIDParam(r)
+	if err != nil {
+		return nil, errorReply(err)
+	}
+	policy, err := a.db.GetFarmingPolicy(policyID)
+	if errors.Is(err, db.ErrFarmingPolicyNotFound) {
+		return nil, mw.NotFound(types.NewError(types.ErrCodeNotFound, fmt.Sprintf("farming policy %d not found", policyID)))
+	} else if err != nil {
+		return nil, mw.Error(err)
+	}
+	return farmingPolicyFromDBFarmingPolicy(policy), mw.Ok()
+}
+
 // Setup is the server and do initial configurations
 // @title Grid Proxy Server API
 // @version 1.0
@@ -420,7 +1557,7 @@ func (a *App) getNodeStatistics(r *http.Request) (interface{}, mw.Response) {
 // @license.name Apache 2.0
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 // @BasePath /
-func Setup(router *mux.Router, gitCommit string, database db.Database, relayClient rmb.Client) error {
+func Setup(router *mux.Router, gitCommit string, database db.Database, relayClient rmb.Client, priceSource PriceSource, nodeEvents *NodeEvents) error {
 
 	c := cache.New(2*time.Minute, 3*time.Minute)
 	a := App{
@@ -428,23 +1565,57 @@ func Setup(router *mux.Router, gitCommit string, database db.Database, relayClie
 		lruCache:       c,
 		releaseVersion: gitCommit,
 		relayClient:    relayClient,
+		priceSource:    priceSource,
+		nodeEvents:     nodeEvents,
 	}
 
+	a.registerRoutes(router)
+	a.registerRoutes(router.PathPrefix(v2Prefix).Subrouter())
+	router.PathPrefix("/swagger").Handler(httpSwagger.WrapHandler)
+
+	return nil
+}
+
+// registerRoutes registers the endpoints on the router
+func (a *App) registerRoutes(router *mux.Router) {
 	router.HandleFunc("/farms", mw.AsHandlerFunc(a.listFarms))
+	router.HandleFunc("/farms/{farm_id:[0-9]+}/report", mw.AsHandlerFunc(a.getFarmReport))
 	router.HandleFunc("/stats", mw.AsHandlerFunc(a.getStats))
+	router.HandleFunc("/stats/history", mw.AsHandlerFunc(a.getStatsHistory))
 	router.HandleFunc("/nodes", mw.AsHandlerFunc(a.getNodes))
 	router.HandleFunc("/gateways", mw.AsHandlerFunc(a.getGateways))
 	router.HandleFunc("/twins", mw.AsHandlerFunc(a.listTwins))
 	router.HandleFunc("/contracts", mw.AsHandlerFunc(a.listContracts))
+	router.HandleFunc("/twins/by_account/{account_id}", mw.AsHandlerFunc(a.getTwinByAccount))
+	router.HandleFunc("/twins/{twin_id:[0-9]+}/spending", mw.AsHandlerFunc(a.getTwinSpending))
 	router.HandleFunc("/nodes/{node_id:[0-9]+}", mw.AsHandlerFunc(a.getNode))
 	router.HandleFunc("/gateways/{node_id:[0-9]+}", mw.AsHandlerFunc(a.getGateway))
+	router.HandleFunc("/gateways/resolve", mw.AsHandlerFunc(a.resolveGateway))
+	router.HandleFunc("/names/{name}", mw.AsHandlerFunc(a.getName))
 	router.HandleFunc("/nodes/{node_id:[0-9]+}/status", mw.AsHandlerFunc(a.getNodeStatus))
 	router.HandleFunc("/gateways/{node_id:[0-9]+}/status", mw.AsHandlerFunc(a.getNodeStatus))
+	router.HandleFunc("/nodes/{node_id:[0-9]+}/contracts", mw.AsHandlerFunc(a.getNodeContracts))
 	router.HandleFunc("/ping", mw.AsHandlerFunc(a.ping))
 	router.HandleFunc("/", mw.AsHandlerFunc(a.indexPage(router)))
 	router.HandleFunc("/version", mw.AsHandlerFunc(a.version))
 	router.HandleFunc("/nodes/{node_id:[0-9]+}/statistics", mw.AsHandlerFunc(a.getNodeStatistics))
-	router.PathPrefix("/swagger").Handler(httpSwagger.WrapHandler)
-
-	return nil
+	router.HandleFunc("/nodes/statistics", mw.AsHandlerFunc(a.getNodesStatistics))
+	router.HandleFunc("/nodes/compare", mw.AsHandlerFunc(a.compareNodes))
+	router.HandleFunc("/nodes/{node_id:[0-9]+}/version", mw.AsHandlerFunc(a.getNodeVersion))
+	router.HandleFunc("/nodes/{node_id:[0-9]+}/dmi", mw.AsHandlerFunc(a.getNodeDMI))
+	router.HandleFunc("/nodes/{node_id:[0-9]+}/interfaces", mw.AsHandlerFunc(a.getNodeInterfaces))
+	router.HandleFunc("/nodes/{node_id:[0-9]+}/gpus", mw.AsHandlerFunc(a.getNodeGPUs))
+	router.HandleFunc("/nodes/{node_id:[0-9]+}/pools", mw.AsHandlerFunc(a.getNodePools))
+	router.HandleFunc("/pricing/estimate", mw.AsHandlerFunc(a.estimatePrice))
+	router.HandleFunc("/rentable", mw.AsHandlerFunc(a.listRentableNodes))
+	router.HandleFunc("/pricing_policies", mw.AsHandlerFunc(a.listPricingPolicies))
+	router.HandleFunc("/pricing_policies/{policy_id:[0-9]+}", mw.AsHandlerFunc(a.getPricingPolicy))
+	router.HandleFunc("/farming_policies", mw.AsHandlerFunc(a.listFarmingPolicies))
+	router.HandleFunc("/farming_policies/{policy_id:[0-9]+}", mw.AsHandlerFunc(a.getFarmingPolicy))
+	router.HandleFunc("/events", mw.AsStreamHandlerFunc(a.streamEvents))
+	router.HandleFunc("/changes", mw.AsHandlerFunc(a.getChanges))
+	router.HandleFunc("/webhooks", mw.AsHandlerFunc(a.createWebhook)).Methods(http.MethodPost)
+	router.HandleFunc("/webhooks/{webhook_id:[0-9]+}", mw.AsHandlerFunc(a.getWebhook)).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks/{webhook_id:[0-9]+}", mw.AsHandlerFunc(a.deleteWebhook)).Methods(http.MethodDelete)
+	router.HandleFunc("/webhooks/{webhook_id:[0-9]+}/deliveries", mw.AsHandlerFunc(a.listWebhookDeliveries)).Methods(http.MethodGet)
 }
@@ -0,0 +1,75 @@
+package explorer
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+const (
+	// DefaultStatsSnapshotInterval is the default interval between the stats snapshots
+	DefaultStatsSnapshotInterval = time.Hour
+	// statsHistoryDefaultRange is the stats history returned if no range is given
+	statsHistoryDefaultRange = 30 * 24 * time.Hour
+	// statsHistoryDefaultInterval is the stats history bucket size if no interval is given
+	statsHistoryDefaultInterval = 24 * time.Hour
+)
+
+// StatsSnapshotter stores the grid counters periodically to keep the history of the grid stats
+type StatsSnapshotter struct {
+	db       db.Database
+	interval time.Duration
+}
+
+// NewStatsSnapshotter creates a new snapshotter storing the grid counters every interval
+func NewStatsSnapshotter(database db.Database, interval time.Duration) *StatsSnapshotter {
+	return &StatsSnapshotter{db: database, interval: interval}
+}
+
+// Run takes a snapshot right away then every interval until the context is canceled
+func (s *StatsSnapshotter) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.snapshot(time.Now()); err != nil {
+			log.Error().Err(err).Msg("failed to snapshot grid stats")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *StatsSnapshotter) snapshot(now time.Time) error {
+	counters, err := s.db.GetCounters(types.StatsFilter{})
+	if err != nil {
+		return errors.Wrap(err, "couldn't get grid counters")
+	}
+	up := db.NodeUp
+	upCounters, err := s.db.GetCounters(types.StatsFilter{Status: &up})
+	if err != nil {
+		return errors.Wrap(err, "couldn't get up nodes counters")
+	}
+	return s.db.InsertStatsSnapshot(db.StatsSnapshot{
+		Timestamp:   now.Unix(),
+		Nodes:       counters.Nodes,
+		UpNodes:     upCounters.Nodes,
+		Farms:       counters.Farms,
+		Countries:   counters.Countries,
+		TotalCru:    counters.TotalCRU,
+		TotalSru:    counters.TotalSRU,
+		TotalMru:    counters.TotalMRU,
+		TotalHru:    counters.TotalHRU,
+		PublicIps:   counters.PublicIPs,
+		AccessNodes: counters.AccessNodes,
+		Gateways:    counters.Gateways,
+		Twins:       counters.Twins,
+		Contracts:   counters.Contracts,
+	})
+}
@@ -0,0 +1,84 @@
+package explorer
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+func TestStatsHistoryParams(t *testing.T) {
+	var a App
+	day := uint64(statsHistoryDefaultInterval.Seconds())
+	month := uint64(statsHistoryDefaultRange.Seconds())
+	tests := []struct {
+		query    string
+		from     uint64
+		to       uint64
+		interval uint64
+		params   []string
+	}{
+		{"to=1700000000", 1700000000 - month, 1700000000, day, nil},
+		{"to=1000", 0, 1000, day, nil},
+		{"to=0", 0, 0, day, nil},
+		{"from=100&to=1000&interval=60", 100, 1000, 60, nil},
+		{"from=0&to=1000&interval=0", 0, 1000, 0, []string{"interval"}},
+		{"from=1001&to=1000", 1001, 1000, day, []string{"from"}},
+		{"from=1001&to=1000&interval=0&strict=true&status=up", 1001, 1000, 0, []string{"from", "interval", "status"}},
+		{"from=100&to=1000&interval=900", 100, 1000, 900, nil},
+		{"from=100&to=1000&interval=901", 100, 1000, 901, []string{"interval"}},
+		// the default interval is kept for the ranges shorter than it
+		{"from=100&to=1000", 100, 1000, day, nil},
+		{"from=9223372036854775808&to=18446744073709551615", 9223372036854775808, 18446744073709551615, day, []string{"from", "to"}},
+		{"from=0&to=9223372036854775807&interval=9223372036854775807", 0, 9223372036854775807, 9223372036854775807, nil},
+		{"from=0&to=9223372036854775808&interval=9223372036854775808", 0, 9223372036854775808, 9223372036854775808, []string{"interval", "to"}},
+		{"from=5&to=5&interval=18446744073709551615", 5, 5, 18446744073709551615, []string{"interval"}},
+	}
+	for _, test := range tests {
+		from, to, interval, err := a.handleStatsHistoryRequestsQueryParams(httptest.NewRequest("GET", "/stats/history?"+test.query, nil))
+		if params := invalidParams(t, err); !reflect.DeepEqual(params, test.params) {
+			t.Fatalf("%s: invalid params mismatch: expected: %v, found: %v", test.query, test.params, params)
+		}
+		if from != test.from || to != test.to || interval != test.interval {
+			t.Fatalf("%s: range mismatch: expected: [%d, %d) by %d, found: [%d, %d) by %d", test.query, test.from, test.to, test.interval, from, to, interval)
+		}
+	}
+
+	now := uint64(time.Now().Unix())
+	from, to, interval, err := a.handleStatsHistoryRequestsQueryParams(httptest.NewRequest("GET", "/stats/history", nil))
+	if err != nil || to < now || to > now+60 || from != to-month || interval != day {
+		t.Fatalf("default range mismatch: [%d, %d) by %d, %v", from, to, interval, err)
+	}
+}
+
+func TestStatsSnapshot(t *testing.T) {
+	database := &fakeDatabase{counters: map[string]types.Counters{
+		"":        {Nodes: 10, Farms: 3, Countries: 2, TotalCRU: 80, TotalSRU: 1, TotalMRU: 2, TotalHRU: 3, PublicIPs: 5, AccessNodes: 4, Gateways: 1, Twins: 20, Contracts: 30},
+		db.NodeUp: {Nodes: 7},
+	}}
+	now := time.Unix(1700000000, 0)
+	if err := NewStatsSnapshotter(database, time.Hour).snapshot(now); err != nil {
+		t.Fatalf("failed to snapshot: %s", err.Error())
+	}
+	expected := db.StatsSnapshot{
+		Timestamp: 1700000000, Nodes: 10, UpNodes: 7, Farms: 3, Countries: 2, TotalCru: 80, TotalSru: 1, TotalMru: 2, TotalHru: 3,
+		PublicIps: 5, AccessNodes: 4, Gateways: 1, Twins: 20, Contracts: 30,
+	}
+	if len(database.snapshots) != 1 || database.snapshots[0] != expected {
+		t.Fatalf("snapshot mismatch: expected: %+v, found: %+v", expected, database.snapshots)
+	}
+
+	res, resp := testApp(database, nil).getStatsHistory(httptest.NewRequest("GET", "/stats/history?from=100&to=1000&interval=60", nil))
+	if resp != nil {
+		t.Fatalf("failed to get stats history: %v", resp.Err())
+	}
+	if !reflect.DeepEqual(database.historyArgs, []int64{100, 1000, 60}) {
+		t.Fatalf("stats history query mismatch: %v", database.historyArgs)
+	}
+	if snapshots := res.([]types.StatsSnapshot); len(snapshots) != 1 || snapshots[0].UpNodes != 7 || snapshots[0].TotalCRU != 80 || snapshots[0].Timestamp != 1700000000 {
+		t.Fatalf("stats history mismatch: %+v", snapshots)
+	}
+}
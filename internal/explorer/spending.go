@@ -0,0 +1,85 @@
+package explorer
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+// spendingGroupings are the allowed values of the spending group_by parameter
+var spendingGroupings = []string{db.SpendingByDay, db.SpendingByMonth, db.SpendingByContract, db.SpendingByNode}
+
+// twinIDParam returns the twin id path parameter
+func twinIDParam(r *http.Request) (uint64, error) {
+	value := mux.Vars(r)["twin_id"]
+	twinID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, paramError("twin_id", "invalid twin id %s: %s", value, err.Error())
+	}
+	return twinID, nil
+}
+
+// twinSpending sums the bill reports of the twin contracts in the [from, to) range by the given grouping
+func (a *App) twinSpending(twinID uint64, from, to int64, groupBy string) (types.TwinSpending, error) {
+	spending := types.TwinSpending{
+		TwinID:    twinID,
+		From:      from,
+		To:        to,
+		GroupBy:   groupBy,
+		Discounts: []types.SpendingDiscount{},
+		Groups:    []types.SpendingGroup{},
+	}
+	reports, err := a.db.GetTwinSpending(twinID, from, to, groupBy)
+	if err != nil {
+		return spending, errors.Wrap(err, "couldn't get twin bill reports")
+	}
+	// the reports are ordered by the key so the reports of a group are consecutive
+	for idx, report := range reports {
+		if idx == 0 || report.Key != reports[idx-1].Key {
+			spending.Groups = append(spending.Groups, newSpendingGroup(report.Key, groupBy))
+		}
+		group := &spending.Groups[len(spending.Groups)-1]
+		group.AmountBilled += report.AmountBilled
+		group.Reports += report.Reports
+		group.Discounts = addSpendingDiscount(group.Discounts, report)
+		spending.AmountBilled += report.AmountBilled
+		spending.Reports += report.Reports
+		spending.Discounts = addSpendingDiscount(spending.Discounts, report)
+	}
+	return spending, nil
+}
+
+// newSpendingGroup creates an empty group with the key set in the field of the grouping
+func newSpendingGroup(key uint64, groupBy string) types.SpendingGroup {
+	group := types.SpendingGroup{Discounts: []types.SpendingDiscount{}}
+	switch groupBy {
+	case db.SpendingByContract:
+		group.ContractID = &key
+	case db.SpendingByNode:
+		group.NodeID = &key
+	default:
+		timestamp := int64(key)
+		group.Timestamp = &timestamp
+	}
+	return group
+}
+
+// addSpendingDiscount adds the report amount to the discount level of the report
+func addSpendingDiscount(discounts []types.SpendingDiscount, report db.SpendingReport) []types.SpendingDiscount {
+	for idx := range discounts {
+		if discounts[idx].DiscountReceived == report.DiscountReceived {
+			discounts[idx].AmountBilled += report.AmountBilled
+			discounts[idx].Reports += report.Reports
+			return discounts
+		}
+	}
+	return append(discounts, types.SpendingDiscount{
+		DiscountReceived: report.DiscountReceived,
+		AmountBilled:     report.AmountBilled,
+		Reports:          report.Reports,
+	})
+}
@@ -0,0 +1,157 @@
+package explorer
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+// spendingReports are the reports of 3 groups ordered by key, with 2 discount levels
+var spendingReports = []db.SpendingReport{
+	{Key: 10, DiscountReceived: "None", AmountBilled: 100, Reports: 1},
+	{Key: 10, DiscountReceived: "Gold", AmountBilled: 50, Reports: 2},
+	{Key: 20, DiscountReceived: "Gold", AmountBilled: 30, Reports: 3},
+	{Key: 30, DiscountReceived: "None", AmountBilled: 7, Reports: 1},
+	{Key: 30, DiscountReceived: "Gold", AmountBilled: 3, Reports: 1},
+}
+
+func TestTwinSpending(t *testing.T) {
+	database := &fakeDatabase{spending: spendingReports}
+	spending, err := testApp(database, nil).twinSpending(5, 100, 200, db.SpendingByContract)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if args := []interface{}{uint64(5), int64(100), int64(200), db.SpendingByContract}; !reflect.DeepEqual(database.spendingArgs, args) {
+		t.Fatalf("query mismatch: expected: %v, found: %v", args, database.spendingArgs)
+	}
+	if spending.TwinID != 5 || spending.From != 100 || spending.To != 200 || spending.GroupBy != db.SpendingByContract {
+		t.Fatalf("spending range mismatch: %+v", spending)
+	}
+	if spending.AmountBilled != 190 || spending.Reports != 8 {
+		t.Fatalf("total mismatch: expected: 190 in 8 reports, found: %d in %d reports", spending.AmountBilled, spending.Reports)
+	}
+	// the discounts are in the order they're first seen
+	discounts := []types.SpendingDiscount{
+		{DiscountReceived: "None", AmountBilled: 107, Reports: 2},
+		{DiscountReceived: "Gold", AmountBilled: 83, Reports: 6},
+	}
+	if !reflect.DeepEqual(spending.Discounts, discounts) {
+		t.Fatalf("discounts mismatch: expected: %+v, found: %+v", discounts, spending.Discounts)
+	}
+
+	groups := []struct {
+		contractID uint64
+		amount     uint64
+		reports    uint64
+		discounts  []types.SpendingDiscount
+	}{
+		{10, 150, 3, []types.SpendingDiscount{{DiscountReceived: "None", AmountBilled: 100, Reports: 1}, {DiscountReceived: "Gold", AmountBilled: 50, Reports: 2}}},
+		{20, 30, 3, []types.SpendingDiscount{{DiscountReceived: "Gold", AmountBilled: 30, Reports: 3}}},
+		{30, 10, 2, []types.SpendingDiscount{{DiscountReceived: "None", AmountBilled: 7, Reports: 1}, {DiscountReceived: "Gold", AmountBilled: 3, Reports: 1}}},
+	}
+	if len(spending.Groups) != len(groups) {
+		t.Fatalf("groups mismatch: expected: %d groups, found: %+v", len(groups), spending.Groups)
+	}
+	for idx, expected := range groups {
+		group := spending.Groups[idx]
+		if group.ContractID == nil || *group.ContractID != expected.contractID || group.NodeID != nil || group.Timestamp != nil {
+			t.Fatalf("group %d key mismatch: expected contract %d, found: %+v", idx, expected.contractID, group)
+		}
+		if group.AmountBilled != expected.amount || group.Reports != expected.reports {
+			t.Fatalf("group %d total mismatch: expected: %d in %d reports, found: %d in %d reports",
+				idx, expected.amount, expected.reports, group.AmountBilled, group.Reports)
+		}
+		if !reflect.DeepEqual(group.Discounts, expected.discounts) {
+			t.Fatalf("group %d discounts mismatch: expected: %+v, found: %+v", idx, expected.discounts, group.Discounts)
+		}
+	}
+}
+
+func TestTwinSpendingGroupKeys(t *testing.T) {
+	database := &fakeDatabase{spending: spendingReports}
+	for _, groupBy := range spendingGroupings {
+		spending, err := testApp(database, nil).twinSpending(5, 0, 200, groupBy)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", groupBy, err.Error())
+		}
+		var keys []uint64
+		for _, group := range spending.Groups {
+			switch {
+			case group.Timestamp != nil && group.ContractID == nil && group.NodeID == nil:
+				keys = append(keys, uint64(*group.Timestamp))
+			case group.ContractID != nil && group.Timestamp == nil && group.NodeID == nil:
+				keys = append(keys, *group.ContractID)
+			case group.NodeID != nil && group.Timestamp == nil && group.ContractID == nil:
+				keys = append(keys, *group.NodeID)
+			default:
+				t.Fatalf("%s: group should have one key: %+v", groupBy, group)
+			}
+		}
+		if !reflect.DeepEqual(keys, []uint64{10, 20, 30}) {
+			t.Fatalf("%s: keys mismatch: expected: [10 20 30], found: %v", groupBy, keys)
+		}
+		field := map[string]bool{
+			db.SpendingByDay:      spending.Groups[0].Timestamp != nil,
+			db.SpendingByMonth:    spending.Groups[0].Timestamp != nil,
+			db.SpendingByContract: spending.Groups[0].ContractID != nil,
+			db.SpendingByNode:     spending.Groups[0].NodeID != nil,
+		}
+		if !field[groupBy] {
+			t.Fatalf("%s: key set in the wrong field: %+v", groupBy, spending.Groups[0])
+		}
+	}
+}
+
+func TestTwinSpendingNoReports(t *testing.T) {
+	spending, err := testApp(&fakeDatabase{}, nil).twinSpending(5, 0, 200, db.SpendingByMonth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if spending.AmountBilled != 0 || spending.Reports != 0 || spending.Groups == nil || len(spending.Groups) != 0 ||
+		spending.Discounts == nil || len(spending.Discounts) != 0 {
+		t.Fatalf("spending should be empty with empty lists: %+v", spending)
+	}
+}
+
+func TestGetTwinSpending(t *testing.T) {
+	database := &fakeDatabase{twins: []types.Twin{{TwinID: 5}}, spending: spendingReports}
+	tests := []struct {
+		name   string
+		twinID string
+		query  string
+		status int
+	}{
+		{"spending", "5", "group_by=contract&from=100&to=200", 200},
+		{"default grouping", "5", "", 200},
+		{"missing twin", "6", "", 404},
+		{"invalid twin", "five", "", 400},
+		{"invalid grouping", "5", "group_by=year", 400},
+		{"empty range", "5", "from=200&to=100", 400},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/twins/"+test.twinID+"/spending?"+test.query, nil)
+		r = mux.SetURLVars(r, map[string]string{"twin_id": test.twinID})
+		result, resp := testApp(database, nil).getTwinSpending(r)
+		if resp.Status() != test.status {
+			t.Fatalf("%s: status mismatch: expected: %d, found: %d", test.name, test.status, resp.Status())
+		}
+		if test.status == 404 && !types.IsErrorCode(resp.Err(), types.ErrCodeNotFound) {
+			t.Fatalf("%s: error mismatch: expected a not found error, found: %v", test.name, resp.Err())
+		}
+		if test.status != 200 {
+			continue
+		}
+		spending := result.(types.TwinSpending)
+		if spending.TwinID != 5 || spending.AmountBilled != 190 {
+			t.Fatalf("%s: spending mismatch: %+v", test.name, spending)
+		}
+	}
+	// the failed requests don't query the spending so the last query is of the default grouping
+	if groupBy := database.spendingArgs[3]; groupBy != db.SpendingByMonth {
+		t.Fatalf("default grouping mismatch: expected: %s, found: %v", db.SpendingByMonth, groupBy)
+	}
+}
@@ -0,0 +1,81 @@
+package explorer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+const (
+	// statisticsParallelism is the max number of nodes asked for their statistics at the same time
+	statisticsParallelism = 10
+	// statisticsCacheTTL is how long the statistics of a node are served from the cache
+	statisticsCacheTTL = 30 * time.Second
+	// maxStatisticsNodes is the max number of nodes in one statistics request
+	maxStatisticsNodes = 100
+)
+
+// nodeStatistics returns the statistics of the node from the cache, or asks the node for them
+func (a *App) nodeStatistics(ctx context.Context, nodeID, twinID uint32) (types.NodeStatistics, error) {
+	key := fmt.Sprintf("statistics-%d", nodeID)
+	if cached, ok := a.lruCache.Get(key); ok {
+		return cached.(types.NodeStatistics), nil
+	}
+
+	var res types.NodeStatistics
+	if err := a.callNode(ctx, twinID, "zos.statistics.get", nil, &res); err != nil {
+		return res, err
+	}
+	a.lruCache.Set(key, res, statisticsCacheTTL)
+	return res, nil
+}
+
+// nodesStatistics asks the nodes for their statistics concurrently, the result of each node
+// has its statistics or the error of getting them
+func (a *App) nodesStatistics(ctx context.Context, nodeIDs []uint32) []types.NodeStatisticsResult {
+	results := make([]types.NodeStatisticsResult, len(nodeIDs))
+	sem := make(chan struct{}, statisticsParallelism)
+	var wg sync.WaitGroup
+	for idx, nodeID := range nodeIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, nodeID uint32) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[idx].NodeID = nodeID
+			node, err := a.db.GetNode(nodeID, "twinId")
+			if errors.Is(err, db.ErrNodeNotFound) {
+				err = ErrNodeNotFound
+			}
+			if err != nil {
+				results[idx].Error = statisticsError(err)
+				return
+			}
+			statistics, err := a.nodeStatistics(ctx, nodeID, uint32(node.TwinID))
+			if err != nil {
+				results[idx].Error = statisticsError(err)
+				return
+			}
+			results[idx].Statistics = &statistics
+		}(idx, nodeID)
+	}
+	wg.Wait()
+	return results
+}
+
+// statisticsError returns the error model of a failure to get the statistics of a node
+func statisticsError(err error) *types.Error {
+	code := types.ErrCodeInternal
+	var typed *types.Error
+	if errors.As(err, &typed) {
+		code = typed.Code
+	}
+	return types.NewError(code, err.Error())
+}
@@ -0,0 +1,68 @@
+package explorer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+func TestNodesStatistics(t *testing.T) {
+	database := &fakeDatabase{nodes: []db.Node{{NodeID: 1, TwinID: 11}, {NodeID: 2, TwinID: 12}}}
+	relay := &fakeRelay{
+		responses: map[uint32]interface{}{11: types.NodeStatistics{Total: types.NodeStatisticsResources{CRU: 8}}},
+		errs:      map[uint32]error{12: errors.New("twin not connected")},
+	}
+	a := testApp(database, relay)
+	results := a.nodesStatistics(context.Background(), []uint32{1, 2, 3})
+	if len(results) != 3 {
+		t.Fatalf("results count mismatch: expected: 3, found: %d", len(results))
+	}
+	if results[0].NodeID != 1 || results[0].Error != nil || results[0].Statistics == nil || results[0].Statistics.Total.CRU != 8 {
+		t.Fatalf("statistics of node 1 mismatch: %+v", results[0])
+	}
+	if results[1].NodeID != 2 || results[1].Statistics != nil || results[1].Error == nil || results[1].Error.Code != types.ErrCodeBadGateway {
+		t.Fatalf("relay error of node 2 mismatch: %+v", results[1])
+	}
+	if results[2].NodeID != 3 || results[2].Statistics != nil || results[2].Error == nil || results[2].Error.Code != types.ErrCodeNodeNotFound {
+		t.Fatalf("missing node 3 error mismatch: %+v", results[2])
+	}
+	if relay.calls != 2 {
+		t.Fatalf("relay calls mismatch: expected: 2, found: %d", relay.calls)
+	}
+
+	results = a.nodesStatistics(context.Background(), []uint32{1, 2})
+	if relay.calls != 3 {
+		t.Fatalf("relay calls mismatch: expected the cached statistics of node 1 and a call to node 2, found: %d calls", relay.calls)
+	}
+	if results[0].Statistics == nil || results[0].Statistics.Total.CRU != 8 || results[1].Error == nil {
+		t.Fatalf("results with cached statistics mismatch: %+v", results)
+	}
+}
+
+func TestNodesStatisticsParallelism(t *testing.T) {
+	database := &fakeDatabase{}
+	relay := &fakeRelay{responses: map[uint32]interface{}{}, delay: 10 * time.Millisecond}
+	var nodeIDs []uint32
+	for nodeID := uint32(1); nodeID <= 3*statisticsParallelism; nodeID++ {
+		database.nodes = append(database.nodes, db.Node{NodeID: int64(nodeID), TwinID: int64(nodeID)})
+		relay.responses[nodeID] = types.NodeStatistics{}
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	a := testApp(database, relay)
+	results := a.nodesStatistics(context.Background(), nodeIDs)
+	for idx, result := range results {
+		if result.NodeID != nodeIDs[idx] || result.Statistics == nil {
+			t.Fatalf("result %d mismatch: %+v", idx, result)
+		}
+	}
+	if relay.calls != len(nodeIDs) {
+		t.Fatalf("relay calls mismatch: expected: %d, found: %d", len(nodeIDs), relay.calls)
+	}
+	if relay.maxParallel > statisticsParallelism {
+		t.Fatalf("parallel calls mismatch: expected at most %d, found: %d", statisticsParallelism, relay.maxParallel)
+	}
+}
@@ -0,0 +1,585 @@
+package explorer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/mw"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+const (
+	// WebhookSecretHeader carries the secret of the webhook to manage it
+	WebhookSecretHeader = "X-Webhook-Secret"
+	// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of the delivery body signed with the webhook secret
+	WebhookSignatureHeader = "X-Webhook-Signature-256"
+	// WebhookEventHeader carries the type of the delivered event
+	WebhookEventHeader = "X-Webhook-Event"
+	// WebhookEventIDHeader carries the id of the delivered event, it's the same for all the attempts
+	WebhookEventIDHeader = "X-Webhook-Event-ID"
+
+	// webhookAttempts is the number of attempts to deliver an event before giving up
+	webhookAttempts = 5
+	// webhookBackoff is the delay before the second attempt, it doubles with every attempt
+	webhookBackoff = 10 * time.Second
+	// webhookTimeout is the timeout of a single delivery attempt
+	webhookTimeout = 10 * time.Second
+	// webhookConcurrency is the number of delivery attempts running at the same time, the deliveries
+	// waiting for a retry don't count
+	webhookConcurrency = 32
+	// webhookQueueSize is the number of deliveries queued or in progress, the deliveries of the events
+	// dispatched while the queue is full are dropped
+	webhookQueueSize = 4096
+	// webhookSubscriptionsTTL is how long the subscriptions are cached before they're reloaded
+	webhookSubscriptionsTTL = 10 * time.Second
+	// webhookSecretSize is the number of random bytes in a webhook secret
+	webhookSecretSize = 32
+
+	// contractStateDeleted is the state of the contracts that left the active contracts between polls
+	contractStateDeleted = "Deleted"
+)
+
+// webhookBlockedNets are the special purpose networks the webhooks can't be delivered to on top of
+// the loopback, private, link-local, multicast and unspecified addresses
+var webhookBlockedNets = parseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+)
+
+// webhookStore is the part of the database used by the webhook dispatcher
+type webhookStore interface {
+	GetActiveContractStates() ([]db.ContractState, error)
+	GetReservedPublicIPs() ([]db.PublicIP, error)
+	GetWebhookSubscriptions() ([]db.WebhookSubscription, error)
+	InsertWebhookDelivery(delivery db.WebhookDelivery) error
+}
+
+// webhook is a subscription with its decoded filter
+type webhook struct {
+	db.WebhookSubscription
+	filter types.WebhookFilter
+}
+
+// webhookSubject is what the webhook filters are matched against
+type webhookSubject struct {
+	farmID uint
+	nodeID uint
+	twinID uint
+	// nodeStatus is only set for the node events
+	nodeStatus string
+	// contractState is only set for the contract events
+	contractState string
+}
+
+// WebhookDispatcher delivers the node events and the contract state and public ip changes
+// detected between database polls to the matching webhooks
+type WebhookDispatcher struct {
+	db         webhookStore
+	nodeEvents *NodeEvents
+	interval   time.Duration
+	client     *http.Client
+	attempts   int
+	backoff    time.Duration
+	// allowedNets are the networks the deliveries are sent to even if they're not public, it's only set by the tests
+	allowedNets []*net.IPNet
+
+	// the fields below are only used by the Run goroutine
+	// contracts are the contracts that aren't deleted and publicIPs are the reserved ones
+	contracts     map[uint]db.ContractState
+	publicIPs     map[string]db.PublicIP
+	webhooks      []webhook
+	webhooksAt    time.Time
+	lastID        uint64
+	lastNodeEvent *uint64
+
+	queue chan struct{}
+	sem   chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher polling the database every interval,
+// the node events are not delivered if nodeEvents is nil
+func NewWebhookDispatcher(database db.Database, nodeEvents *NodeEvents, interval time.Duration) *WebhookDispatcher {
+	return newWebhookDispatcher(database, nodeEvents, interval)
+}
+
+func newWebhookDispatcher(store webhookStore, nodeEvents *NodeEvents, interval time.Duration) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		db:         store,
+		nodeEvents: nodeEvents,
+		interval:   interval,
+		attempts:   webhookAttempts,
+		backoff:    webhookBackoff,
+		lastID:     uint64(time.Now().UnixNano()),
+		queue:      make(chan struct{}, webhookQueueSize),
+		sem:        make(chan struct{}, webhookConcurrency),
+	}
+	// the address is checked when connecting, after the hostname is resolved, so a hostname
+	// resolving to a public address when the webhook is created can't be pointed to the internal network later
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: d.dialControl}
+	d.client = &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+	return d
+}
+
+// dialControl rejects the connections of the deliveries to the addresses that aren't public
+func (d *WebhookDispatcher) dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrapf(err, "invalid address %s", address)
+	}
+	if ip := net.ParseIP(host); ip == nil || !webhookIPAllowed(ip, d.allowedNets) {
+		return errors.Errorf("delivery to %s isn't allowed, it's not a public address", host)
+	}
+	return nil
+}
+
+// Run dispatches the events until the context is canceled and waits for the running deliveries,
+// the first poll only records the state of the contracts and public ips
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	defer d.wg.Wait()
+	events, unsubscribe := d.subscribe(ctx)
+	defer func() { unsubscribe() }()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	if err := d.poll(ctx, time.Now()); err != nil {
+		log.Error().Err(err).Msg("failed to detect contract and public ip changes")
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.poll(ctx, time.Now()); err != nil {
+				log.Error().Err(err).Msg("failed to detect contract and public ip changes")
+			}
+		case event, ok := <-events:
+			if !ok {
+				// dropped for being slow, resume from the last event
+				unsubscribe()
+				events, unsubscribe = d.subscribe(ctx)
+				continue
+			}
+			d.dispatchNodeEvent(ctx, event)
+		}
+	}
+}
+
+// subscribe subscribes to the node events and dispatches the buffered ones missed since the last event
+func (d *WebhookDispatcher) subscribe(ctx context.Context) (<-chan types.NodeEvent, func()) {
+	if d.nodeEvents == nil {
+		return nil, func() {}
+	}
+	backlog, events, unsubscribe := d.nodeEvents.subscribe(d.lastNodeEvent)
+	for _, event := range backlog {
+		d.dispatchNodeEvent(ctx, event)
+	}
+	return events, unsubscribe
+}
+
+func (d *WebhookDispatcher) dispatchNodeEvent(ctx context.Context, event types.NodeEvent) {
+	id := event.ID
+	d.lastNodeEvent = &id
+	d.dispatch(ctx, event.Type, event.Timestamp, event, webhookSubject{
+		farmID:     uint(event.FarmID),
+		nodeID:     uint(event.NodeID),
+		twinID:     uint(event.TwinID),
+		nodeStatus: event.Current.Status,
+	})
+}
+
+// poll detects the contract state changes and the freed public ips since the last poll, only the contracts
+// that aren't deleted and the reserved public ips are loaded so a contract missing from the poll is deleted
+// and a public ip missing from it is freed
+func (d *WebhookDispatcher) poll(ctx context.Context, now time.Time) error {
+	states, err := d.db.GetActiveContractStates()
+	if err != nil {
+		return errors.Wrap(err, "couldn't get contract states")
+	}
+	ips, err := d.db.GetReservedPublicIPs()
+	if err != nil {
+		return errors.Wrap(err, "couldn't get public ips")
+	}
+	baseline := d.contracts == nil
+
+	contracts := make(map[uint]db.ContractState, len(states))
+	for _, contract := range states {
+		contracts[contract.ContractID] = contract
+		previous := d.contracts[contract.ContractID]
+		if baseline || previous.State == contract.State {
+			continue
+		}
+		d.dispatchContractState(ctx, now, previous.State, contract)
+	}
+	for _, contractID := range sortedContractIDs(d.contracts) {
+		if _, ok := contracts[contractID]; ok {
+			continue
+		}
+		deleted := d.contracts[contractID]
+		deleted.State = contractStateDeleted
+		d.dispatchContractState(ctx, now, d.contracts[contractID].State, deleted)
+	}
+
+	publicIPs := make(map[string]db.PublicIP, len(ips))
+	for _, ip := range ips {
+		publicIPs[ip.ID] = ip
+	}
+	for _, id := range sortedPublicIPIDs(d.publicIPs) {
+		if _, ok := publicIPs[id]; ok {
+			continue
+		}
+		previous := d.publicIPs[id]
+		d.dispatch(ctx, types.PublicIPEventFreed, now.Unix(), types.PublicIPEvent{
+			IP:                 previous.IP,
+			FarmID:             previous.FarmID,
+			PreviousContractID: previous.ContractID,
+		}, webhookSubject{farmID: previous.FarmID})
+	}
+
+	d.contracts = contracts
+	d.publicIPs = publicIPs
+	return nil
+}
+
+func (d *WebhookDispatcher) dispatchContractState(ctx context.Context, now time.Time, previousState string, contract db.ContractState) {
+	d.dispatch(ctx, types.ContractEventState, now.Unix(), types.ContractEvent{
+		ContractID:    contract.ContractID,
+		ContractType:  contract.Type,
+		TwinID:        contract.TwinID,
+		NodeID:        contract.NodeID,
+		FarmID:        contract.FarmID,
+		PreviousState: previousState,
+		State:         contract.State,
+	}, webhookSubject{
+		farmID:        contract.FarmID,
+		nodeID:        contract.NodeID,
+		twinID:        contract.TwinID,
+		contractState: contract.State,
+	})
+}
+
+// sortedContractIDs returns the ids of the contracts in order to dispatch their events in order
+func sortedContractIDs(contracts map[uint]db.ContractState) []uint {
+	ids := make([]uint, 0, len(contracts))
+	for id := range contracts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// sortedPublicIPIDs returns the ids of the public ips in order to dispatch their events in order
+func sortedPublicIPIDs(ips map[string]db.PublicIP) []string {
+	ids := make([]string, 0, len(ips))
+	for id := range ips {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// dispatch queues the deliveries of the event to the matching webhooks without waiting for them
+func (d *WebhookDispatcher) dispatch(ctx context.Context, eventType string, timestamp int64, data interface{}, subject webhookSubject) {
+	webhooks, err := d.subscriptions()
+	if err != nil {
+		log.Error().Err(err).Str("event_type", eventType).Msg("failed to get webhook subscriptions")
+		return
+	}
+	d.lastID++
+	event := types.WebhookEvent{
+		ID:        fmt.Sprint(d.lastID),
+		Type:      eventType,
+		Timestamp: timestamp,
+		Data:      data,
+	}
+	var body []byte
+	for _, hook := range webhooks {
+		if !webhookMatches(hook.filter, eventType, subject) {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(event); err != nil {
+				log.Error().Err(err).Str("event_type", eventType).Msg("failed to encode webhook event")
+				return
+			}
+		}
+		select {
+		case d.queue <- struct{}{}:
+		default:
+			log.Warn().Uint64("webhook_id", hook.ID).Str("event_id", event.ID).Msg("webhook delivery queue is full, dropping the delivery")
+			continue
+		}
+		d.wg.Add(1)
+		go func(subscription db.WebhookSubscription) {
+			defer d.wg.Done()
+			defer func() { <-d.queue }()
+			d.deliver(ctx, subscription, event, body)
+		}(hook.WebhookSubscription)
+	}
+}
+
+// subscriptions returns the webhooks from the cache, or the database if they're older than webhookSubscriptionsTTL
+func (d *WebhookDispatcher) subscriptions() ([]webhook, error) {
+	if d.webhooks != nil && time.Since(d.webhooksAt) < webhookSubscriptionsTTL {
+		return d.webhooks, nil
+	}
+	subscriptions, err := d.db.GetWebhookSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]webhook, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		var filter types.WebhookFilter
+		if err := json.Unmarshal([]byte(subscription.Filter), &filter); err != nil {
+			log.Error().Err(err).Uint64("webhook_id", subscription.ID).Msg("failed to decode webhook filter")
+			continue
+		}
+		webhooks = append(webhooks, webhook{WebhookSubscription: subscription, filter: filter})
+	}
+	d.webhooks, d.webhooksAt = webhooks, time.Now()
+	return webhooks, nil
+}
+
+// deliver posts the event to the webhook until it succeeds or the attempts run out, every attempt is
+// recorded in the delivery log
+func (d *WebhookDispatcher) deliver(ctx context.Context, subscription db.WebhookSubscription, event types.WebhookEvent, body []byte) {
+	backoff := d.backoff
+	for attempt := 1; attempt <= d.attempts; attempt++ {
+		select {
+		case d.sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		statusCode, err := d.post(ctx, subscription, event, body)
+		<-d.sem
+		delivery := db.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Attempt:        attempt,
+			StatusCode:     statusCode,
+			Success:        err == nil,
+			Timestamp:      time.Now().Unix(),
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		if err := d.db.InsertWebhookDelivery(delivery); err != nil {
+			log.Error().Err(err).Uint64("webhook_id", subscription.ID).Msg("failed to record webhook delivery")
+		}
+		if err == nil || attempt == d.attempts {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends a single delivery attempt, a response with a status other than 2xx is a failure
+func (d *WebhookDispatcher) post(ctx context.Context, subscription db.WebhookSubscription, event types.WebhookEvent, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "couldn't create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event.Type)
+	req.Header.Set(WebhookEventIDHeader, event.ID)
+	req.Header.Set(WebhookSignatureHeader, signWebhookBody(subscription.Secret, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhookBody returns the signature header value of the body
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookMatches checks if the event satisfies the filter
+func webhookMatches(filter types.WebhookFilter, eventType string, subject webhookSubject) bool {
+	if len(filter.Types) != 0 && !isOneOf(eventType, filter.Types) {
+		return false
+	}
+	if filter.FarmID != nil && *filter.FarmID != uint64(subject.farmID) {
+		return false
+	}
+	if len(filter.NodeIDs) != 0 && !isIn(filter.NodeIDs, uint64(subject.nodeID)) {
+		return false
+	}
+	if filter.TwinID != nil && *filter.TwinID != uint64(subject.twinID) {
+		return false
+	}
+	if filter.NodeStatus != nil && !strings.EqualFold(*filter.NodeStatus, subject.nodeStatus) {
+		return false
+	}
+	if filter.ContractState != nil && !strings.EqualFold(*filter.ContractState, subject.contractState) {
+		return false
+	}
+	return true
+}
+
+// webhookIDParam returns the webhook id path parameter
+func webhookIDParam(r *http.Request) (uint64, error) {
+	value := mux.Vars(r)["webhook_id"]
+	webhookID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, paramError("webhook_id", "invalid webhook id %s: %s", value, err.Error())
+	}
+	return webhookID, nil
+}
+
+// authorizedWebhook returns the webhook of the request if the request has its secret.
+// a wrong secret is reported as not found to not reveal the existing webhooks
+func (a *App) authorizedWebhook(r *http.Request) (db.WebhookSubscription, error) {
+	webhookID, err := webhookIDParam(r)
+	if err != nil {
+		return db.WebhookSubscription{}, err
+	}
+	subscription, err := a.db.GetWebhookSubscription(webhookID)
+	if err != nil {
+		return subscription, err
+	}
+	secret := r.Header.Get(WebhookSecretHeader)
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(subscription.Secret)) != 1 {
+		return db.WebhookSubscription{}, db.ErrWebhookNotFound
+	}
+	return subscription, nil
+}
+
+// webhookReply maps the webhook errors to responses
+func webhookReply(r *http.Request, err error) mw.Response {
+	if errors.Is(err, db.ErrWebhookNotFound) {
+		return mw.NotFound(types.NewError(types.ErrCodeNotFound, fmt.Sprintf("webhook %s not found", mux.Vars(r)["webhook_id"])))
+	}
+	return errorReply(err)
+}
+
+// handleWebhookRequestBody returns the webhook subscription in the request body after validating its url and filter
+func handleWebhookRequestBody(r *http.Request) (types.WebhookSubscription, error) {
+	var subscription types.WebhookSubscription
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		return subscription, errors.Wrapf(ErrBadRequest, "couldn't decode webhook: %s", err.Error())
+	}
+	var errs paramErrors
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		errs.add(paramError("url", "invalid url %s, must be an absolute http or https url", subscription.URL))
+	} else if err := webhookHostAllowed(r.Context(), u.Hostname()); err != nil {
+		errs.add(paramError("url", "invalid url %s: %s", subscription.URL, err.Error()))
+	}
+	filter := subscription.Filter
+	for _, eventType := range filter.Types {
+		if !isOneOf(eventType, types.WebhookEventTypes) {
+			errs.add(paramError("filter.types", "invalid type %s, must be one of: %s", eventType, strings.Join(types.WebhookEventTypes, ", ")))
+		}
+	}
+	if filter.NodeStatus != nil && !isOneOf(*filter.NodeStatus, nodeStatuses) {
+		errs.add(paramError("filter.nodeStatus", "invalid node status %s, must be one of: %s", *filter.NodeStatus, strings.Join(nodeStatuses, ", ")))
+	}
+	if filter.ContractState != nil && !isOneOf(*filter.ContractState, contractStates) {
+		errs.add(paramError("filter.contractState", "invalid contract state %s, must be one of: %s", *filter.ContractState, strings.Join(contractStates, ", ")))
+	}
+	return subscription, errs.err()
+}
+
+// webhookHostAllowed checks that the webhook host is a public address, or a hostname resolving to public addresses only
+func webhookHostAllowed(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !webhookIPAllowed(ip, nil) {
+			return errors.Errorf("%s isn't a public address", host)
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.Errorf("couldn't resolve %s", host)
+	}
+	for _, addr := range addrs {
+		if !webhookIPAllowed(addr.IP, nil) {
+			return errors.Errorf("%s resolves to %s that isn't a public address", host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// webhookIPAllowed checks if the webhooks can be delivered to the ip, it must be a public unicast address or in the allowed networks
+func webhookIPAllowed(ip net.IP, allowed []*net.IPNet) bool {
+	for _, n := range allowed {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, n := range webhookBlockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCIDRs parses the networks, it panics on invalid ones
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for idx, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[idx] = n
+	}
+	return nets
+}
+
+// newWebhookSecret generates a random hex encoded webhook secret
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "couldn't generate webhook secret")
+	}
+	return hex.EncodeToString(secret), nil
+}
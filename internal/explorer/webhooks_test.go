@@ -0,0 +1,388 @@
+package explorer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+	"github.com/threefoldtech/grid_proxy_server/pkg/types"
+)
+
+type fakeWebhookStore struct {
+	mu sync.Mutex
+	// states are the states of the active contracts and ips are the reserved public ips
+	states        []db.ContractState
+	ips           []db.PublicIP
+	subscriptions []db.WebhookSubscription
+	deliveries    []db.WebhookDelivery
+}
+
+func (s *fakeWebhookStore) GetActiveContractStates() ([]db.ContractState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]db.ContractState(nil), s.states...), nil
+}
+
+func (s *fakeWebhookStore) GetReservedPublicIPs() ([]db.PublicIP, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]db.PublicIP(nil), s.ips...), nil
+}
+
+func (s *fakeWebhookStore) GetWebhookSubscriptions() ([]db.WebhookSubscription, error) {
+	return s.subscriptions, nil
+}
+
+func (s *fakeWebhookStore) InsertWebhookDelivery(delivery db.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, delivery)
+	return nil
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver records the received deliveries and responds with the statuses in order, then with 200
+func webhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []receivedWebhook) {
+	var mu sync.Mutex
+	var received []receivedWebhook
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read delivery body: %s", err.Error())
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		status := http.StatusOK
+		if len(received) < len(statuses) {
+			status = statuses[len(received)]
+		}
+		received = append(received, receivedWebhook{header: r.Header, body: body})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []receivedWebhook {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedWebhook(nil), received...)
+	}
+}
+
+func testWebhookDispatcher(store *fakeWebhookStore) *WebhookDispatcher {
+	d := newWebhookDispatcher(store, nil, time.Hour)
+	// the receivers of the tests listen on the loopback interface
+	d.allowedNets = parseCIDRs("127.0.0.0/8", "::1/128")
+	d.attempts = 3
+	d.backoff = time.Millisecond
+	return d
+}
+
+func webhookFilter(t *testing.T, filter types.WebhookFilter) string {
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		t.Fatalf("failed to encode filter: %s", err.Error())
+	}
+	return string(encoded)
+}
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	server, received := webhookReceiver(t, http.StatusInternalServerError)
+	store := &fakeWebhookStore{
+		subscriptions: []db.WebhookSubscription{{ID: 1, URL: server.URL, Secret: "secret", Filter: "{}"}},
+	}
+	d := testWebhookDispatcher(store)
+	event := types.NodeEvent{ID: 10, Type: types.NodeEventStatus, NodeID: 1, FarmID: 42, Current: types.NodeState{Status: "down"}}
+	d.dispatchNodeEvent(context.Background(), event)
+	d.wg.Wait()
+
+	deliveries := received()
+	if len(deliveries) != 2 {
+		t.Fatalf("deliveries count mismatch: expected: 2, found: %d", len(deliveries))
+	}
+	for _, delivery := range deliveries {
+		if signature := delivery.header.Get(WebhookSignatureHeader); signature != signWebhookBody("secret", delivery.body) {
+			t.Fatalf("invalid signature %s of body %s", signature, delivery.body)
+		}
+		if delivery.header.Get(WebhookEventHeader) != types.NodeEventStatus {
+			t.Fatalf("invalid event header: %+v", delivery.header)
+		}
+	}
+	if deliveries[0].header.Get(WebhookEventIDHeader) != deliveries[1].header.Get(WebhookEventIDHeader) {
+		t.Fatalf("event id changed between attempts: %+v, %+v", deliveries[0].header, deliveries[1].header)
+	}
+	var body struct {
+		types.WebhookEvent
+		Data types.NodeEvent `json:"data"`
+	}
+	if err := json.Unmarshal(deliveries[1].body, &body); err != nil {
+		t.Fatalf("failed to decode delivery body: %s", err.Error())
+	}
+	if body.Type != types.NodeEventStatus || body.Data.NodeID != 1 || body.Data.Current.Status != "down" {
+		t.Fatalf("delivered event mismatch: %s", deliveries[1].body)
+	}
+
+	if len(store.deliveries) != 2 {
+		t.Fatalf("delivery log mismatch: %+v", store.deliveries)
+	}
+	failed, succeeded := store.deliveries[0], store.deliveries[1]
+	if failed.Attempt != 1 || failed.Success || failed.StatusCode != http.StatusInternalServerError || failed.Error == "" {
+		t.Fatalf("failed attempt log mismatch: %+v", failed)
+	}
+	if succeeded.Attempt != 2 || !succeeded.Success || succeeded.StatusCode != http.StatusOK || succeeded.EventID != failed.EventID {
+		t.Fatalf("successful attempt log mismatch: %+v", succeeded)
+	}
+}
+
+func TestWebhookDeliveryGivesUp(t *testing.T) {
+	server, received := webhookReceiver(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	store := &fakeWebhookStore{
+		subscriptions: []db.WebhookSubscription{{ID: 1, URL: server.URL, Secret: "secret", Filter: "{}"}},
+	}
+	d := testWebhookDispatcher(store)
+	d.dispatchNodeEvent(context.Background(), types.NodeEvent{ID: 1, Type: types.NodeEventRent})
+	d.wg.Wait()
+	if len(received()) != d.attempts || len(store.deliveries) != d.attempts {
+		t.Fatalf("attempts mismatch: expected: %d, received: %d, logged: %d", d.attempts, len(received()), len(store.deliveries))
+	}
+	for _, delivery := range store.deliveries {
+		if delivery.Success {
+			t.Fatalf("failed attempt logged as successful: %+v", delivery)
+		}
+	}
+}
+
+func TestWebhookPoll(t *testing.T) {
+	server, received := webhookReceiver(t)
+	gracePeriod := "GracePeriod"
+	farmID, twinID := uint64(42), uint64(7)
+	store := &fakeWebhookStore{
+		states: []db.ContractState{
+			{ContractID: 1, Type: "node", TwinID: 7, NodeID: 1, FarmID: 42, State: "Created"},
+			{ContractID: 2, Type: "node", TwinID: 8, NodeID: 1, FarmID: 42, State: "Created"},
+		},
+		ips: []db.PublicIP{{ID: "ip-1", IP: "185.0.0.1/24", FarmID: 42, ContractID: 1}},
+		subscriptions: []db.WebhookSubscription{
+			{ID: 1, URL: server.URL, Filter: webhookFilter(t, types.WebhookFilter{TwinID: &twinID, ContractState: &gracePeriod})},
+			{ID: 2, URL: server.URL, Filter: webhookFilter(t, types.WebhookFilter{Types: []string{types.PublicIPEventFreed}, FarmID: &farmID})},
+		},
+	}
+	d := testWebhookDispatcher(store)
+	if err := d.poll(context.Background(), time.Now()); err != nil {
+		t.Fatalf("failed to poll: %s", err.Error())
+	}
+	d.wg.Wait()
+	if len(received()) != 0 {
+		t.Fatalf("events delivered for the first poll: %d", len(received()))
+	}
+
+	store.states[0].State = gracePeriod
+	store.states[1].State = gracePeriod
+	// the freed public ip isn't reserved anymore
+	store.ips = nil
+	if err := d.poll(context.Background(), time.Now()); err != nil {
+		t.Fatalf("failed to poll: %s", err.Error())
+	}
+	d.wg.Wait()
+
+	deliveries := map[string]receivedWebhook{}
+	for _, delivery := range received() {
+		deliveries[delivery.header.Get(WebhookEventHeader)] = delivery
+	}
+	if len(received()) != 2 || len(deliveries) != 2 {
+		t.Fatalf("deliveries mismatch: expected a contract and a public ip event, found: %+v", deliveries)
+	}
+	var contract struct {
+		Data types.ContractEvent `json:"data"`
+	}
+	if err := json.Unmarshal(deliveries[types.ContractEventState].body, &contract); err != nil {
+		t.Fatalf("failed to decode contract event: %s", err.Error())
+	}
+	if contract.Data.ContractID != 1 || contract.Data.PreviousState != "Created" || contract.Data.State != gracePeriod {
+		t.Fatalf("contract event mismatch: %+v", contract.Data)
+	}
+	var ip struct {
+		Data types.PublicIPEvent `json:"data"`
+	}
+	if err := json.Unmarshal(deliveries[types.PublicIPEventFreed].body, &ip); err != nil {
+		t.Fatalf("failed to decode public ip event: %s", err.Error())
+	}
+	if ip.Data.IP != "185.0.0.1/24" || ip.Data.PreviousContractID != 1 || ip.Data.ContractID != 0 {
+		t.Fatalf("public ip event mismatch: %+v", ip.Data)
+	}
+	if len(d.publicIPs) != 0 {
+		t.Fatalf("freed public ips should be forgotten: %+v", d.publicIPs)
+	}
+}
+
+func TestWebhookPollDeletedContracts(t *testing.T) {
+	server, received := webhookReceiver(t)
+	store := &fakeWebhookStore{
+		states: []db.ContractState{
+			{ContractID: 1, Type: "node", TwinID: 7, NodeID: 1, FarmID: 42, State: "GracePeriod"},
+			{ContractID: 2, Type: "name", TwinID: 7, State: "Created"},
+		},
+		subscriptions: []db.WebhookSubscription{{ID: 1, URL: server.URL, Filter: "{}"}},
+	}
+	d := testWebhookDispatcher(store)
+	if err := d.poll(context.Background(), time.Now()); err != nil {
+		t.Fatalf("failed to poll: %s", err.Error())
+	}
+	// the deleted contracts aren't active anymore
+	store.states = store.states[1:]
+	if err := d.poll(context.Background(), time.Now()); err != nil {
+		t.Fatalf("failed to poll: %s", err.Error())
+	}
+	d.wg.Wait()
+	if len(received()) != 1 {
+		t.Fatalf("deliveries mismatch: expected the deleted contract event, found: %d", len(received()))
+	}
+	var contract struct {
+		Data types.ContractEvent `json:"data"`
+	}
+	if err := json.Unmarshal(received()[0].body, &contract); err != nil {
+		t.Fatalf("failed to decode contract event: %s", err.Error())
+	}
+	if contract.Data.ContractID != 1 || contract.Data.FarmID != 42 || contract.Data.PreviousState != "GracePeriod" || contract.Data.State != "Deleted" {
+		t.Fatalf("contract event mismatch: %+v", contract.Data)
+	}
+	if _, ok := d.contracts[1]; ok || len(d.contracts) != 1 {
+		t.Fatalf("deleted contracts should be forgotten: %+v", d.contracts)
+	}
+}
+
+func TestWebhookDispatchDoesntWait(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		received++
+	}))
+	t.Cleanup(server.Close)
+	store := &fakeWebhookStore{
+		subscriptions: []db.WebhookSubscription{{ID: 1, URL: server.URL, Filter: "{}"}},
+	}
+	d := testWebhookDispatcher(store)
+	d.sem = make(chan struct{}, 1)
+	d.queue = make(chan struct{}, 2)
+
+	// the deliveries wait for the receiver in the background and the ones after the full queue are dropped
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		for id := uint64(1); id <= 3; id++ {
+			d.dispatchNodeEvent(context.Background(), types.NodeEvent{ID: id, Type: types.NodeEventStatus})
+		}
+	}()
+	select {
+	case <-dispatched:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("dispatch waited for the deliveries")
+	}
+	close(release)
+	d.wg.Wait()
+	if received != 2 || len(store.deliveries) != 2 {
+		t.Fatalf("deliveries mismatch: expected: 2, received: %d, logged: %d", received, len(store.deliveries))
+	}
+}
+
+func TestWebhookMatches(t *testing.T) {
+	farmID, twinID := uint64(42), uint64(7)
+	down, gracePeriod := "down", "gracePeriod"
+	nodeDown := webhookSubject{farmID: 42, nodeID: 1, twinID: 3, nodeStatus: "down"}
+	contract := webhookSubject{farmID: 42, nodeID: 1, twinID: 7, contractState: "GracePeriod"}
+	tests := []struct {
+		name      string
+		filter    types.WebhookFilter
+		eventType string
+		subject   webhookSubject
+		matches   bool
+	}{
+		{"empty filter", types.WebhookFilter{}, types.NodeEventStatus, nodeDown, true},
+		{"node down in farm", types.WebhookFilter{Types: []string{types.NodeEventStatus}, FarmID: &farmID, NodeStatus: &down}, types.NodeEventStatus, nodeDown, true},
+		{"other type", types.WebhookFilter{Types: []string{types.NodeEventRent}}, types.NodeEventStatus, nodeDown, false},
+		{"other node", types.WebhookFilter{NodeIDs: []uint64{2, 3}}, types.NodeEventStatus, nodeDown, false},
+		{"node status of contract event", types.WebhookFilter{NodeStatus: &down}, types.ContractEventState, contract, false},
+		{"contract grace period of twin", types.WebhookFilter{TwinID: &twinID, ContractState: &gracePeriod}, types.ContractEventState, contract, true},
+		{"contract of other twin", types.WebhookFilter{TwinID: &twinID}, types.ContractEventState, webhookSubject{twinID: 8}, false},
+		{"public ip of farm", types.WebhookFilter{FarmID: &farmID}, types.PublicIPEventFreed, webhookSubject{farmID: 42}, true},
+		{"public ip of node", types.WebhookFilter{NodeIDs: []uint64{1}}, types.PublicIPEventFreed, webhookSubject{farmID: 42}, false},
+	}
+	for _, test := range tests {
+		if matches := webhookMatches(test.filter, test.eventType, test.subject); matches != test.matches {
+			t.Fatalf("%s: match mismatch: expected: %t, found: %t", test.name, test.matches, matches)
+		}
+	}
+}
+
+func TestWebhookURLValidation(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"http://93.184.216.34/hook", true},
+		{"https://93.184.216.34:8443/hook?key=value", true},
+		{"https://[2606:4700:4700::1111]/hook", true},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://127.1.2.3/hook", false},
+		{"http://localhost:8080/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://[::ffff:127.0.0.1]/hook", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://10.0.0.5/hook", false},
+		{"http://172.16.0.1/hook", false},
+		{"http://192.168.1.1/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://100.64.0.1/hook", false},
+		{"http://[fd00::1]/hook", false},
+		{"http://[fe80::1]/hook", false},
+		{"http://[64:ff9b::a00:1]/hook", false},
+		{"http://224.0.0.1/hook", false},
+		{"ftp://93.184.216.34/hook", false},
+		{"/hook", false},
+	}
+	for _, test := range tests {
+		body := strings.NewReader(`{"url": "` + test.url + `"}`)
+		_, err := handleWebhookRequestBody(httptest.NewRequest("POST", "/webhooks", body))
+		if test.valid && err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.url, err.Error())
+		}
+		if !test.valid && (!types.IsErrorCode(err, types.ErrCodeInvalidParam) || err.(*types.Error).Param != "url") {
+			t.Fatalf("%s: error mismatch: expected an invalid url, found: %v", test.url, err)
+		}
+	}
+}
+
+func TestWebhookDeliveryToPrivateAddress(t *testing.T) {
+	server, received := webhookReceiver(t)
+	store := &fakeWebhookStore{
+		subscriptions: []db.WebhookSubscription{{ID: 1, URL: server.URL, Secret: "secret", Filter: "{}"}},
+	}
+	d := testWebhookDispatcher(store)
+	d.allowedNets = nil
+	d.dispatchNodeEvent(context.Background(), types.NodeEvent{ID: 1, Type: types.NodeEventStatus})
+	d.wg.Wait()
+	if len(received()) != 0 {
+		t.Fatalf("event delivered to a loopback address: %d deliveries", len(received()))
+	}
+	if len(store.deliveries) != d.attempts {
+		t.Fatalf("attempts mismatch: expected: %d, found: %d", d.attempts, len(store.deliveries))
+	}
+	for _, delivery := range store.deliveries {
+		if delivery.Success || !strings.Contains(delivery.Error, "isn't allowed") {
+			t.Fatalf("delivery to a loopback address isn't rejected: %+v", delivery)
+		}
+	}
+}
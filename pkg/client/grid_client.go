@@ -37,16 +37,25 @@ func NewClient(endpoint string) Client {
 	return &proxy
 }
 
-func parseError(body io.ReadCloser) error {
-	text, err := io.ReadAll(body)
+// parseError decodes the error model returned by the proxy into a *types.Error
+// callers can branch on its code using types.IsErrorCode
+func parseError(res *http.Response) error {
+	text, err := io.ReadAll(res.Body)
 	if err != nil {
 		return errors.Wrap(err, "couldn't read body response")
 	}
-	var res ErrorReply
-	if err := json.Unmarshal(text, &res); err != nil {
-		return errors.New(string(text))
+	var reply types.Error
+	if err := json.Unmarshal(text, &reply); err != nil {
+		return types.NewError(types.ErrorCodeFromStatus(res.StatusCode), string(text))
 	}
-	return fmt.Errorf("%s", res.Error)
+	if reply.Message == "" {
+		// older proxies only set the error field
+		reply.Message = reply.Legacy
+	}
+	if reply.Code == "" {
+		reply.Code = types.ErrorCodeFromStatus(res.StatusCode)
+	}
+	return &reply
 }
 
 func requestCounters(r *http.Response) (int, error) {
@@ -86,7 +95,7 @@ func (g *Clientimpl) Nodes(filter types.NodeFilter, limit types.Limit) (res []ty
 		return
 	}
 	if req.StatusCode != http.StatusOK {
-		err = parseError(req.Body)
+		err = parseError(req)
 		return
 	}
 	if err := json.NewDecoder(req.Body).Decode(&res); err != nil {
@@ -104,7 +113,7 @@ func (g *Clientimpl) Farms(filter types.FarmFilter, limit types.Limit) (res []ty
 		return
 	}
 	if req.StatusCode != http.StatusOK {
-		err = parseError(req.Body)
+		err = parseError(req)
 		return
 	}
 	data, err := io.ReadAll(req.Body)
@@ -127,7 +136,7 @@ func (g *Clientimpl) Twins(filter types.TwinFilter, limit types.Limit) (res []ty
 		return
 	}
 	if req.StatusCode != http.StatusOK {
-		err = parseError(req.Body)
+		err = parseError(req)
 		return
 	}
 	data, err := io.ReadAll(req.Body)
@@ -150,7 +159,7 @@ func (g *Clientimpl) Contracts(filter types.ContractFilter, limit types.Limit) (
 		return
 	}
 	if req.StatusCode != http.StatusOK {
-		err = parseError(req.Body)
+		err = parseError(req)
 		return
 	}
 	data, err := io.ReadAll(req.Body)
@@ -190,7 +199,7 @@ func (g *Clientimpl) Node(nodeID uint32) (res types.NodeWithNestedCapacity, err
 		return
 	}
 	if req.StatusCode != http.StatusOK {
-		err = parseError(req.Body)
+		err = parseError(req)
 		return
 	}
 	data, err := io.ReadAll(req.Body)
@@ -208,7 +217,7 @@ func (g *Clientimpl) NodeStatus(nodeID uint32) (res types.NodeStatus, err error)
 		return
 	}
 	if req.StatusCode != http.StatusOK {
-		err = parseError(req.Body)
+		err = parseError(req)
 		return
 	}
 	if err := json.NewDecoder(req.Body).Decode(&res); err != nil {
@@ -225,7 +234,7 @@ func (g *Clientimpl) Counters(filter types.StatsFilter) (res types.Counters, err
 		return
 	}
 	if req.StatusCode != http.StatusOK {
-		err = parseError(req.Body)
+		err = parseError(req)
 		return
 	}
 	if err := json.NewDecoder(req.Body).Decode(&res); err != nil {
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -156,6 +157,45 @@ func testStatusCodeFailures(t *testing.T, f ProxyFunc) {
 	}
 }
 
+func TestTypedErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`
+			{
+				"code": "node_not_found",
+				"message": "node not found",
+				"requestId": "some-id"
+			}
+		`))
+	}))
+	defer ts.Close()
+	proxy := NewClient(ts.URL)
+	_, err := proxy.Node(1)
+	if !types.IsErrorCode(err, types.ErrCodeNodeNotFound) {
+		t.Fatalf("expected a %s error, found: %v", types.ErrCodeNodeNotFound, err)
+	}
+	var typed *types.Error
+	if !errors.As(err, &typed) || typed.RequestID != "some-id" || typed.Error() != "node not found" {
+		t.Fatalf("error parsed incorrectly: %+v", err)
+	}
+}
+
+func TestUntypedErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("not a json body"))
+	}))
+	defer ts.Close()
+	proxy := NewClient(ts.URL)
+	_, err := proxy.NodeStatus(1)
+	if !types.IsErrorCode(err, types.ErrCodeBadGateway) {
+		t.Fatalf("expected a %s error, found: %v", types.ErrCodeBadGateway, err)
+	}
+	if err.Error() != "not a json body" {
+		t.Fatalf("error parsed incorrectly: %s, should be: not a json body", err.Error())
+	}
+}
+
 func AssertHTTPRequest(
 	t *testing.T,
 	f ProxyFunc,
@@ -1,5 +1,8 @@
 package client
 
+// ErrorReply is the old error model returned by the proxy
+//
+// Deprecated: errors returned by the client are *types.Error
 type ErrorReply struct {
 	Error string `json:"error"`
 }
@@ -46,6 +46,39 @@ func nodeParams(filter types.NodeFilter, limit types.Limit) string {
 	if filter.TotalSRU != nil && *filter.TotalSRU != 0 {
 		fmt.Fprintf(&builder, "total_sru=%d&", *filter.TotalSRU)
 	}
+	if filter.FreeMRUMax != nil {
+		fmt.Fprintf(&builder, "free_mru_max=%d&", *filter.FreeMRUMax)
+	}
+	if filter.FreeHRUMax != nil {
+		fmt.Fprintf(&builder, "free_hru_max=%d&", *filter.FreeHRUMax)
+	}
+	if filter.FreeSRUMax != nil {
+		fmt.Fprintf(&builder, "free_sru_max=%d&", *filter.FreeSRUMax)
+	}
+	if filter.TotalCRUMax != nil {
+		fmt.Fprintf(&builder, "total_cru_max=%d&", *filter.TotalCRUMax)
+	}
+	if filter.TotalHRUMax != nil {
+		fmt.Fprintf(&builder, "total_hru_max=%d&", *filter.TotalHRUMax)
+	}
+	if filter.TotalMRUMax != nil {
+		fmt.Fprintf(&builder, "total_mru_max=%d&", *filter.TotalMRUMax)
+	}
+	if filter.TotalSRUMax != nil {
+		fmt.Fprintf(&builder, "total_sru_max=%d&", *filter.TotalSRUMax)
+	}
+	if filter.UptimeMin != nil && *filter.UptimeMin != 0 {
+		fmt.Fprintf(&builder, "uptime_min=%d&", *filter.UptimeMin)
+	}
+	if filter.UptimeMax != nil {
+		fmt.Fprintf(&builder, "uptime_max=%d&", *filter.UptimeMax)
+	}
+	if filter.CreatedAfter != nil && *filter.CreatedAfter != 0 {
+		fmt.Fprintf(&builder, "created_after=%d&", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		fmt.Fprintf(&builder, "created_before=%d&", *filter.CreatedBefore)
+	}
 	if filter.Country != nil && *filter.Country != "" {
 		fmt.Fprintf(&builder, "country=%s&", url.QueryEscape(*filter.Country))
 	}
@@ -98,6 +131,51 @@ func nodeParams(filter types.NodeFilter, limit types.Limit) string {
 	if filter.AvailableFor != nil {
 		fmt.Fprintf(&builder, "available_for=%d&", *filter.AvailableFor)
 	}
+	if filter.HasGPU != nil {
+		fmt.Fprintf(&builder, "has_gpu=%t&", *filter.HasGPU)
+	}
+	if filter.GPUVendor != nil && *filter.GPUVendor != "" {
+		fmt.Fprintf(&builder, "gpu_vendor=%s&", url.QueryEscape(*filter.GPUVendor))
+	}
+	if filter.GPUDevice != nil && *filter.GPUDevice != "" {
+		fmt.Fprintf(&builder, "gpu_device=%s&", url.QueryEscape(*filter.GPUDevice))
+	}
+	if filter.GPUAvailable != nil {
+		fmt.Fprintf(&builder, "gpu_available=%t&", *filter.GPUAvailable)
+	}
+	if len(filter.ExcludeFarmIDs) != 0 {
+		fmt.Fprintf(&builder, "exclude_farm_ids=%s&", url.QueryEscape(stringifyList(filter.ExcludeFarmIDs)))
+	}
+	if len(filter.ExcludeNodeIDs) != 0 {
+		fmt.Fprintf(&builder, "exclude_node_ids=%s&", url.QueryEscape(stringifyList(filter.ExcludeNodeIDs)))
+	}
+	if len(filter.ExcludeTwinIDs) != 0 {
+		fmt.Fprintf(&builder, "exclude_twin_ids=%s&", url.QueryEscape(stringifyList(filter.ExcludeTwinIDs)))
+	}
+	if filter.CountryNot != nil && *filter.CountryNot != "" {
+		fmt.Fprintf(&builder, "country_not=%s&", url.QueryEscape(*filter.CountryNot))
+	}
+	if filter.CertificationTypeNot != nil && *filter.CertificationTypeNot != "" {
+		fmt.Fprintf(&builder, "certification_type_not=%s&", url.QueryEscape(*filter.CertificationTypeNot))
+	}
+	if filter.Secure != nil {
+		fmt.Fprintf(&builder, "secure=%t&", *filter.Secure)
+	}
+	if filter.Virtualized != nil {
+		fmt.Fprintf(&builder, "virtualized=%t&", *filter.Virtualized)
+	}
+	if filter.GridVersion != nil {
+		fmt.Fprintf(&builder, "grid_version=%d&", *filter.GridVersion)
+	}
+	if filter.HasInterfaceIP != nil {
+		fmt.Fprintf(&builder, "has_interface_ip=%t&", *filter.HasInterfaceIP)
+	}
+	if len(filter.NodeIDs) != 0 {
+		fmt.Fprintf(&builder, "node_ids=%s&", url.QueryEscape(stringifyList(filter.NodeIDs)))
+	}
+	if filter.UpdatedAfter != nil {
+		fmt.Fprintf(&builder, "updated_after=%d&", *filter.UpdatedAfter)
+	}
 	if limit.Page != 0 {
 		fmt.Fprintf(&builder, "page=%d&", limit.Page)
 	}
@@ -154,6 +232,39 @@ func farmParams(filter types.FarmFilter, limit types.Limit) string {
 	if filter.Dedicated != nil {
 		fmt.Fprintf(&builder, "dedicated=%t&", *filter.Dedicated)
 	}
+	if len(filter.ExcludeFarmIDs) != 0 {
+		fmt.Fprintf(&builder, "exclude_farm_ids=%s&", url.QueryEscape(stringifyList(filter.ExcludeFarmIDs)))
+	}
+	if len(filter.ExcludeTwinIDs) != 0 {
+		fmt.Fprintf(&builder, "exclude_twin_ids=%s&", url.QueryEscape(stringifyList(filter.ExcludeTwinIDs)))
+	}
+	if filter.CertificationTypeNot != nil && *filter.CertificationTypeNot != "" {
+		fmt.Fprintf(&builder, "certification_type_not=%s&", url.QueryEscape(*filter.CertificationTypeNot))
+	}
+	if filter.NodeCountMin != nil {
+		fmt.Fprintf(&builder, "node_count_min=%d&", *filter.NodeCountMin)
+	}
+	if filter.UpNodesMin != nil {
+		fmt.Fprintf(&builder, "up_nodes_min=%d&", *filter.UpNodesMin)
+	}
+	if filter.RentableNodesMin != nil {
+		fmt.Fprintf(&builder, "rentable_nodes_min=%d&", *filter.RentableNodesMin)
+	}
+	if filter.Country != nil && *filter.Country != "" {
+		fmt.Fprintf(&builder, "country=%s&", url.QueryEscape(*filter.Country))
+	}
+	if filter.NodeFreeMRU != nil {
+		fmt.Fprintf(&builder, "node_free_mru=%d&", *filter.NodeFreeMRU)
+	}
+	if filter.NodeFreeSRU != nil {
+		fmt.Fprintf(&builder, "node_free_sru=%d&", *filter.NodeFreeSRU)
+	}
+	if filter.NodeCertificationType != nil && *filter.NodeCertificationType != "" {
+		fmt.Fprintf(&builder, "node_certification_type=%s&", url.QueryEscape(*filter.NodeCertificationType))
+	}
+	if len(filter.FarmIDs) != 0 {
+		fmt.Fprintf(&builder, "farm_ids=%s&", url.QueryEscape(stringifyList(filter.FarmIDs)))
+	}
 	if limit.Page != 0 {
 		fmt.Fprintf(&builder, "page=%d&", limit.Page)
 	}
@@ -185,6 +296,26 @@ func twinParams(filter types.TwinFilter, limit types.Limit) string {
 		fmt.Fprintf(&builder, "account_id=%s&", url.QueryEscape(*filter.AccountID))
 	}
 
+	if len(filter.TwinIDs) != 0 {
+		fmt.Fprintf(&builder, "twin_ids=%s&", url.QueryEscape(stringifyList(filter.TwinIDs)))
+	}
+
+	if filter.Relay != nil && *filter.Relay != "" {
+		fmt.Fprintf(&builder, "relay=%s&", url.QueryEscape(*filter.Relay))
+	}
+
+	if filter.PublicKey != nil && *filter.PublicKey != "" {
+		fmt.Fprintf(&builder, "public_key=%s&", url.QueryEscape(*filter.PublicKey))
+	}
+
+	if filter.RelayContains != nil && *filter.RelayContains != "" {
+		fmt.Fprintf(&builder, "relay_contains=%s&", url.QueryEscape(*filter.RelayContains))
+	}
+
+	if len(filter.AccountIDs) != 0 {
+		fmt.Fprintf(&builder, "account_ids=%s&", url.QueryEscape(strings.Join(filter.AccountIDs, ",")))
+	}
+
 	if limit.Page != 0 {
 		fmt.Fprintf(&builder, "page=%d&", limit.Page)
 	}
@@ -237,6 +368,36 @@ func contractParams(filter types.ContractFilter, limit types.Limit) string {
 	if filter.DeploymentHash != nil && *filter.DeploymentHash != "" {
 		fmt.Fprintf(&builder, "deployment_hash=%s&", url.QueryEscape(*filter.DeploymentHash))
 	}
+	if len(filter.States) != 0 {
+		fmt.Fprintf(&builder, "states=%s&", url.QueryEscape(strings.Join(filter.States, ",")))
+	}
+	if len(filter.Types) != 0 {
+		fmt.Fprintf(&builder, "types=%s&", url.QueryEscape(strings.Join(filter.Types, ",")))
+	}
+	if filter.CreatedAfter != nil {
+		fmt.Fprintf(&builder, "created_after=%d&", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		fmt.Fprintf(&builder, "created_before=%d&", *filter.CreatedBefore)
+	}
+	if len(filter.NodeIDs) != 0 {
+		fmt.Fprintf(&builder, "node_ids=%s&", url.QueryEscape(stringifyList(filter.NodeIDs)))
+	}
+	if len(filter.TwinIDs) != 0 {
+		fmt.Fprintf(&builder, "twin_ids=%s&", url.QueryEscape(stringifyList(filter.TwinIDs)))
+	}
+	if filter.FarmID != nil && *filter.FarmID != 0 {
+		fmt.Fprintf(&builder, "farm_id=%d&", *filter.FarmID)
+	}
+	if filter.HasPublicIPs != nil {
+		fmt.Fprintf(&builder, "has_public_ips=%t&", *filter.HasPublicIPs)
+	}
+	if filter.NameContains != nil && *filter.NameContains != "" {
+		fmt.Fprintf(&builder, "name_contains=%s&", url.QueryEscape(*filter.NameContains))
+	}
+	if len(filter.ContractIDs) != 0 {
+		fmt.Fprintf(&builder, "contract_ids=%s&", url.QueryEscape(stringifyList(filter.ContractIDs)))
+	}
 	if limit.Page != 0 {
 		fmt.Fprintf(&builder, "page=%d&", limit.Page)
 	}
@@ -11,31 +11,49 @@ func nodesFilterValues() (types.NodeFilter, types.Limit, string) {
 	Egypt := "Egypt"
 	Mansoura := "Mansoura"
 	Freefarm := "Freefarm"
+	Nvidia := "NVIDIA Corporation"
+	T4 := "Tesla T4"
 	trueVal := true
 	falseVal := false
 	ints := []uint64{0, 1, 2, 3, 4, 5, 6}
 	f := types.NodeFilter{
-		Status:       &Up,
-		FreeMRU:      &ints[1],
-		FreeHRU:      &ints[2],
-		FreeSRU:      &ints[3],
-		Country:      &Egypt,
-		City:         &Mansoura,
-		FarmName:     &Freefarm,
-		FarmIDs:      []uint64{1, 2},
-		FreeIPs:      &ints[4],
-		IPv4:         &trueVal,
-		IPv6:         &falseVal,
-		Domain:       &trueVal,
-		Rentable:     &falseVal,
-		RentedBy:     &ints[5],
-		AvailableFor: &ints[6],
+		Status:         &Up,
+		FreeMRU:        &ints[1],
+		FreeHRU:        &ints[2],
+		FreeSRU:        &ints[3],
+		TotalCRUMax:    &ints[4],
+		FreeMRUMax:     &ints[0],
+		UptimeMin:      &ints[5],
+		CreatedBefore:  &ints[6],
+		Country:        &Egypt,
+		City:           &Mansoura,
+		FarmName:       &Freefarm,
+		FarmIDs:        []uint64{1, 2},
+		FreeIPs:        &ints[4],
+		IPv4:           &trueVal,
+		IPv6:           &falseVal,
+		Domain:         &trueVal,
+		Rentable:       &falseVal,
+		RentedBy:       &ints[5],
+		AvailableFor:   &ints[6],
+		HasGPU:         &trueVal,
+		GPUVendor:      &Nvidia,
+		GPUDevice:      &T4,
+		GPUAvailable:   &trueVal,
+		ExcludeFarmIDs: []uint64{3},
+		ExcludeNodeIDs: []uint64{4, 5},
+		CountryNot:     &Egypt,
+		Secure:         &trueVal,
+		Virtualized:    &falseVal,
+		GridVersion:    &ints[3],
+		NodeIDs:        []uint64{7, 8},
+		UpdatedAfter:   &ints[2],
 	}
 	l := types.Limit{
 		Page: 12,
 		Size: 13,
 	}
-	return f, l, "?status=up&free_mru=1&free_hru=2&free_sru=3&country=Egypt&city=Mansoura&farm_name=Freefarm&farm_ids=1%2C2&free_ips=4&ipv4=true&ipv6=false&domain=true&rentable=false&rented_by=5&available_for=6&page=12&size=13"
+	return f, l, "?status=up&free_mru=1&free_hru=2&free_sru=3&free_mru_max=0&total_cru_max=4&uptime_min=5&created_before=6&country=Egypt&city=Mansoura&farm_name=Freefarm&farm_ids=1%2C2&free_ips=4&ipv4=true&ipv6=false&domain=true&rentable=false&rented_by=5&available_for=6&has_gpu=true&gpu_vendor=NVIDIA+Corporation&gpu_device=Tesla+T4&gpu_available=true&exclude_farm_ids=3&exclude_node_ids=4%2C5&country_not=Egypt&secure=true&virtualized=false&grid_version=3&node_ids=7%2C8&updated_after=2&page=12&size=13"
 }
 
 func farmsFilterValues() (types.FarmFilter, types.Limit, string) {
@@ -44,25 +62,79 @@ func farmsFilterValues() (types.FarmFilter, types.Limit, string) {
 	FreeFar := "freefar"
 	DYI := "DYI"
 	Dedicated := false
+	Egypt := "Egypt"
 	ints := []uint64{0, 1, 2, 3, 4, 5, 6}
 	f := types.FarmFilter{
-		FreeIPs:           &ints[1],
-		TotalIPs:          &ints[2],
-		StellarAddress:    &StellarAddress,
-		PricingPolicyID:   &ints[3],
-		FarmID:            &ints[5],
-		TwinID:            &ints[6],
-		Name:              &FreeFarm,
-		NameContains:      &FreeFar,
-		CertificationType: &DYI,
-		Dedicated:         &Dedicated,
+		FreeIPs:              &ints[1],
+		TotalIPs:             &ints[2],
+		StellarAddress:       &StellarAddress,
+		PricingPolicyID:      &ints[3],
+		FarmID:               &ints[5],
+		TwinID:               &ints[6],
+		Name:                 &FreeFarm,
+		NameContains:         &FreeFar,
+		CertificationType:    &DYI,
+		Dedicated:            &Dedicated,
+		ExcludeTwinIDs:       []uint64{1, 2},
+		CertificationTypeNot: &DYI,
+		UpNodesMin:           &ints[2],
+		Country:              &Egypt,
+		NodeFreeMRU:          &ints[4],
+		FarmIDs:              []uint64{5, 6},
+	}
+	l := types.Limit{
+		Page: 12,
+		Size: 13,
+	}
+
+	return f, l, "?free_ips=1&total_ips=2&stellar_address=StellarAddress&pricing_policy_id=3&farm_id=5&twin_id=6&name=freefarm&name_contains=freefar&certification_type=DYI&dedicated=false&exclude_twin_ids=1%2C2&certification_type_not=DYI&up_nodes_min=2&country=Egypt&node_free_mru=4&farm_ids=5%2C6&page=12&size=13"
+}
+
+func twinsFilterValues() (types.TwinFilter, types.Limit, string) {
+	twinID := uint64(1)
+	account := "account"
+	relay := "relay.grid.tf"
+	publicKey := "key"
+	contains := "grid"
+	f := types.TwinFilter{
+		TwinID:        &twinID,
+		AccountID:     &account,
+		TwinIDs:       []uint64{1, 2},
+		Relay:         &relay,
+		PublicKey:     &publicKey,
+		RelayContains: &contains,
+		AccountIDs:    []string{"account", "other"},
 	}
 	l := types.Limit{
 		Page: 12,
 		Size: 13,
 	}
 
-	return f, l, "?free_ips=1&total_ips=2&stellar_address=StellarAddress&pricing_policy_id=3&farm_id=5&twin_id=6&name=freefarm&name_contains=freefar&certification_type=DYI&dedicated=false&page=12&size=13"
+	return f, l, "?twin_id=1&account_id=account&twin_ids=1%2C2&relay=relay.grid.tf&public_key=key&relay_contains=grid&account_ids=account%2Cother&page=12&size=13"
+}
+
+func contractsFilterValues() (types.ContractFilter, types.Limit, string) {
+	name := "name"
+	hasPublicIPs := true
+	ints := []uint64{0, 1, 2, 3, 4}
+	f := types.ContractFilter{
+		States:        []string{"Created", "GracePeriod"},
+		Types:         []string{"node", "rent"},
+		CreatedAfter:  &ints[1],
+		CreatedBefore: &ints[2],
+		NodeIDs:       []uint64{3, 4},
+		TwinIDs:       []uint64{1, 2},
+		FarmID:        &ints[3],
+		HasPublicIPs:  &hasPublicIPs,
+		NameContains:  &name,
+		ContractIDs:   []uint64{5},
+	}
+	l := types.Limit{
+		Page: 12,
+		Size: 13,
+	}
+
+	return f, l, "?states=Created%2CGracePeriod&types=node%2Crent&created_after=1&created_before=2&node_ids=3%2C4&twin_ids=1%2C2&farm_id=3&has_public_ips=true&name_contains=name&contract_ids=5&page=12&size=13"
 }
 
 func TestNodeFilter(t *testing.T) {
@@ -96,3 +168,19 @@ func TestEmptyFarmFilter(t *testing.T) {
 		t.Fatalf("found: %s, expected: %s", found, expected)
 	}
 }
+
+func TestContractFilter(t *testing.T) {
+	f, l, expected := contractsFilterValues()
+	found := contractParams(f, l)
+	if found != expected {
+		t.Fatalf("found: %s, expected: %s", found, expected)
+	}
+}
+
+func TestTwinFilter(t *testing.T) {
+	f, l, expected := twinsFilterValues()
+	found := twinParams(f, l)
+	if found != expected {
+		t.Fatalf("found: %s, expected: %s", found, expected)
+	}
+}
@@ -0,0 +1,44 @@
+package types
+
+// NodeComparison is a side by side comparison of nodes
+type NodeComparison struct {
+	// Nodes are the compared nodes in the requested order
+	Nodes []ComparedNode `json:"nodes"`
+	// Attributes are the rows of the comparison table with a value for each node in the order of the nodes
+	Attributes []ComparedAttribute `json:"attributes"`
+}
+
+// ComparedNode is a node with the attributes it's compared by
+type ComparedNode struct {
+	NodeID         int      `json:"nodeId"`
+	FarmID         int      `json:"farmId"`
+	FarmName       string   `json:"farmName"`
+	Status         string   `json:"status"`
+	Uptime         int64    `json:"uptime"`
+	TotalResources Capacity `json:"total_resources"`
+	// FreeResources are the total resources minus the resources used by the contracts and reserved by zos
+	FreeResources         Capacity `json:"free_resources"`
+	Location              Location `json:"location"`
+	CertificationType     string   `json:"certificationType"`
+	FarmCertificationType string   `json:"farmCertificationType"`
+	Dedicated             bool     `json:"dedicated"`
+	RentedByTwinID        uint     `json:"rentedByTwinId"`
+	Rentable              bool     `json:"rentable"`
+	// Available is set if the node is up and not rented
+	Available       bool   `json:"available"`
+	PricingPolicyID uint32 `json:"pricingPolicyId"`
+	// Price is the price of renting the node total resources after the dedicated discount
+	Price        Price `json:"price"`
+	PricePerCore Price `json:"pricePerCore"`
+	PricePerGB   Price `json:"pricePerGb"`
+}
+
+// ComparedAttribute is an attribute of the compared nodes
+type ComparedAttribute struct {
+	Name string `json:"name"`
+	// Values are the values of the attribute for each node in the order of the nodes
+	Values []interface{} `json:"values"`
+	// Best are the ids of the nodes with the best value. it's empty if all the nodes have the same value
+	// or if the attribute has no better value like the location
+	Best []int `json:"best"`
+}
@@ -0,0 +1,72 @@
+package types
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorCode is a stable machine readable identifier of a failure
+type ErrorCode string
+
+const (
+	// ErrCodeInternal unexpected server failure
+	ErrCodeInternal ErrorCode = "internal_error"
+	// ErrCodeBadRequest the request is malformed
+	ErrCodeBadRequest ErrorCode = "bad_request"
+	// ErrCodeInvalidParam a query or path parameter has an invalid value, the parameter is set in Error.Param
+	ErrCodeInvalidParam ErrorCode = "invalid_parameter"
+	// ErrCodeNotFound the requested object doesn't exist
+	ErrCodeNotFound ErrorCode = "not_found"
+	// ErrCodeNodeNotFound the requested node doesn't exist
+	ErrCodeNodeNotFound ErrorCode = "node_not_found"
+	// ErrCodeGatewayNotFound the requested gateway doesn't exist
+	ErrCodeGatewayNotFound ErrorCode = "gateway_not_found"
+	// ErrCodeBadGateway the node or service the request is proxied to failed
+	ErrCodeBadGateway ErrorCode = "bad_gateway"
+	// ErrCodeUnavailable the server is too busy
+	ErrCodeUnavailable ErrorCode = "unavailable"
+)
+
+// Error is the error model returned by the proxy for every failed request
+type Error struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Param     string    `json:"param,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+	// Details has an error for each invalid parameter when more than one is reported
+	Details []Error `json:"details,omitempty"`
+	// Legacy duplicates the message for clients reading the old `{"error": "..."}` model
+	Legacy string `json:"error,omitempty"`
+}
+
+// NewError creates an error with the given code and message
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// IsErrorCode checks if the error, or any error it wraps, is an Error with the given code
+func IsErrorCode(err error, code ErrorCode) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// ErrorCodeFromStatus returns the generic error code of an http status code
+func ErrorCodeFromStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeBadRequest
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusBadGateway:
+		return ErrCodeBadGateway
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	default:
+		return ErrCodeInternal
+	}
+}
@@ -0,0 +1,33 @@
+package types
+
+const (
+	// NodeEventStatus is sent when the status of a node changes
+	NodeEventStatus = "status"
+	// NodeEventRent is sent when a node is rented or its rent contract ends
+	NodeEventRent = "rent"
+	// NodeEventCapacity is sent when the total or used capacity of a node changes
+	NodeEventCapacity = "capacity"
+)
+
+// NodeEventTypes are the types of the node events
+var NodeEventTypes = []string{NodeEventStatus, NodeEventRent, NodeEventCapacity}
+
+// NodeState is the part of a node watched for changes
+type NodeState struct {
+	Status         string   `json:"status"`
+	RentedByTwinID uint     `json:"rentedByTwinId"`
+	TotalResources Capacity `json:"total_resources"`
+	UsedResources  Capacity `json:"used_resources"`
+}
+
+// NodeEvent is a change of a node detected between two polls of the database
+type NodeEvent struct {
+	ID        uint64    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp int64     `json:"timestamp"`
+	NodeID    int       `json:"nodeId"`
+	FarmID    int       `json:"farmId"`
+	TwinID    int       `json:"twinId"`
+	Previous  NodeState `json:"previous"`
+	Current   NodeState `json:"current"`
+}
@@ -0,0 +1,30 @@
+package types
+
+// NameAvailability is the availability of a gateway name
+type NameAvailability struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	// ContractID, TwinID, State and CreatedAt are of the name contract reserving the name, they're not set if it's available
+	ContractID uint   `json:"contractId,omitempty"`
+	TwinID     uint   `json:"twinId,omitempty"`
+	State      string `json:"state,omitempty"`
+	CreatedAt  uint   `json:"created_at,omitempty"`
+}
+
+// GatewayResolution is the gateway nodes that can serve a hostname
+type GatewayResolution struct {
+	FQDN string `json:"fqdn"`
+	// Name is the gateway name in the hostname if it's a subdomain of a gateway domain, it's not set for the other hostnames
+	Name *NameAvailability `json:"name,omitempty"`
+	// Gateways are the gateway nodes with a domain the hostname is in, the up nodes and the most specific domains come first
+	Gateways []GatewayCandidate `json:"gateways"`
+}
+
+// GatewayCandidate is a gateway node that can serve a hostname
+type GatewayCandidate struct {
+	NodeID       int          `json:"nodeId"`
+	FarmID       int          `json:"farmId"`
+	TwinID       int          `json:"twinId"`
+	Status       string       `json:"status"`
+	PublicConfig PublicConfig `json:"publicConfig"`
+}
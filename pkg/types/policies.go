@@ -0,0 +1,41 @@
+package types
+
+// PolicyPrice is the price of a resource unit in a pricing policy
+type PolicyPrice struct {
+	// Value is the price in units of 1e-7 USD per hour
+	Value uint64 `json:"value"`
+	Unit  string `json:"unit"`
+}
+
+// PricingPolicy is the prices of the resources deployed on the farms using the policy
+type PricingPolicy struct {
+	ID                    uint32      `json:"id"`
+	Name                  string      `json:"name"`
+	SU                    PolicyPrice `json:"su"`
+	CU                    PolicyPrice `json:"cu"`
+	NU                    PolicyPrice `json:"nu"`
+	IPU                   PolicyPrice `json:"ipu"`
+	FoundationAccount     string      `json:"foundationAccount"`
+	CertifiedSalesAccount string      `json:"certifiedSalesAccount"`
+	// DedicatedNodeDiscount is the discount percentage of renting a whole node
+	DedicatedNodeDiscount uint8 `json:"dedicatedNodeDiscount"`
+}
+
+// FarmingPolicy is the rewards of the resources provided by the nodes using the policy
+type FarmingPolicy struct {
+	ID            uint32 `json:"id"`
+	Name          string `json:"name"`
+	CU            uint64 `json:"cu"`
+	SU            uint64 `json:"su"`
+	NU            uint64 `json:"nu"`
+	IPv4          uint64 `json:"ipv4"`
+	MinimalUptime uint64 `json:"minimalUptime"`
+	PolicyCreated uint64 `json:"policyCreated"`
+	PolicyEnd     uint64 `json:"policyEnd"`
+	Immutable     bool   `json:"immutable"`
+	Default       bool   `json:"default"`
+	// NodeCertification is the certification the node must have to use the policy
+	NodeCertification string `json:"nodeCertification"`
+	// FarmCertification is the certification the farm must have to use the policy
+	FarmCertification string `json:"farmCertification"`
+}
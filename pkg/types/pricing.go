@@ -0,0 +1,71 @@
+package types
+
+// PriceEstimate is the estimated cost of deploying resources on the grid
+type PriceEstimate struct {
+	PricingPolicyID uint32   `json:"pricingPolicyId"`
+	NodeID          uint32   `json:"nodeId,omitempty"`
+	Resources       Capacity `json:"resources"`
+	PublicIPs       uint64   `json:"publicIps"`
+	CU              float64  `json:"cu"`
+	SU              float64  `json:"su"`
+	IPU             float64  `json:"ipu"`
+	Certified       bool     `json:"certified"`
+	Dedicated       bool     `json:"dedicated"`
+	// DedicatedDiscount is the discount percentage of renting a whole node
+	DedicatedDiscount uint8 `json:"dedicatedDiscount"`
+	// TFTPrice is the price of one TFT in USD
+	TFTPrice float64 `json:"tftPrice"`
+	// Price is the price without the staking discount
+	Price Price `json:"price"`
+	// StakingDiscount is the staking discount reached with the given balance, it's not set if no balance is given
+	StakingDiscount *StakingDiscount `json:"stakingDiscount,omitempty"`
+	// Discounts are all the staking discount levels
+	Discounts []StakingDiscount `json:"discounts"`
+}
+
+// Price is a cost in USD and TFT
+type Price struct {
+	HourlyUSD  float64 `json:"hourlyUsd"`
+	MonthlyUSD float64 `json:"monthlyUsd"`
+	HourlyTFT  float64 `json:"hourlyTft"`
+	MonthlyTFT float64 `json:"monthlyTft"`
+}
+
+// StakingDiscount is a discount given to twins with a balance covering some months of their deployments
+type StakingDiscount struct {
+	Level string `json:"level"`
+	// Discount is the discount percentage
+	Discount uint8 `json:"discount"`
+	// Months is the number of months the balance must cover to get the discount
+	Months float64 `json:"months"`
+	// RequiredBalance is the balance in TFT needed to get the discount
+	RequiredBalance float64 `json:"requiredBalance"`
+	Price           Price   `json:"price"`
+}
+
+// RentableNode is a node available for renting with the price of renting it
+type RentableNode struct {
+	NodeID         int       `json:"nodeId"`
+	FarmID         int       `json:"farmId"`
+	TwinID         int       `json:"twinId"`
+	FarmName       string    `json:"farmName"`
+	Status         string    `json:"status"`
+	Location       Location  `json:"location"`
+	TotalResources Capacity  `json:"total_resources"`
+	GPUs           []NodeGPU `json:"gpus"`
+	// CertificationType is the certification of the node, certified nodes cost more
+	CertificationType string `json:"certificationType"`
+	// FarmCertificationType is the certification of the farm of the node
+	FarmCertificationType string `json:"farmCertificationType"`
+	// Dedicated is set if the farm of the node is dedicated, the nodes of the other farms are rentable while they have no contracts
+	Dedicated       bool   `json:"dedicated"`
+	PricingPolicyID uint32 `json:"pricingPolicyId"`
+	// DedicatedDiscount is the discount percentage of renting a whole node, it's applied on the prices
+	DedicatedDiscount uint8 `json:"dedicatedDiscount"`
+	// Price is the price of renting the node total resources without the staking discount
+	Price Price `json:"price"`
+	// PricePerCore is the price of renting the node divided by its cores
+	PricePerCore Price `json:"pricePerCore"`
+	// PricePerGB is the price of renting the node divided by its memory in GB
+	PricePerGB Price `json:"pricePerGb"`
+}
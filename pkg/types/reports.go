@@ -0,0 +1,50 @@
+package types
+
+// FarmReport is the revenue of a farm in a time range with the current usage of its nodes and public ips
+type FarmReport struct {
+	FarmID uint64 `json:"farmId"`
+	// From and To are the [from, to) range of the bill reports timestamps, the usage is the current one
+	From        int64  `json:"from"`
+	To          int64  `json:"to"`
+	Nodes       uint64 `json:"nodes"`
+	UpNodes     uint64 `json:"upNodes"`
+	RentedNodes uint64 `json:"rentedNodes"`
+	// RentedRatio is the ratio of the rented nodes to all the nodes
+	RentedRatio float64 `json:"rentedRatio"`
+	// AverageUptime is the average uptime in seconds of the up nodes
+	AverageUptime  int64       `json:"averageUptime"`
+	TotalResources Capacity    `json:"total_resources"`
+	UsedResources  Capacity    `json:"used_resources"`
+	Utilization    Utilization `json:"utilization"`
+	PublicIPs      uint64      `json:"publicIps"`
+	UsedPublicIPs  uint64      `json:"usedPublicIps"`
+	// PublicIPsRatio is the ratio of the public ips used by contracts to all the public ips
+	PublicIPsRatio float64 `json:"publicIpsRatio"`
+	// AmountBilled is the amount billed for the node and rent contracts on the farm nodes in units of 1e-7 TFT
+	AmountBilled uint64 `json:"amountBilled"`
+	Reports      uint64 `json:"reports"`
+	// Discounts is the amount billed with each discount level
+	Discounts   []SpendingDiscount `json:"discounts"`
+	NodeReports []FarmNodeReport   `json:"nodeReports"`
+}
+
+// FarmNodeReport is the revenue of a farm node in a time range with its current usage
+type FarmNodeReport struct {
+	NodeID         uint64      `json:"nodeId"`
+	Status         string      `json:"status"`
+	Uptime         int64       `json:"uptime"`
+	Rented         bool        `json:"rented"`
+	TotalResources Capacity    `json:"total_resources"`
+	UsedResources  Capacity    `json:"used_resources"`
+	Utilization    Utilization `json:"utilization"`
+	AmountBilled   uint64      `json:"amountBilled"`
+	Reports        uint64      `json:"reports"`
+}
+
+// Utilization is the ratio of the used resources to the total resources
+type Utilization struct {
+	CRU float64 `json:"cru"`
+	MRU float64 `json:"mru"`
+	SRU float64 `json:"sru"`
+	HRU float64 `json:"hru"`
+}
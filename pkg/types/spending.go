@@ -0,0 +1,37 @@
+package types
+
+// TwinSpending is the amount billed for the contracts of a twin in a time range
+type TwinSpending struct {
+	TwinID uint64 `json:"twinId"`
+	// From and To are the [from, to) range of the bill reports timestamps
+	From    int64  `json:"from"`
+	To      int64  `json:"to"`
+	GroupBy string `json:"groupBy"`
+	// AmountBilled is the total amount billed in units of 1e-7 TFT
+	AmountBilled uint64 `json:"amountBilled"`
+	Reports      uint64 `json:"reports"`
+	// Discounts is the total amount billed with each discount level
+	Discounts []SpendingDiscount `json:"discounts"`
+	Groups    []SpendingGroup    `json:"groups"`
+}
+
+// SpendingGroup is the amount billed in a day, month, contract or node
+type SpendingGroup struct {
+	// Timestamp is the start of the UTC day or month if grouped by day or month
+	Timestamp *int64 `json:"timestamp,omitempty"`
+	// ContractID is set if grouped by contract
+	ContractID *uint64 `json:"contractId,omitempty"`
+	// NodeID is set if grouped by node, it's 0 for the name contracts
+	NodeID       *uint64            `json:"nodeId,omitempty"`
+	AmountBilled uint64             `json:"amountBilled"`
+	Reports      uint64             `json:"reports"`
+	Discounts    []SpendingDiscount `json:"discounts"`
+}
+
+// SpendingDiscount is the amount billed with a discount level, the chain reports the level
+// of the discount received and not its amount
+type SpendingDiscount struct {
+	DiscountReceived string `json:"discountReceived"`
+	AmountBilled     uint64 `json:"amountBilled"`
+	Reports          uint64 `json:"reports"`
+}
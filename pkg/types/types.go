@@ -31,6 +31,37 @@ type Counters struct {
 	NodesDistribution map[string]int64 `json:"nodesDistribution" gorm:"-:all"`
 }
 
+// StatsSnapshot is the grid counters at the end of a time bucket of the stats history
+type StatsSnapshot struct {
+	// Timestamp is the start of the time bucket
+	Timestamp   int64 `json:"timestamp"`
+	Nodes       int64 `json:"nodes"`
+	UpNodes     int64 `json:"upNodes"`
+	Farms       int64 `json:"farms"`
+	Countries   int64 `json:"countries"`
+	TotalCRU    int64 `json:"totalCru"`
+	TotalSRU    int64 `json:"totalSru"`
+	TotalMRU    int64 `json:"totalMru"`
+	TotalHRU    int64 `json:"totalHru"`
+	PublicIPs   int64 `json:"publicIps"`
+	AccessNodes int64 `json:"accessNodes"`
+	Gateways    int64 `json:"gateways"`
+	Twins       int64 `json:"twins"`
+	Contracts   int64 `json:"contracts"`
+}
+
+// Changes are the nodes, farms, twins and contracts created or modified after a checkpoint
+type Changes struct {
+	// Checkpoint is passed as since to get the changes after these ones
+	Checkpoint string `json:"checkpoint"`
+	// More is set if there are more changes after the checkpoint
+	More      bool       `json:"more"`
+	Nodes     []Node     `json:"nodes"`
+	Farms     []Farm     `json:"farms"`
+	Twins     []Twin     `json:"twins"`
+	Contracts []Contract `json:"contracts"`
+}
+
 // PublicConfig node public config
 type PublicConfig struct {
 	Domain string `json:"domain"`
@@ -56,6 +87,8 @@ type Farm struct {
 	StellarAddress    string     `json:"stellarAddress"`
 	Dedicated         bool       `json:"dedicated"`
 	PublicIps         []PublicIP `json:"publicIps"`
+	// PricingPolicy is set only if requested with expand=policy
+	PricingPolicy *PricingPolicy `json:"pricingPolicy,omitempty" expand:"policy"`
 }
 
 // PublicIP info about public ip in the farm
@@ -67,6 +100,14 @@ type PublicIP struct {
 	Gateway    string `json:"gateway"`
 }
 
+// NodeEventFilter node events filters
+type NodeEventFilter struct {
+	FarmID  *uint64
+	NodeIDs []uint64
+	TwinID  *uint64
+	Types   []string
+}
+
 // StatsFilter statistics filters
 type StatsFilter struct {
 	Status *string
@@ -82,47 +123,86 @@ type Limit struct {
 
 // NodeFilter node filters
 type NodeFilter struct {
-	Status            *string
-	FreeMRU           *uint64
-	FreeHRU           *uint64
-	FreeSRU           *uint64
-	TotalMRU          *uint64
-	TotalHRU          *uint64
-	TotalSRU          *uint64
-	TotalCRU          *uint64
-	Country           *string
-	CountryContains   *string
-	City              *string
-	CityContains      *string
-	FarmName          *string
-	FarmNameContains  *string
-	FarmIDs           []uint64
-	FreeIPs           *uint64
-	IPv4              *bool
-	IPv6              *bool
-	Domain            *bool
-	Dedicated         *bool
-	Rentable          *bool
-	Rented            *bool
-	RentedBy          *uint64
-	AvailableFor      *uint64
-	NodeID            *uint64
-	TwinID            *uint64
-	CertificationType *string
+	Status               *string
+	FreeMRU              *uint64
+	FreeHRU              *uint64
+	FreeSRU              *uint64
+	TotalMRU             *uint64
+	TotalHRU             *uint64
+	TotalSRU             *uint64
+	TotalCRU             *uint64
+	FreeMRUMax           *uint64
+	FreeHRUMax           *uint64
+	FreeSRUMax           *uint64
+	TotalMRUMax          *uint64
+	TotalHRUMax          *uint64
+	TotalSRUMax          *uint64
+	TotalCRUMax          *uint64
+	UptimeMin            *uint64
+	UptimeMax            *uint64
+	CreatedAfter         *uint64
+	CreatedBefore        *uint64
+	Country              *string
+	CountryContains      *string
+	City                 *string
+	CityContains         *string
+	FarmName             *string
+	FarmNameContains     *string
+	FarmIDs              []uint64
+	FreeIPs              *uint64
+	IPv4                 *bool
+	IPv6                 *bool
+	Domain               *bool
+	Dedicated            *bool
+	Rentable             *bool
+	Rented               *bool
+	RentedBy             *uint64
+	AvailableFor         *uint64
+	NodeID               *uint64
+	TwinID               *uint64
+	CertificationType    *string
+	HasGPU               *bool
+	GPUVendor            *string
+	GPUDevice            *string
+	GPUAvailable         *bool
+	ExcludeFarmIDs       []uint64
+	ExcludeNodeIDs       []uint64
+	ExcludeTwinIDs       []uint64
+	CountryNot           *string
+	CertificationTypeNot *string
+	Secure               *bool
+	Virtualized          *bool
+	GridVersion          *uint64
+	HasInterfaceIP       *bool
+	NodeIDs              []uint64
+	// UpdatedAfter filters nodes that reported at or after the given unix timestamp
+	UpdatedAfter *uint64
 }
 
 // FarmFilter farm filters
 type FarmFilter struct {
-	FreeIPs           *uint64
-	TotalIPs          *uint64
-	StellarAddress    *string
-	PricingPolicyID   *uint64
-	FarmID            *uint64
-	TwinID            *uint64
-	Name              *string
-	NameContains      *string
-	CertificationType *string
-	Dedicated         *bool
+	FreeIPs              *uint64
+	TotalIPs             *uint64
+	StellarAddress       *string
+	PricingPolicyID      *uint64
+	FarmID               *uint64
+	TwinID               *uint64
+	Name                 *string
+	NameContains         *string
+	CertificationType    *string
+	Dedicated            *bool
+	ExcludeFarmIDs       []uint64
+	ExcludeTwinIDs       []uint64
+	CertificationTypeNot *string
+	// the node filters match the farms by the nodes that satisfy all of them
+	NodeCountMin          *uint64
+	UpNodesMin            *uint64
+	RentableNodesMin      *uint64
+	Country               *string
+	NodeFreeMRU           *uint64
+	NodeFreeSRU           *uint64
+	NodeCertificationType *string
+	FarmIDs               []uint64
 }
 
 // TwinFilter twin filters
@@ -131,6 +211,11 @@ type TwinFilter struct {
 	AccountID *string
 	Relay     *string
 	PublicKey *string
+	TwinIDs   []uint64
+	// RelayContains filters the twins with a relay containing the given string
+	RelayContains *string
+	// AccountIDs filters the twins of any of the accounts
+	AccountIDs []string
 }
 
 // ContractFilter contract filters
@@ -144,6 +229,19 @@ type ContractFilter struct {
 	NumberOfPublicIps *uint64
 	DeploymentData    *string
 	DeploymentHash    *string
+	States            []string
+	Types             []string
+	// CreatedAfter filters contracts created at or after the given unix timestamp
+	CreatedAfter *uint64
+	// CreatedBefore filters contracts created before the given unix timestamp
+	CreatedBefore *uint64
+	NodeIDs       []uint64
+	TwinIDs       []uint64
+	// FarmID filters node and rent contracts on the nodes of the farm
+	FarmID       *uint64
+	HasPublicIPs *bool
+	NameContains *string
+	ContractIDs  []uint64
 }
 
 type Location struct {
@@ -170,12 +268,17 @@ type Node struct {
 	UsedResources     Capacity     `json:"used_resources"`
 	Location          Location     `json:"location"`
 	PublicConfig      PublicConfig `json:"publicConfig"`
-	Status            string       `json:"status"` // added node status field for up or down
+	Status            string       `json:"status"` // added node status field for up, down or standby
 	CertificationType string       `json:"certificationType"`
 	Dedicated         bool         `json:"dedicated"`
 	RentContractID    uint         `json:"rentContractId"`
 	RentedByTwinID    uint         `json:"rentedByTwinId"`
 	SerialNumber      string       `json:"serialNumber"`
+	GPUs              []NodeGPU    `json:"gpus"`
+	Secure            bool         `json:"secure"`
+	Virtualized       bool         `json:"virtualized"`
+	// FarmingPolicy is set only if requested with expand=policy
+	FarmingPolicy *FarmingPolicy `json:"farmingPolicy,omitempty" expand:"policy"`
 }
 
 // CapacityResult is the NodeData capacity results to unmarshal json in it
@@ -186,26 +289,32 @@ type CapacityResult struct {
 
 // Node to be compatible with old view
 type NodeWithNestedCapacity struct {
-	ID                string         `json:"id"`
-	NodeID            int            `json:"nodeId"`
-	FarmID            int            `json:"farmId"`
-	TwinID            int            `json:"twinId"`
-	Country           string         `json:"country"`
-	GridVersion       int            `json:"gridVersion"`
-	City              string         `json:"city"`
-	Uptime            int64          `json:"uptime"`
-	Created           int64          `json:"created"`
-	FarmingPolicyID   int            `json:"farmingPolicyId"`
-	UpdatedAt         int64          `json:"updatedAt"`
-	Capacity          CapacityResult `json:"capacity"`
-	Location          Location       `json:"location"`
-	PublicConfig      PublicConfig   `json:"publicConfig"`
-	Status            string         `json:"status"` // added node status field for up or down
-	CertificationType string         `json:"certificationType"`
-	Dedicated         bool           `json:"dedicated"`
-	RentContractID    uint           `json:"rentContractId"`
-	RentedByTwinID    uint           `json:"rentedByTwinId"`
-	SerialNumber      string         `json:"serialNumber"`
+	ID                string                 `json:"id"`
+	NodeID            int                    `json:"nodeId"`
+	FarmID            int                    `json:"farmId"`
+	TwinID            int                    `json:"twinId"`
+	Country           string                 `json:"country"`
+	GridVersion       int                    `json:"gridVersion"`
+	City              string                 `json:"city"`
+	Uptime            int64                  `json:"uptime"`
+	Created           int64                  `json:"created"`
+	FarmingPolicyID   int                    `json:"farmingPolicyId"`
+	UpdatedAt         int64                  `json:"updatedAt"`
+	Capacity          CapacityResult         `json:"capacity"`
+	Location          Location               `json:"location"`
+	PublicConfig      PublicConfig           `json:"publicConfig"`
+	Status            string                 `json:"status"` // added node status field for up, down or standby
+	CertificationType string                 `json:"certificationType"`
+	Dedicated         bool                   `json:"dedicated"`
+	RentContractID    uint                   `json:"rentContractId"`
+	RentedByTwinID    uint                   `json:"rentedByTwinId"`
+	SerialNumber      string                 `json:"serialNumber"`
+	GPUs              []NodeGPU              `json:"gpus"`
+	Secure            bool                   `json:"secure"`
+	Virtualized       bool                   `json:"virtualized"`
+	Interfaces        []NodeNetworkInterface `json:"interfaces"`
+	// FarmingPolicy is set only if requested with expand=policy
+	FarmingPolicy *FarmingPolicy `json:"farmingPolicy,omitempty" expand:"policy"`
 }
 
 type Twin struct {
@@ -240,6 +349,31 @@ type Contract struct {
 	Billing    []ContractBilling `json:"billing"`
 }
 
+// NodeContract is an active node contract with the resources it uses
+type NodeContract struct {
+	ContractID        uint     `json:"contractId"`
+	TwinID            uint     `json:"twinId"`
+	State             string   `json:"state"`
+	CreatedAt         uint     `json:"created_at"`
+	DeploymentData    string   `json:"deployment_data"`
+	DeploymentHash    string   `json:"deployment_hash"`
+	NumberOfPublicIps uint     `json:"number_of_public_ips"`
+	Resources         Capacity `json:"resources"`
+}
+
+// NodeContracts is what runs on a node, the used resources of the node are
+// the sum of its contracts resources and the resources reserved by zos
+type NodeContracts struct {
+	NodeID        int            `json:"nodeId"`
+	Contracts     []NodeContract `json:"contracts"`
+	RentContract  *Contract      `json:"rentContract"`
+	Sum           Capacity       `json:"sum"`
+	Reserved      Capacity       `json:"reserved"`
+	UsedResources Capacity       `json:"used_resources"`
+	// Reconciled is true if the sum and the reserved resources add up to the used resources
+	Reconciled bool `json:"reconciled"`
+}
+
 type Version struct {
 	Version string `json:"version"`
 }
@@ -264,6 +398,13 @@ type NodeStatistics struct {
 	Users  NodeStatisticsUsers     `json:"users"`
 }
 
+// NodeStatisticsResult is the statistics of a node, or the error of getting them
+type NodeStatisticsResult struct {
+	NodeID     uint32          `json:"nodeId"`
+	Statistics *NodeStatistics `json:"statistics,omitempty"`
+	Error      *Error          `json:"error,omitempty"`
+}
+
 // NodeStatus is used for status endpoint to decode json in
 type NodeStatus struct {
 	Status string `json:"status"`
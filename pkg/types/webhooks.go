@@ -0,0 +1,78 @@
+package types
+
+const (
+	// ContractEventState is sent when the state of a contract changes
+	ContractEventState = "contract_state"
+	// PublicIPEventFreed is sent when the contract using a public ip ends
+	PublicIPEventFreed = "public_ip_freed"
+)
+
+// WebhookEventTypes are the types of the events a webhook can subscribe to
+var WebhookEventTypes = []string{NodeEventStatus, NodeEventRent, NodeEventCapacity, ContractEventState, PublicIPEventFreed}
+
+// ContractEvent is a change of a contract state detected between two polls of the database
+type ContractEvent struct {
+	ContractID   uint   `json:"contractId"`
+	ContractType string `json:"contractType"`
+	TwinID       uint   `json:"twinId"`
+	NodeID       uint   `json:"nodeId"`
+	FarmID       uint   `json:"farmId"`
+	// PreviousState is empty for the contracts created since the last poll
+	PreviousState string `json:"previousState"`
+	State         string `json:"state"`
+}
+
+// PublicIPEvent is a change of the contract using a public ip detected between two polls of the database
+type PublicIPEvent struct {
+	IP                 string `json:"ip"`
+	FarmID             uint   `json:"farmId"`
+	PreviousContractID uint   `json:"previousContractId"`
+	ContractID         uint   `json:"contractId"`
+}
+
+// WebhookEvent is the body of a webhook delivery
+type WebhookEvent struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Timestamp int64  `json:"timestamp"`
+	// Data is a NodeEvent, ContractEvent or PublicIPEvent depending on the type
+	Data interface{} `json:"data"`
+}
+
+// WebhookFilter selects the events delivered to a webhook, an event is delivered if it matches all the set fields
+type WebhookFilter struct {
+	Types []string `json:"types,omitempty"`
+	// FarmID matches the events of the nodes, node and rent contracts and public ips of the farm
+	FarmID *uint64 `json:"farmId,omitempty"`
+	// NodeIDs matches the events of the nodes and their node and rent contracts
+	NodeIDs []uint64 `json:"nodeIds,omitempty"`
+	// TwinID matches the events of the node with the twin and the contracts of the twin
+	TwinID *uint64 `json:"twinId,omitempty"`
+	// NodeStatus matches the node events with the node in the status after the change
+	NodeStatus *string `json:"nodeStatus,omitempty"`
+	// ContractState matches the contract events with the contract in the state after the change
+	ContractState *string `json:"contractState,omitempty"`
+}
+
+// WebhookSubscription is a url the matching events are posted to
+type WebhookSubscription struct {
+	ID     uint64        `json:"id"`
+	URL    string        `json:"url"`
+	Filter WebhookFilter `json:"filter"`
+	// Secret signs the deliveries and authorizes managing the webhook, it's only returned when the webhook is created
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// WebhookDelivery is an attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID         uint64 `json:"id"`
+	WebhookID  uint64 `json:"webhookId"`
+	EventID    string `json:"eventId"`
+	EventType  string `json:"eventType"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"statusCode"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	Timestamp  int64  `json:"timestamp"`
+}
@@ -0,0 +1,84 @@
+package types
+
+import "github.com/threefoldtech/zos/pkg/gridtypes"
+
+// NodeVersion is the versions of the software running on a node
+type NodeVersion struct {
+	ZOS        string `json:"zos"`
+	ZInit      string `json:"zinit"`
+	Hypervisor string `json:"hypervisor"`
+}
+
+// DMI is the hardware info of a node decoded from its DMI table
+type DMI struct {
+	Tooling  DMITooling   `json:"tooling"`
+	Sections []DMISection `json:"sections"`
+}
+
+// DMITooling is the tools used to read the DMI table
+type DMITooling struct {
+	Aggregator string `json:"aggregator"`
+	Decoder    string `json:"decoder"`
+}
+
+// DMISection is a DMI table entry, like the bios or the memory devices
+type DMISection struct {
+	HandleLine  string          `json:"handleline"`
+	TypeStr     string          `json:"typestr,omitempty"`
+	Type        int             `json:"typenum"`
+	SubSections []DMISubSection `json:"subsections"`
+}
+
+// DMISubSection is a titled group of properties of a DMI section
+type DMISubSection struct {
+	Title      string                     `json:"title"`
+	Properties map[string]DMIPropertyData `json:"properties,omitempty"`
+}
+
+// DMIPropertyData is the value of a DMI property, a property can have a list of items
+type DMIPropertyData struct {
+	Value string   `json:"value"`
+	Items []string `json:"items,omitempty"`
+}
+
+// NodeInterfaces is the network interfaces of a node and its public config
+type NodeInterfaces struct {
+	// Interfaces maps the name of each interface to its ips
+	Interfaces map[string][]string `json:"interfaces"`
+	// PublicConfig is nil if the node has no public config
+	PublicConfig *NodePublicConfig `json:"publicConfig"`
+}
+
+// NodePublicConfig is the public network config of a node as reported by the node
+type NodePublicConfig struct {
+	Type   string `json:"type"`
+	IPv4   string `json:"ipv4"`
+	IPv6   string `json:"ipv6"`
+	GW4    string `json:"gw4"`
+	GW6    string `json:"gw6"`
+	Domain string `json:"domain"`
+}
+
+// NodeGPU is a gpu on a node, the contract is the node contract using it if any
+type NodeGPU struct {
+	ID       string `json:"id"`
+	Vendor   string `json:"vendor"`
+	Device   string `json:"device"`
+	Contract uint64 `json:"contract"`
+}
+
+// NodeNetworkInterface is a network interface of a node as registered on the chain
+type NodeNetworkInterface struct {
+	Name string `json:"name"`
+	Mac  string `json:"mac"`
+	// IPs is the comma separated ips of the interface
+	IPs string `json:"ips"`
+}
+
+// StoragePool is a storage pool of a node
+type StoragePool struct {
+	Name string         `json:"name"`
+	Type string         `json:"type"`
+	Size gridtypes.Unit `json:"size"`
+	Used gridtypes.Unit `json:"used"`
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
+)
+
+// nodeChangedAfter returns true if the node is in the change log after the given change id, the changes
+// may be recorded by the tested proxy too
+func nodeChangedAfter(t *testing.T, database db.Database, after uint64, nodeID uint64) bool {
+	changes, err := database.GetChanges(after, 999999999)
+	assert.NoError(t, err)
+	for _, change := range changes {
+		if change.Kind == db.ChangeNode && change.ObjectID == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestChanges(t *testing.T) {
+	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s "+
+		"password=%s dbname=%s sslmode=disable",
+		POSTGRES_HOST, POSTGRES_PORT, POSTGRES_USER, POSTGRES_PASSSWORD, POSTGRES_DB)
+	sqlDB, err := sql.Open("postgres", psqlInfo)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to open db"))
+	}
+	defer sqlDB.Close()
+	database, err := db.NewPostgresDatabase(POSTGRES_HOST, POSTGRES_PORT, POSTGRES_USER, POSTGRES_PASSSWORD, POSTGRES_DB, db.DefaultNodeStatusConfig())
+	if err != nil {
+		panic(errors.Wrap(err, "failed to open db"))
+	}
+
+	var nodeID uint64
+	var country string
+	err = sqlDB.QueryRow("SELECT node_id, country FROM node ORDER BY node_id LIMIT 1").Scan(&nodeID, &country)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to get a node"))
+	}
+	// the status of the nodes depends on the time, all the runs use the same one
+	now := time.Now().Unix()
+	_, err = database.RecordChanges(now)
+	assert.NoError(t, err)
+
+	t.Run("unchanged objects aren't logged", func(t *testing.T) {
+		count, err := database.RecordChanges(now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("uptime reports aren't logged", func(t *testing.T) {
+		last, err := database.GetLastChangeID()
+		assert.NoError(t, err)
+		_, err = sqlDB.Exec("UPDATE node SET uptime = uptime + 60, updated_at = updated_at + 1 WHERE node_id = $1", nodeID)
+		assert.NoError(t, err)
+		defer func() {
+			_, err := sqlDB.Exec("UPDATE node SET uptime = uptime - 60, updated_at = updated_at - 1 WHERE node_id = $1", nodeID)
+			assert.NoError(t, err)
+		}()
+		_, err = database.RecordChanges(now)
+		assert.NoError(t, err)
+		assert.False(t, nodeChangedAfter(t, database, last, nodeID), "uptime report logged as a node change")
+	})
+
+	t.Run("node changes are logged", func(t *testing.T) {
+		last, err := database.GetLastChangeID()
+		assert.NoError(t, err)
+		_, err = sqlDB.Exec("UPDATE node SET country = $1 WHERE node_id = $2", country+"-changed", nodeID)
+		assert.NoError(t, err)
+		_, err = database.RecordChanges(now)
+		assert.NoError(t, err)
+		assert.True(t, nodeChangedAfter(t, database, last, nodeID), "node change isn't logged")
+
+		last, err = database.GetLastChangeID()
+		assert.NoError(t, err)
+		_, err = sqlDB.Exec("UPDATE node SET country = $1 WHERE node_id = $2", country, nodeID)
+		assert.NoError(t, err)
+		_, err = database.RecordChanges(now)
+		assert.NoError(t, err)
+		assert.True(t, nodeChangedAfter(t, database, last, nodeID), "node change isn't logged")
+	})
+
+	t.Run("changes are pruned by age", func(t *testing.T) {
+		_, err := database.PruneChanges(now - int64((30 * 24 * time.Hour).Seconds()))
+		assert.NoError(t, err)
+		var oldChanges int64
+		err = sqlDB.QueryRow("SELECT count(*) FROM change_log WHERE timestamp < $1", now-int64((30*24*time.Hour).Seconds())).Scan(&oldChanges)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), oldChanges)
+	})
+}
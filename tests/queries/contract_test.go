@@ -23,8 +23,11 @@ type ContractsAggregate struct {
 	Names            []string
 	DeploymentDatas  []string
 	DeploymentHashes []string
+	FarmIDs          []uint64
 
 	maxNumberOfPublicIPs uint64
+	minCreatedAt         uint64
+	maxCreatedAt         uint64
 }
 
 const (
@@ -101,6 +104,7 @@ func TestContracts(t *testing.T) {
 
 func calcContractsAggregates(data *DBData) (res ContractsAggregate) {
 	types := make(map[string]struct{})
+	res.minCreatedAt = ^uint64(0)
 	for _, contract := range data.nodeContracts {
 		res.contractIDs = append(res.contractIDs, contract.contract_id)
 		res.maxNumberOfPublicIPs = max(res.maxNumberOfPublicIPs, contract.number_of_public_i_ps)
@@ -109,8 +113,27 @@ func calcContractsAggregates(data *DBData) (res ContractsAggregate) {
 		res.NodeIDs = append(res.NodeIDs, contract.node_id)
 		res.States = append(res.States, contract.state)
 		res.TwinIDs = append(res.TwinIDs, contract.twin_id)
+		res.minCreatedAt = min(res.minCreatedAt, contract.created_at)
+		res.maxCreatedAt = max(res.maxCreatedAt, contract.created_at)
 		types["node"] = struct{}{}
 	}
+	for _, contract := range data.rentContracts {
+		res.minCreatedAt = min(res.minCreatedAt, contract.created_at)
+		res.maxCreatedAt = max(res.maxCreatedAt, contract.created_at)
+		types["rent"] = struct{}{}
+	}
+	for _, contract := range data.nameContracts {
+		res.minCreatedAt = min(res.minCreatedAt, contract.created_at)
+		res.maxCreatedAt = max(res.maxCreatedAt, contract.created_at)
+		res.Names = append(res.Names, contract.name)
+		types["name"] = struct{}{}
+	}
+	for farmID := range data.farms {
+		res.FarmIDs = append(res.FarmIDs, farmID)
+	}
+	if res.minCreatedAt > res.maxCreatedAt {
+		res.minCreatedAt = res.maxCreatedAt
+	}
 
 	for typ := range types {
 		res.Types = append(res.Types, typ)
@@ -118,6 +141,9 @@ func calcContractsAggregates(data *DBData) (res ContractsAggregate) {
 	sort.Slice(res.contractIDs, func(i, j int) bool {
 		return res.contractIDs[i] < res.contractIDs[j]
 	})
+	sort.Slice(res.FarmIDs, func(i, j int) bool {
+		return res.FarmIDs[i] < res.FarmIDs[j]
+	})
 	sort.Slice(res.TwinIDs, func(i, j int) bool {
 		return res.TwinIDs[i] < res.TwinIDs[j]
 	})
@@ -179,9 +205,61 @@ func randomContractsFilter(agg *ContractsAggregate) proxytypes.ContractFilter {
 		c := agg.DeploymentHashes[rand.Intn(len(agg.DeploymentHashes))]
 		f.DeploymentHash = &c
 	}
+	if flip(.1) {
+		f.States = randomStrs(agg.States)
+	}
+	if flip(.1) {
+		f.Types = randomStrs(agg.Types)
+	}
+	if flip(.1) {
+		f.CreatedAfter = rndref(agg.minCreatedAt, agg.maxCreatedAt)
+	}
+	if flip(.1) {
+		f.CreatedBefore = rndref(agg.minCreatedAt, agg.maxCreatedAt)
+	}
+	if flip(.1) {
+		f.NodeIDs = randomIDs(agg.NodeIDs)
+	}
+	if flip(.1) {
+		f.TwinIDs = randomIDs(agg.TwinIDs)
+	}
+	if flip(.1) {
+		f.ContractIDs = randomIDs(agg.contractIDs)
+	}
+	if flip(.1) && len(agg.FarmIDs) != 0 {
+		c := agg.FarmIDs[rand.Intn(len(agg.FarmIDs))]
+		f.FarmID = &c
+	}
+	if flip(.1) {
+		v := flip(.5)
+		f.HasPublicIPs = &v
+	}
+	if flip(.1) && len(agg.Names) != 0 {
+		c := agg.Names[rand.Intn(len(agg.Names))]
+		c = c[:rand.Intn(len(c)+1)]
+		f.NameContains = &c
+	}
 	return f
 }
 
+// randomStrs returns up to 3 random items of the list
+func randomStrs(l []string) []string {
+	var res []string
+	for i := rand.Intn(3) + 1; i > 0 && len(l) != 0; i-- {
+		res = append(res, l[rand.Intn(len(l))])
+	}
+	return res
+}
+
+// randomIDs returns up to 3 random items of the list
+func randomIDs(l []uint64) []uint64 {
+	var res []uint64
+	for i := rand.Intn(3) + 1; i > 0 && len(l) != 0; i-- {
+		res = append(res, l[rand.Intn(len(l))])
+	}
+	return res
+}
+
 func validateContractsResults(local, remote []proxytypes.Contract) error {
 	iter := local
 	if len(remote) < len(local) {
@@ -276,5 +354,35 @@ func serializeContractsFilter(f proxytypes.ContractFilter) string {
 	if f.DeploymentHash != nil {
 		res = fmt.Sprintf("%sDeploymentHash: %s\n", res, *f.DeploymentHash)
 	}
+	if len(f.States) != 0 {
+		res = fmt.Sprintf("%sStates: %v\n", res, f.States)
+	}
+	if len(f.Types) != 0 {
+		res = fmt.Sprintf("%sTypes: %v\n", res, f.Types)
+	}
+	if f.CreatedAfter != nil {
+		res = fmt.Sprintf("%sCreatedAfter: %d\n", res, *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		res = fmt.Sprintf("%sCreatedBefore: %d\n", res, *f.CreatedBefore)
+	}
+	if len(f.NodeIDs) != 0 {
+		res = fmt.Sprintf("%sNodeIDs: %v\n", res, f.NodeIDs)
+	}
+	if len(f.TwinIDs) != 0 {
+		res = fmt.Sprintf("%sTwinIDs: %v\n", res, f.TwinIDs)
+	}
+	if f.FarmID != nil {
+		res = fmt.Sprintf("%sFarmID: %d\n", res, *f.FarmID)
+	}
+	if f.HasPublicIPs != nil {
+		res = fmt.Sprintf("%sHasPublicIPs: %t\n", res, *f.HasPublicIPs)
+	}
+	if f.NameContains != nil {
+		res = fmt.Sprintf("%sNameContains: %s\n", res, *f.NameContains)
+	}
+	if len(f.ContractIDs) != 0 {
+		res = fmt.Sprintf("%sContractIDs: %v\n", res, f.ContractIDs)
+	}
 	return res
 }
@@ -41,6 +41,18 @@ func TestCounters(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("counters standby test", func(t *testing.T) {
+		f := proxytypes.StatsFilter{
+			Status: &STATUS_STANDBY,
+		}
+		counters, err := localClient.Counters(f)
+		assert.NoError(t, err)
+		remote, err := proxyClient.Counters(f)
+		assert.NoError(t, err)
+		err = validateCountersResults(counters, remote)
+		assert.NoError(t, err)
+	})
+
 	t.Run("counters all test", func(t *testing.T) {
 		f := proxytypes.StatsFilter{}
 		counters, err := localClient.Counters(f)
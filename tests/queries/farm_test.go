@@ -25,9 +25,13 @@ type FarmsAggregate struct {
 	farmIDs          []uint64
 	twinIDs          []uint64
 	certifications   []string
+	countries        []string
 
-	maxFreeIPs  uint64
-	maxTotalIPs uint64
+	maxFreeIPs     uint64
+	maxTotalIPs    uint64
+	maxFarmNodes   uint64
+	maxNodeFreeMRU uint64
+	maxNodeFreeSRU uint64
 }
 
 func TestFarm(t *testing.T) {
@@ -116,6 +120,19 @@ func calcFarmsAggregates(data *DBData) (res FarmsAggregate) {
 		res.maxTotalIPs = max(res.maxTotalIPs, cnt)
 	}
 
+	farmNodes := make(map[uint64]uint64)
+	for _, node := range data.nodes {
+		farmNodes[node.farm_id]++
+		res.countries = append(res.countries, node.country)
+		free := calcFreeResources(data.nodeTotalResources[node.node_id], data.nodeUsedResources[node.node_id])
+		res.maxNodeFreeMRU = max(res.maxNodeFreeMRU, free.mru)
+		res.maxNodeFreeSRU = max(res.maxNodeFreeSRU, free.sru)
+	}
+	for _, cnt := range farmNodes {
+		res.maxFarmNodes = max(res.maxFarmNodes, cnt)
+	}
+	sort.Strings(res.countries)
+
 	sort.Slice(res.stellarAddresses, func(i, j int) bool {
 		return res.stellarAddresses[i] < res.stellarAddresses[j]
 	})
@@ -187,6 +204,42 @@ func randomFarmsFilter(agg *FarmsAggregate) proxytypes.FarmFilter {
 		}
 		f.Dedicated = &v
 	}
+	if flip(.1) {
+		f.ExcludeFarmIDs = randomIDs(agg.farmIDs)
+	}
+	if flip(.1) {
+		f.ExcludeTwinIDs = randomIDs(agg.twinIDs)
+	}
+	if flip(.1) {
+		f.FarmIDs = randomIDs(agg.farmIDs)
+	}
+	if flip(.1) {
+		c := agg.certifications[rand.Intn(len(agg.certifications))]
+		f.CertificationTypeNot = &c
+	}
+	if flip(.1) {
+		f.NodeCountMin = rndref(0, agg.maxFarmNodes)
+	}
+	if flip(.1) {
+		f.UpNodesMin = rndref(0, agg.maxFarmNodes)
+	}
+	if flip(.1) {
+		f.RentableNodesMin = rndref(0, agg.maxFarmNodes)
+	}
+	if flip(.1) && len(agg.countries) != 0 {
+		c := changeCase(agg.countries[rand.Intn(len(agg.countries))])
+		f.Country = &c
+	}
+	if flip(.1) {
+		f.NodeFreeMRU = rndref(0, agg.maxNodeFreeMRU)
+	}
+	if flip(.1) {
+		f.NodeFreeSRU = rndref(0, agg.maxNodeFreeSRU)
+	}
+	if flip(.1) {
+		c := []string{"Diy", "Certified"}[rand.Intn(2)]
+		f.NodeCertificationType = &c
+	}
 
 	return f
 }
@@ -283,5 +336,38 @@ func serializeFarmsFilter(f proxytypes.FarmFilter) string {
 	if f.Dedicated != nil {
 		res = fmt.Sprintf("%sDedicated: %t\n", res, *f.Dedicated)
 	}
+	if len(f.ExcludeFarmIDs) != 0 {
+		res = fmt.Sprintf("%sExcludeFarmIDs: %v\n", res, f.ExcludeFarmIDs)
+	}
+	if len(f.ExcludeTwinIDs) != 0 {
+		res = fmt.Sprintf("%sExcludeTwinIDs: %v\n", res, f.ExcludeTwinIDs)
+	}
+	if len(f.FarmIDs) != 0 {
+		res = fmt.Sprintf("%sFarmIDs: %v\n", res, f.FarmIDs)
+	}
+	if f.CertificationTypeNot != nil {
+		res = fmt.Sprintf("%sCertificationTypeNot: %s\n", res, *f.CertificationTypeNot)
+	}
+	if f.NodeCountMin != nil {
+		res = fmt.Sprintf("%sNodeCountMin: %d\n", res, *f.NodeCountMin)
+	}
+	if f.UpNodesMin != nil {
+		res = fmt.Sprintf("%sUpNodesMin: %d\n", res, *f.UpNodesMin)
+	}
+	if f.RentableNodesMin != nil {
+		res = fmt.Sprintf("%sRentableNodesMin: %d\n", res, *f.RentableNodesMin)
+	}
+	if f.Country != nil {
+		res = fmt.Sprintf("%sCountry: %s\n", res, *f.Country)
+	}
+	if f.NodeFreeMRU != nil {
+		res = fmt.Sprintf("%sNodeFreeMRU: %d\n", res, *f.NodeFreeMRU)
+	}
+	if f.NodeFreeSRU != nil {
+		res = fmt.Sprintf("%sNodeFreeSRU: %d\n", res, *f.NodeFreeSRU)
+	}
+	if f.NodeCertificationType != nil {
+		res = fmt.Sprintf("%sNodeCertificationType: %s\n", res, *f.NodeCertificationType)
+	}
 	return res
 }
@@ -28,6 +28,8 @@ type DBData struct {
 	billings            map[uint64][]contract_bill_report
 	contractResources   map[string]contract_resources
 	nonDeletedContracts map[uint64][]uint64
+	nodeGPUs            map[uint64][]node_gpu
+	nodeInterfaces      map[uint64][]interfaces
 	db                  *sql.DB
 }
 
@@ -50,7 +52,9 @@ func loadNodes(db *sql.DB, data *DBData) error {
 		COALESCE(serial_number, ''),
 		COALESCE(created_at, 0),
 		COALESCE(updated_at, 0),
-		COALESCE(location_id, '')
+		COALESCE(location_id, ''),
+		COALESCE(power->>'state', ''),
+		COALESCE(power->>'target', '')
 	FROM
 		node;`)
 	if err != nil {
@@ -76,6 +80,8 @@ func loadNodes(db *sql.DB, data *DBData) error {
 			&node.created_at,
 			&node.updated_at,
 			&node.location_id,
+			&node.power_state,
+			&node.power_target,
 		); err != nil {
 			return err
 		}
@@ -297,6 +303,65 @@ func loadPublicConfigs(db *sql.DB, data *DBData) error {
 	}
 	return nil
 }
+func loadNodeGPUs(db *sql.DB, data *DBData) error {
+	rows, err := db.Query(`
+	SELECT
+		COALESCE(id, ''),
+		COALESCE(node_twin_id, 0),
+		COALESCE(vendor, ''),
+		COALESCE(device, ''),
+		COALESCE(contract, 0)
+	FROM
+		node_gpu
+	ORDER BY id;`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var gpu node_gpu
+		if err := rows.Scan(
+			&gpu.id,
+			&gpu.node_twin_id,
+			&gpu.vendor,
+			&gpu.device,
+			&gpu.contract,
+		); err != nil {
+			return err
+		}
+		data.nodeGPUs[gpu.node_twin_id] = append(data.nodeGPUs[gpu.node_twin_id], gpu)
+	}
+	return nil
+}
+func loadNodeInterfaces(db *sql.DB, data *DBData) error {
+	rows, err := db.Query(`
+	SELECT
+		COALESCE(id, ''),
+		COALESCE(name, ''),
+		COALESCE(mac, ''),
+		COALESCE(ips, ''),
+		COALESCE(node_id, '')
+	FROM
+		interfaces
+	ORDER BY name, id;`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var iface interfaces
+		if err := rows.Scan(
+			&iface.id,
+			&iface.name,
+			&iface.mac,
+			&iface.ips,
+			&iface.node_id,
+		); err != nil {
+			return err
+		}
+		nodeID := data.nodeIDMap[iface.node_id]
+		data.nodeInterfaces[nodeID] = append(data.nodeInterfaces[nodeID], iface)
+	}
+	return nil
+}
 func loadContracts(db *sql.DB, data *DBData) error {
 	rows, err := db.Query(`
 	SELECT
@@ -487,6 +552,8 @@ func load(db *sql.DB) (DBData, error) {
 		nodeTotalResources:  make(map[uint64]node_resources_total),
 		nodeUsedResources:   make(map[uint64]node_resources_total),
 		nonDeletedContracts: make(map[uint64][]uint64),
+		nodeGPUs:            make(map[uint64][]node_gpu),
+		nodeInterfaces:      make(map[uint64][]interfaces),
 		db:                  db,
 	}
 	if err := loadNodes(db, &data); err != nil {
@@ -504,6 +571,12 @@ func load(db *sql.DB) (DBData, error) {
 	if err := loadPublicIPs(db, &data); err != nil {
 		return data, err
 	}
+	if err := loadNodeGPUs(db, &data); err != nil {
+		return data, err
+	}
+	if err := loadNodeInterfaces(db, &data); err != nil {
+		return data, err
+	}
 	if err := loadContracts(db, &data); err != nil {
 		return data, err
 	}
@@ -35,10 +35,7 @@ func (g *GridProxyClientimpl) Nodes(filter proxytypes.NodeFilter, limit proxytyp
 	}
 	for _, node := range g.data.nodes {
 		if nodeSatisfies(&g.data, node, filter) {
-			status := STATUS_DOWN
-			if isUp(node.updated_at) {
-				status = STATUS_UP
-			}
+			status := nodeStatus(node)
 			res = append(res, proxytypes.Node{
 				ID:              node.id,
 				NodeID:          int(node.node_id),
@@ -80,6 +77,9 @@ func (g *GridProxyClientimpl) Nodes(filter proxytypes.NodeFilter, limit proxytyp
 				RentedByTwinID:    uint(g.data.nodeRentedBy[node.node_id]),
 				RentContractID:    uint(g.data.nodeRentContractID[node.node_id]),
 				SerialNumber:      node.serial_number,
+				GPUs:              nodeGPUs(g.data, node),
+				Secure:            node.secure,
+				Virtualized:       node.virtualized,
 			})
 		}
 	}
@@ -172,7 +172,7 @@ func (g *GridProxyClientimpl) Contracts(filter proxytypes.ContractFilter, limit
 		})
 	}
 	for _, contract := range g.data.nodeContracts {
-		if nodeContractsSatisfies(contract, filter) {
+		if nodeContractsSatisfies(contract, filter) && g.contractSatisfies(filter, "node", contract.state, "", contract.node_id, contract.twin_id, contract.created_at, contract.number_of_public_i_ps) {
 			contract := proxytypes.Contract{
 				ContractID: uint(contract.contract_id),
 				TwinID:     uint(contract.twin_id),
@@ -191,7 +191,7 @@ func (g *GridProxyClientimpl) Contracts(filter proxytypes.ContractFilter, limit
 		}
 	}
 	for _, contract := range g.data.rentContracts {
-		if rentContractsSatisfies(contract, filter) {
+		if rentContractsSatisfies(contract, filter) && g.contractSatisfies(filter, "rent", contract.state, "", contract.node_id, contract.twin_id, contract.created_at, 0) {
 			contract := proxytypes.Contract{
 				ContractID: uint(contract.contract_id),
 				TwinID:     uint(contract.twin_id),
@@ -207,7 +207,7 @@ func (g *GridProxyClientimpl) Contracts(filter proxytypes.ContractFilter, limit
 		}
 	}
 	for _, contract := range g.data.nameContracts {
-		if nameContractsSatisfies(contract, filter) {
+		if nameContractsSatisfies(contract, filter) && g.contractSatisfies(filter, "name", contract.state, contract.name, 0, contract.twin_id, contract.created_at, 0) {
 			contract := proxytypes.Contract{
 				ContractID: uint(contract.contract_id),
 				TwinID:     uint(contract.twin_id),
@@ -277,10 +277,7 @@ func (g *GridProxyClientimpl) Twins(filter proxytypes.TwinFilter, limit proxytyp
 }
 func (g *GridProxyClientimpl) Node(nodeID uint32) (res proxytypes.NodeWithNestedCapacity, err error) {
 	node := g.data.nodes[uint64(nodeID)]
-	status := STATUS_DOWN
-	if isUp(node.updated_at) {
-		status = STATUS_UP
-	}
+	status := nodeStatus(node)
 	res = proxytypes.NodeWithNestedCapacity{
 		ID:              node.id,
 		NodeID:          int(node.node_id),
@@ -324,16 +321,17 @@ func (g *GridProxyClientimpl) Node(nodeID uint32) (res proxytypes.NodeWithNested
 		RentedByTwinID:    uint(g.data.nodeRentedBy[node.node_id]),
 		RentContractID:    uint(g.data.nodeRentContractID[node.node_id]),
 		SerialNumber:      node.serial_number,
+		GPUs:              nodeGPUs(g.data, node),
+		Secure:            node.secure,
+		Virtualized:       node.virtualized,
+		Interfaces:        nodeInterfaces(g.data, node),
 	}
 	return
 }
 
 func (g *GridProxyClientimpl) NodeStatus(nodeID uint32) (res proxytypes.NodeStatus, err error) {
 	node := g.data.nodes[uint64(nodeID)]
-	res.Status = STATUS_DOWN
-	if isUp(node.updated_at) {
-		res.Status = STATUS_UP
-	}
+	res.Status = nodeStatus(node)
 	return
 }
 
@@ -346,7 +344,7 @@ func (g *GridProxyClientimpl) Counters(filter proxytypes.StatsFilter) (res proxy
 	res.Contracts += int64(len(g.data.nameContracts))
 	distribution := map[string]int64{}
 	for _, node := range g.data.nodes {
-		if filter.Status == nil || (*filter.Status == STATUS_UP && isUp(node.updated_at)) {
+		if filter.Status == nil || *filter.Status == nodeStatus(node) {
 			res.Nodes++
 			distribution[node.country] += 1
 			res.TotalCRU += int64(g.data.nodeTotalResources[node.node_id].cru)
@@ -367,10 +365,72 @@ func (g *GridProxyClientimpl) Counters(filter proxytypes.StatsFilter) (res proxy
 	return
 }
 
+func nodeGPUs(data DBData, node node) []proxytypes.NodeGPU {
+	gpus := []proxytypes.NodeGPU{}
+	for _, gpu := range data.nodeGPUs[node.twin_id] {
+		gpus = append(gpus, proxytypes.NodeGPU{
+			ID:       gpu.id,
+			Vendor:   gpu.vendor,
+			Device:   gpu.device,
+			Contract: gpu.contract,
+		})
+	}
+	return gpus
+}
+
+func nodeInterfaces(data DBData, node node) []proxytypes.NodeNetworkInterface {
+	interfaces := []proxytypes.NodeNetworkInterface{}
+	for _, iface := range data.nodeInterfaces[node.node_id] {
+		interfaces = append(interfaces, proxytypes.NodeNetworkInterface{
+			Name: iface.name,
+			Mac:  iface.mac,
+			IPs:  iface.ips,
+		})
+	}
+	return interfaces
+}
+
+func nodeHasInterfaceIP(data *DBData, node node) bool {
+	for _, iface := range data.nodeInterfaces[node.node_id] {
+		if iface.ips != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func gpuSatisfies(gpu node_gpu, f proxytypes.NodeFilter) bool {
+	if f.GPUVendor != nil && !strings.Contains(strings.ToLower(gpu.vendor), strings.ToLower(*f.GPUVendor)) {
+		return false
+	}
+	if f.GPUDevice != nil && !strings.Contains(strings.ToLower(gpu.device), strings.ToLower(*f.GPUDevice)) {
+		return false
+	}
+	if f.GPUAvailable != nil && *f.GPUAvailable != (gpu.contract == 0) {
+		return false
+	}
+	return true
+}
+
 func nodeSatisfies(data *DBData, node node, f proxytypes.NodeFilter) bool {
-	if f.Status != nil && (*f.Status == STATUS_UP) != isUp(node.updated_at) {
+	if f.Status != nil && *f.Status != nodeStatus(node) {
+		return false
+	}
+	if f.HasGPU != nil && *f.HasGPU != (len(data.nodeGPUs[node.twin_id]) != 0) {
 		return false
 	}
+	if f.GPUVendor != nil || f.GPUDevice != nil || f.GPUAvailable != nil {
+		found := false
+		for _, gpu := range data.nodeGPUs[node.twin_id] {
+			if gpuSatisfies(gpu, f) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
 	total := data.nodeTotalResources[node.node_id]
 	used := data.nodeUsedResources[node.node_id]
 	free := calcFreeResources(total, used)
@@ -401,6 +461,39 @@ func nodeSatisfies(data *DBData, node node, f proxytypes.NodeFilter) bool {
 	if f.TotalSRU != nil && *f.TotalSRU > total.sru {
 		return false
 	}
+	if f.FreeMRUMax != nil && *f.FreeMRUMax < free.mru {
+		return false
+	}
+	if f.FreeHRUMax != nil && *f.FreeHRUMax < free.hru {
+		return false
+	}
+	if f.FreeSRUMax != nil && *f.FreeSRUMax < free.sru {
+		return false
+	}
+	if f.TotalCRUMax != nil && *f.TotalCRUMax < total.cru {
+		return false
+	}
+	if f.TotalHRUMax != nil && *f.TotalHRUMax < total.hru {
+		return false
+	}
+	if f.TotalMRUMax != nil && *f.TotalMRUMax < total.mru {
+		return false
+	}
+	if f.TotalSRUMax != nil && *f.TotalSRUMax < total.sru {
+		return false
+	}
+	if f.UptimeMin != nil && *f.UptimeMin > node.uptime {
+		return false
+	}
+	if f.UptimeMax != nil && *f.UptimeMax < node.uptime {
+		return false
+	}
+	if f.CreatedAfter != nil && *f.CreatedAfter > node.created {
+		return false
+	}
+	if f.CreatedBefore != nil && *f.CreatedBefore <= node.created {
+		return false
+	}
 	if f.NodeID != nil && *f.NodeID != node.node_id {
 		return false
 	}
@@ -422,6 +515,36 @@ func nodeSatisfies(data *DBData, node node, f proxytypes.NodeFilter) bool {
 	if f.FarmIDs != nil && !isIn(f.FarmIDs, node.farm_id) {
 		return false
 	}
+	if isIn(f.ExcludeFarmIDs, node.farm_id) || isIn(f.ExcludeNodeIDs, node.node_id) || isIn(f.ExcludeTwinIDs, node.twin_id) {
+		return false
+	}
+	if f.CountryNot != nil && strings.EqualFold(*f.CountryNot, node.country) {
+		return false
+	}
+	if f.CertificationType != nil && !strings.EqualFold(*f.CertificationType, node.certification) {
+		return false
+	}
+	if f.CertificationTypeNot != nil && strings.EqualFold(*f.CertificationTypeNot, node.certification) {
+		return false
+	}
+	if f.Secure != nil && *f.Secure != node.secure {
+		return false
+	}
+	if f.Virtualized != nil && *f.Virtualized != node.virtualized {
+		return false
+	}
+	if f.GridVersion != nil && *f.GridVersion != node.grid_version {
+		return false
+	}
+	if f.HasInterfaceIP != nil && *f.HasInterfaceIP != nodeHasInterfaceIP(data, node) {
+		return false
+	}
+	if len(f.NodeIDs) != 0 && !isIn(f.NodeIDs, node.node_id) {
+		return false
+	}
+	if f.UpdatedAfter != nil && *f.UpdatedAfter > node.updated_at {
+		return false
+	}
 	if f.FreeIPs != nil && *f.FreeIPs > data.FreeIPs[node.farm_id] {
 		return false
 	}
@@ -434,9 +557,7 @@ func nodeSatisfies(data *DBData, node node, f proxytypes.NodeFilter) bool {
 	if f.Domain != nil && *f.Domain && data.publicConfigs[node.node_id].domain == "" {
 		return false
 	}
-	rentable := data.nodeRentedBy[node.node_id] == 0 &&
-		(data.farms[node.farm_id].dedicated_farm || len(data.nonDeletedContracts[node.node_id]) == 0)
-	if f.Rentable != nil && *f.Rentable != rentable {
+	if f.Rentable != nil && *f.Rentable != nodeRentable(data, node) {
 		return false
 	}
 	if f.RentedBy != nil && *f.RentedBy != data.nodeRentedBy[node.node_id] {
@@ -461,6 +582,69 @@ func twinSatisfies(twin twin, f proxytypes.TwinFilter) bool {
 	if f.AccountID != nil && twin.account_id != *f.AccountID {
 		return false
 	}
+	if len(f.TwinIDs) != 0 && !isIn(f.TwinIDs, twin.twin_id) {
+		return false
+	}
+	if f.Relay != nil && twin.relay != *f.Relay {
+		return false
+	}
+	if f.PublicKey != nil && twin.public_key != *f.PublicKey {
+		return false
+	}
+	if f.RelayContains != nil && !stringMatch(twin.relay, *f.RelayContains) {
+		return false
+	}
+	if len(f.AccountIDs) != 0 && !accountIn(f.AccountIDs, twin.account_id) {
+		return false
+	}
+	return true
+}
+
+func nodeRentable(data *DBData, node node) bool {
+	return data.nodeRentedBy[node.node_id] == 0 &&
+		(data.farms[node.farm_id].dedicated_farm || len(data.nonDeletedContracts[node.node_id]) == 0)
+}
+
+// farmNodesSatisfies checks the farm nodes counts among the nodes satisfying the farm node filters
+func farmNodesSatisfies(data *DBData, farm farm, f proxytypes.FarmFilter) bool {
+	var nodes, upNodes, rentableNodes uint64
+	for _, node := range data.nodes {
+		if node.farm_id != farm.farm_id {
+			continue
+		}
+		if f.Country != nil && !strings.EqualFold(*f.Country, node.country) {
+			continue
+		}
+		free := calcFreeResources(data.nodeTotalResources[node.node_id], data.nodeUsedResources[node.node_id])
+		if f.NodeFreeMRU != nil && *f.NodeFreeMRU > free.mru {
+			continue
+		}
+		if f.NodeFreeSRU != nil && *f.NodeFreeSRU > free.sru {
+			continue
+		}
+		if f.NodeCertificationType != nil && !strings.EqualFold(*f.NodeCertificationType, node.certification) {
+			continue
+		}
+		nodes++
+		if nodeStatus(node) == STATUS_UP {
+			upNodes++
+		}
+		if nodeRentable(data, node) {
+			rentableNodes++
+		}
+	}
+	if (f.Country != nil || f.NodeFreeMRU != nil || f.NodeFreeSRU != nil || f.NodeCertificationType != nil) && nodes == 0 {
+		return false
+	}
+	if f.NodeCountMin != nil && *f.NodeCountMin > nodes {
+		return false
+	}
+	if f.UpNodesMin != nil && *f.UpNodesMin > upNodes {
+		return false
+	}
+	if f.RentableNodesMin != nil && *f.RentableNodesMin > rentableNodes {
+		return false
+	}
 	return true
 }
 
@@ -498,6 +682,53 @@ func farmSatisfies(data *DBData, farm farm, f proxytypes.FarmFilter) bool {
 	if f.Dedicated != nil && *f.Dedicated != farm.dedicated_farm {
 		return false
 	}
+	if isIn(f.ExcludeFarmIDs, farm.farm_id) || isIn(f.ExcludeTwinIDs, farm.twin_id) {
+		return false
+	}
+	if len(f.FarmIDs) != 0 && !isIn(f.FarmIDs, farm.farm_id) {
+		return false
+	}
+	if f.CertificationTypeNot != nil && *f.CertificationTypeNot == farm.certification {
+		return false
+	}
+	if !farmNodesSatisfies(data, farm, f) {
+		return false
+	}
+	return true
+}
+
+// contractSatisfies checks the filters shared by all the contract types
+func (g *GridProxyClientimpl) contractSatisfies(f proxytypes.ContractFilter, typ, state, name string, nodeID, twinID, createdAt, publicIPs uint64) bool {
+	if len(f.States) != 0 && !isInStrs(f.States, state) {
+		return false
+	}
+	if len(f.Types) != 0 && !isInStrs(f.Types, typ) {
+		return false
+	}
+	if f.CreatedAfter != nil && createdAt < *f.CreatedAfter {
+		return false
+	}
+	if f.CreatedBefore != nil && createdAt >= *f.CreatedBefore {
+		return false
+	}
+	if len(f.NodeIDs) != 0 && !isIn(f.NodeIDs, nodeID) {
+		return false
+	}
+	if len(f.TwinIDs) != 0 && !isIn(f.TwinIDs, twinID) {
+		return false
+	}
+	if f.FarmID != nil {
+		node, ok := g.data.nodes[nodeID]
+		if !ok || node.farm_id != *f.FarmID {
+			return false
+		}
+	}
+	if f.HasPublicIPs != nil && *f.HasPublicIPs != (publicIPs > 0) {
+		return false
+	}
+	if f.NameContains != nil && !stringMatch(name, *f.NameContains) {
+		return false
+	}
 	return true
 }
 
@@ -505,6 +736,9 @@ func rentContractsSatisfies(contract rent_contract, f proxytypes.ContractFilter)
 	if f.ContractID != nil && contract.contract_id != *f.ContractID {
 		return false
 	}
+	if len(f.ContractIDs) != 0 && !isIn(f.ContractIDs, contract.contract_id) {
+		return false
+	}
 	if f.TwinID != nil && contract.twin_id != *f.TwinID {
 		return false
 	}
@@ -536,6 +770,9 @@ func nameContractsSatisfies(contract name_contract, f proxytypes.ContractFilter)
 	if f.ContractID != nil && contract.contract_id != *f.ContractID {
 		return false
 	}
+	if len(f.ContractIDs) != 0 && !isIn(f.ContractIDs, contract.contract_id) {
+		return false
+	}
 	if f.TwinID != nil && contract.twin_id != *f.TwinID {
 		return false
 	}
@@ -567,6 +804,9 @@ func nodeContractsSatisfies(contract node_contract, f proxytypes.ContractFilter)
 	if f.ContractID != nil && contract.contract_id != *f.ContractID {
 		return false
 	}
+	if len(f.ContractIDs) != 0 && !isIn(f.ContractIDs, contract.contract_id) {
+		return false
+	}
 	if f.TwinID != nil && contract.twin_id != *f.TwinID {
 		return false
 	}
@@ -4,12 +4,15 @@ import (
 	"database/sql"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/threefoldtech/grid_proxy_server/internal/explorer/db"
 	proxyclient "github.com/threefoldtech/grid_proxy_server/pkg/client"
 	proxytypes "github.com/threefoldtech/grid_proxy_server/pkg/types"
 )
@@ -29,6 +32,8 @@ type NodesAggregate struct {
 	maxFreeIPs  uint64
 	nodeRenters []uint64
 	twins       []uint64
+	nodeIDs     []uint64
+	nodeTwins   []uint64
 
 	totalCRUs   []uint64
 	maxTotalCRU uint64
@@ -38,6 +43,15 @@ type NodesAggregate struct {
 	maxTotalMRU uint64
 	totalSRUs   []uint64
 	maxTotalSRU uint64
+
+	gpuVendors []string
+	gpuDevices []string
+
+	maxUptime  uint64
+	minCreated uint64
+	maxCreated uint64
+	minUpdated uint64
+	maxUpdated uint64
 }
 
 var (
@@ -187,10 +201,8 @@ func nodePaginationCheck(t *testing.T, localClient proxyclient.Client, proxyClie
 func randomNodeFilter(agg *NodesAggregate) proxytypes.NodeFilter {
 	var f proxytypes.NodeFilter
 	if flip(.5) { // status
-		status := "down"
-		if flip(.5) {
-			status = "up"
-		}
+		statuses := []string{STATUS_DOWN, STATUS_UP, STATUS_STANDBY}
+		status := statuses[rand.Intn(len(statuses))]
 		f.Status = &status
 	}
 	if flip(.5) {
@@ -249,11 +261,80 @@ func randomNodeFilter(agg *NodesAggregate) proxytypes.NodeFilter {
 			f.TotalHRU = rndref(0, agg.maxTotalHRU)
 		}
 	}
+	if flip(.1) {
+		f.FreeMRUMax = rndref(0, agg.maxFreeMRU)
+	}
+	if flip(.1) {
+		f.FreeHRUMax = rndref(0, agg.maxFreeHRU)
+	}
+	if flip(.1) {
+		f.FreeSRUMax = rndref(0, agg.maxFreeSRU)
+	}
+	if flip(.1) {
+		f.TotalCRUMax = rndref(0, agg.maxTotalCRU)
+	}
+	if flip(.1) {
+		f.TotalHRUMax = rndref(0, agg.maxTotalHRU)
+	}
+	if flip(.1) {
+		f.TotalMRUMax = rndref(0, agg.maxTotalMRU)
+	}
+	if flip(.1) {
+		f.TotalSRUMax = rndref(0, agg.maxTotalSRU)
+	}
+	if flip(.1) {
+		f.UptimeMin = rndref(0, agg.maxUptime)
+	}
+	if flip(.1) {
+		f.UptimeMax = rndref(0, agg.maxUptime)
+	}
+	if flip(.1) && agg.minCreated <= agg.maxCreated {
+		f.CreatedAfter = rndref(agg.minCreated, agg.maxCreated)
+	}
+	if flip(.1) && agg.minCreated <= agg.maxCreated {
+		f.CreatedBefore = rndref(agg.minCreated, agg.maxCreated)
+	}
 	if flip(.05) {
 		c := agg.countries[rand.Intn(len(agg.countries))]
 		v := changeCase(c)
 		f.Country = &v
 	}
+	if flip(.05) {
+		c := agg.countries[rand.Intn(len(agg.countries))]
+		v := changeCase(c)
+		f.CountryNot = &v
+	}
+	if flip(.05) {
+		f.ExcludeFarmIDs = randomIDs(agg.farmIDs)
+	}
+	if flip(.05) {
+		f.ExcludeNodeIDs = randomIDs(agg.nodeIDs)
+	}
+	if flip(.05) {
+		f.ExcludeTwinIDs = randomIDs(agg.nodeTwins)
+	}
+	if flip(.1) {
+		v := flip(.5)
+		f.Secure = &v
+	}
+	if flip(.1) {
+		v := flip(.5)
+		f.Virtualized = &v
+	}
+	if flip(.1) {
+		v := uint64(rand.Intn(2) + 2)
+		f.GridVersion = &v
+	}
+	if flip(.1) {
+		v := flip(.5)
+		f.HasInterfaceIP = &v
+	}
+	if flip(.05) {
+		f.NodeIDs = randomIDs(agg.nodeIDs)
+	}
+	if flip(.1) && agg.minUpdated <= agg.maxUpdated {
+		f.UpdatedAfter = rndref(agg.minUpdated, agg.maxUpdated)
+	}
 	if flip(.05) {
 		c := agg.countries[rand.Intn(len(agg.countries))]
 		a, b := rand.Intn(len(c)), rand.Intn(len(c))
@@ -359,13 +440,38 @@ func randomNodeFilter(agg *NodesAggregate) proxytypes.NodeFilter {
 		}
 		f.Rented = &v
 	}
+	if flip(.1) {
+		v := flip(.5)
+		f.HasGPU = &v
+	}
+	if flip(.1) && len(agg.gpuVendors) != 0 {
+		c := agg.gpuVendors[rand.Intn(len(agg.gpuVendors))]
+		f.GPUVendor = &c
+	}
+	if flip(.1) && len(agg.gpuDevices) != 0 {
+		c := agg.gpuDevices[rand.Intn(len(agg.gpuDevices))]
+		f.GPUDevice = &c
+	}
+	if flip(.1) {
+		v := flip(.5)
+		f.GPUAvailable = &v
+	}
 	return f
 }
 
 func calcNodesAggregates(data *DBData) (res NodesAggregate) {
 	cities := make(map[string]struct{})
 	countries := make(map[string]struct{})
+	res.minCreated = ^uint64(0)
+	res.minUpdated = ^uint64(0)
 	for _, node := range data.nodes {
+		res.nodeIDs = append(res.nodeIDs, node.node_id)
+		res.nodeTwins = append(res.nodeTwins, node.twin_id)
+		res.maxUptime = max(res.maxUptime, node.uptime)
+		res.minCreated = min(res.minCreated, node.created)
+		res.maxCreated = max(res.maxCreated, node.created)
+		res.minUpdated = min(res.minUpdated, node.updated_at)
+		res.maxUpdated = max(res.maxUpdated, node.updated_at)
 		cities[node.city] = struct{}{}
 		countries[node.country] = struct{}{}
 		total := data.nodeTotalResources[node.node_id]
@@ -395,6 +501,22 @@ func calcNodesAggregates(data *DBData) (res NodesAggregate) {
 	for _, twin := range data.twins {
 		res.twins = append(res.twins, twin.twin_id)
 	}
+	gpuVendors := make(map[string]struct{})
+	gpuDevices := make(map[string]struct{})
+	for _, gpus := range data.nodeGPUs {
+		for _, gpu := range gpus {
+			gpuVendors[gpu.vendor] = struct{}{}
+			gpuDevices[gpu.device] = struct{}{}
+		}
+	}
+	for vendor := range gpuVendors {
+		res.gpuVendors = append(res.gpuVendors, vendor)
+	}
+	for device := range gpuDevices {
+		res.gpuDevices = append(res.gpuDevices, device)
+	}
+	sort.Strings(res.gpuVendors)
+	sort.Strings(res.gpuDevices)
 	for city := range cities {
 		res.cities = append(res.cities, city)
 	}
@@ -483,6 +605,18 @@ func validateResults(local, remote []proxytypes.Node, unordered bool) error {
 
 func serializeFilter(f proxytypes.NodeFilter) string {
 	res := ""
+	if f.HasGPU != nil {
+		res = fmt.Sprintf("%shas_gpu: %t\n", res, *f.HasGPU)
+	}
+	if f.GPUVendor != nil {
+		res = fmt.Sprintf("%sgpu_vendor: %s\n", res, *f.GPUVendor)
+	}
+	if f.GPUDevice != nil {
+		res = fmt.Sprintf("%sgpu_device: %s\n", res, *f.GPUDevice)
+	}
+	if f.GPUAvailable != nil {
+		res = fmt.Sprintf("%sgpu_available: %t\n", res, *f.GPUAvailable)
+	}
 	if f.Status != nil {
 		res = fmt.Sprintf("%sstatus: %s\n", res, *f.Status)
 	}
@@ -507,6 +641,39 @@ func serializeFilter(f proxytypes.NodeFilter) string {
 	if f.TotalSRU != nil {
 		res = fmt.Sprintf("%sTotalSRU: %d\n", res, *f.TotalSRU)
 	}
+	if f.FreeMRUMax != nil {
+		res = fmt.Sprintf("%sFreeMRUMax: %d\n", res, *f.FreeMRUMax)
+	}
+	if f.FreeHRUMax != nil {
+		res = fmt.Sprintf("%sFreeHRUMax: %d\n", res, *f.FreeHRUMax)
+	}
+	if f.FreeSRUMax != nil {
+		res = fmt.Sprintf("%sFreeSRUMax: %d\n", res, *f.FreeSRUMax)
+	}
+	if f.TotalCRUMax != nil {
+		res = fmt.Sprintf("%sTotalCRUMax: %d\n", res, *f.TotalCRUMax)
+	}
+	if f.TotalHRUMax != nil {
+		res = fmt.Sprintf("%sTotalHRUMax: %d\n", res, *f.TotalHRUMax)
+	}
+	if f.TotalMRUMax != nil {
+		res = fmt.Sprintf("%sTotalMRUMax: %d\n", res, *f.TotalMRUMax)
+	}
+	if f.TotalSRUMax != nil {
+		res = fmt.Sprintf("%sTotalSRUMax: %d\n", res, *f.TotalSRUMax)
+	}
+	if f.UptimeMin != nil {
+		res = fmt.Sprintf("%sUptimeMin: %d\n", res, *f.UptimeMin)
+	}
+	if f.UptimeMax != nil {
+		res = fmt.Sprintf("%sUptimeMax: %d\n", res, *f.UptimeMax)
+	}
+	if f.CreatedAfter != nil {
+		res = fmt.Sprintf("%sCreatedAfter: %d\n", res, *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		res = fmt.Sprintf("%sCreatedBefore: %d\n", res, *f.CreatedBefore)
+	}
 	if f.Country != nil {
 		res = fmt.Sprintf("%sCountry: %s\n", res, *f.Country)
 	}
@@ -543,5 +710,62 @@ func serializeFilter(f proxytypes.NodeFilter) string {
 	if f.Rented != nil {
 		res = fmt.Sprintf("%sRented: %t\n", res, *f.Rented)
 	}
+	if f.CountryNot != nil {
+		res = fmt.Sprintf("%sCountryNot: %s\n", res, *f.CountryNot)
+	}
+	if len(f.ExcludeFarmIDs) != 0 {
+		res = fmt.Sprintf("%sExcludeFarmIDs: %v\n", res, f.ExcludeFarmIDs)
+	}
+	if len(f.ExcludeNodeIDs) != 0 {
+		res = fmt.Sprintf("%sExcludeNodeIDs: %v\n", res, f.ExcludeNodeIDs)
+	}
+	if len(f.ExcludeTwinIDs) != 0 {
+		res = fmt.Sprintf("%sExcludeTwinIDs: %v\n", res, f.ExcludeTwinIDs)
+	}
+	if f.Secure != nil {
+		res = fmt.Sprintf("%sSecure: %t\n", res, *f.Secure)
+	}
+	if f.Virtualized != nil {
+		res = fmt.Sprintf("%sVirtualized: %t\n", res, *f.Virtualized)
+	}
+	if f.GridVersion != nil {
+		res = fmt.Sprintf("%sGridVersion: %d\n", res, *f.GridVersion)
+	}
+	if f.HasInterfaceIP != nil {
+		res = fmt.Sprintf("%sHasInterfaceIP: %t\n", res, *f.HasInterfaceIP)
+	}
+	if len(f.NodeIDs) != 0 {
+		res = fmt.Sprintf("%sNodeIDs: %v\n", res, f.NodeIDs)
+	}
+	if f.UpdatedAfter != nil {
+		res = fmt.Sprintf("%sUpdatedAfter: %d\n", res, *f.UpdatedAfter)
+	}
 	return res
 }
+
+func TestNodeFields(t *testing.T) {
+	database, err := db.NewPostgresDatabase(POSTGRES_HOST, POSTGRES_PORT, POSTGRES_USER, POSTGRES_PASSSWORD, POSTGRES_DB, db.DefaultNodeStatusConfig())
+	if err != nil {
+		panic(errors.Wrap(err, "failed to open db"))
+	}
+	limit := proxytypes.Limit{Page: 2, Size: 10}
+	nodes, _, err := database.GetNodes(proxytypes.NodeFilter{}, limit)
+	assert.NoError(t, err)
+
+	t.Run("fields without the node id keep the order", func(t *testing.T) {
+		selected, _, err := database.GetNodes(proxytypes.NodeFilter{}, limit, "status", "farmId")
+		assert.NoError(t, err)
+		assert.Equal(t, len(nodes), len(selected))
+		for idx := range selected {
+			assert.Equal(t, nodes[idx].FarmID, selected[idx].FarmID)
+			assert.Equal(t, nodes[idx].Status, selected[idx].Status)
+		}
+	})
+
+	t.Run("fields without the node id are served", func(t *testing.T) {
+		resp, err := http.Get(strings.TrimSuffix(ENDPOINT, "/") + "/nodes?fields=status,farmId&size=10")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
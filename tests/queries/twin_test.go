@@ -100,21 +100,38 @@ func randomTwinsFilter(agg *TwinsAggregate) proxytypes.TwinFilter {
 		}
 	}
 	if flip(.2) {
+		relay := agg.relays[rand.Intn(len(agg.relays))]
 		if f.TwinID != nil && flip(.4) {
-			relay := agg.twins[*f.TwinID].account_id
+			relay = agg.twins[*f.TwinID].relay
+		}
+		// the client doesn't send empty filters
+		if relay != "" {
 			f.Relay = &relay
-		} else {
-			c := agg.relays[rand.Intn(len(agg.relays))]
-			f.Relay = &c
 		}
 	}
 	if flip(.2) {
+		publicKey := agg.publicKeys[rand.Intn(len(agg.publicKeys))]
 		if f.TwinID != nil && flip(.4) {
-			publicKey := agg.twins[*f.TwinID].account_id
+			publicKey = agg.twins[*f.TwinID].public_key
+		}
+		if publicKey != "" {
 			f.PublicKey = &publicKey
-		} else {
-			c := agg.publicKeys[rand.Intn(len(agg.publicKeys))]
-			f.PublicKey = &c
+		}
+	}
+	if flip(.2) {
+		f.TwinIDs = randomIDs(agg.twinIDs)
+	}
+	if c := agg.relays[rand.Intn(len(agg.relays))]; flip(.2) && len(c) != 0 {
+		a, b := rand.Intn(len(c)), rand.Intn(len(c))
+		if a > b {
+			a, b = b, a
+		}
+		c = c[a : b+1]
+		f.RelayContains = &c
+	}
+	if flip(.2) {
+		for i := rand.Intn(3) + 1; i > 0; i-- {
+			f.AccountIDs = append(f.AccountIDs, agg.accountIDs[rand.Intn(len(agg.accountIDs))])
 		}
 	}
 
@@ -173,5 +190,20 @@ func serializeTwinsFilter(f proxytypes.TwinFilter) string {
 	if f.AccountID != nil {
 		res = fmt.Sprintf("%sAccountID: %s\n", res, *f.AccountID)
 	}
+	if len(f.TwinIDs) != 0 {
+		res = fmt.Sprintf("%sTwinIDs: %v\n", res, f.TwinIDs)
+	}
+	if f.Relay != nil {
+		res = fmt.Sprintf("%sRelay: %s\n", res, *f.Relay)
+	}
+	if f.PublicKey != nil {
+		res = fmt.Sprintf("%sPublicKey: %s\n", res, *f.PublicKey)
+	}
+	if f.RelayContains != nil {
+		res = fmt.Sprintf("%sRelayContains: %s\n", res, *f.RelayContains)
+	}
+	if len(f.AccountIDs) != 0 {
+		res = fmt.Sprintf("%sAccountIDs: %v\n", res, f.AccountIDs)
+	}
 	return res
 }
@@ -13,6 +13,7 @@ var (
 	SEED               int
 	STATUS_DOWN        = "down"
 	STATUS_UP          = "up"
+	STATUS_STANDBY     = "standby"
 )
 
 type contract_resources struct {
@@ -53,6 +54,8 @@ type node struct {
 	created_at        uint64
 	updated_at        uint64
 	location_id       string
+	power_state       string
+	power_target      string
 }
 type twin struct {
 	id           string
@@ -126,3 +129,19 @@ type name_contract struct {
 	state        string
 	created_at   uint64
 }
+
+type interfaces struct {
+	id      string
+	name    string
+	mac     string
+	ips     string
+	node_id string
+}
+
+type node_gpu struct {
+	id           string
+	node_twin_id uint64
+	vendor       string
+	device       string
+	contract     uint64
+}
@@ -9,6 +9,7 @@ import (
 var (
 	nodeStateFactor int64 = 3
 	reportInterval        = time.Hour
+	standbyInterval       = 36 * time.Hour
 )
 
 func calcFreeResources(total node_resources_total, used node_resources_total) node_resources_total {
@@ -37,10 +38,31 @@ func isIn(l []uint64, v uint64) bool {
 	return false
 }
 
+// isInStrs checks case insensitively if the value is in the list
+func isInStrs(l []string, v string) bool {
+	for _, i := range l {
+		if strings.EqualFold(i, v) {
+			return true
+		}
+	}
+	return false
+}
+
 func isUp(timestamp uint64) bool {
 	return int64(timestamp) > time.Now().Unix()-nodeStateFactor*int64(reportInterval.Seconds())
 }
 
+func nodeStatus(node node) string {
+	poweredOff := node.power_state == "Down" || node.power_target == "Down"
+	if poweredOff && int64(node.updated_at) >= time.Now().Add(-standbyInterval).Unix() {
+		return STATUS_STANDBY
+	}
+	if isUp(node.updated_at) {
+		return STATUS_UP
+	}
+	return STATUS_DOWN
+}
+
 func flip(success float32) bool {
 	return rand.Float32() < success
 }
@@ -68,6 +90,16 @@ func changeCase(s string) string {
 	return strings.Replace(s, string(s[idx]), strings.ToUpper(string(s[idx])), 1)
 }
 
+// accountIn checks if the account is in the list, account ids are case sensitive
+func accountIn(l []string, v string) bool {
+	for _, i := range l {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
 func stringMatch(str string, sub_str string) bool {
 	return strings.Contains(strings.ToLower(str), strings.ToLower(sub_str))
 }
@@ -22,12 +22,22 @@ var (
 	renter                 = make(map[uint64]uint64)
 	billCnt                = 1
 	contractCnt            = uint64(1)
+	gpus                   = []node_gpu{
+		{vendor: "NVIDIA Corporation", device: "GA102GL [A40]"},
+		{vendor: "NVIDIA Corporation", device: "TU104GL [Tesla T4]"},
+		{vendor: "Advanced Micro Devices, Inc. [AMD/ATI]", device: "Navi 21 [Radeon RX 6800/6800 XT / 6900 XT]"},
+	}
 )
 
 const (
 	contractCreatedRatio = .1 // from devnet
 	usedPublicIPsRatio   = .9
 	nodeUpRatio          = .5
+	nodeStandbyRatio     = .2
+	nodeGPURatio         = .1
+	nodeSecureRatio      = .3
+	nodeVirtualizedRatio = .1
+	nodeInterfaceIPRatio = .7
 	nodeCount            = 1000
 	farmCount            = 100
 	normalUsers          = 2000
@@ -100,6 +110,49 @@ func generatePublicIPs(db *sql.DB) error {
 	return nil
 }
 
+func generatePricingPolicies(db *sql.DB) error {
+	policy := pricing_policy{
+		id:                      "pricing-policy-1",
+		grid_version:            3,
+		pricing_policy_id:       1,
+		name:                    "threefold_default_pricing_policy",
+		su:                      `{"value": 50000, "unit": "Gigabytes"}`,
+		cu:                      `{"value": 100000, "unit": "Gigabytes"}`,
+		nu:                      `{"value": 15000, "unit": "Gigabytes"}`,
+		ipu:                     `{"value": 40000, "unit": "Gigabytes"}`,
+		foundation_account:      "foundation-account",
+		certified_sales_account: "certified-sales-account",
+		dedicated_node_discount: 50,
+	}
+	if _, err := db.Exec(insertQuery(&policy)); err != nil {
+		panic(err)
+	}
+	return nil
+}
+
+func generateFarmingPolicies(db *sql.DB) error {
+	policy := farming_policy{
+		id:                 "farming-policy-1",
+		grid_version:       3,
+		farming_policy_id:  1,
+		name:               "threefold_default_farming_policy",
+		cu:                 2400,
+		su:                 1000,
+		nu:                 30,
+		ipv4:               5,
+		minimal_uptime:     95,
+		policy_created:     0,
+		policy_end:         0,
+		immutable:          false,
+		node_certification: "Diy",
+		farm_certification: "NotCertified",
+	}
+	if _, err := db.Exec(insertQuery(&policy)); err != nil {
+		panic(err)
+	}
+	return nil
+}
+
 func generateFarms(db *sql.DB) error {
 	for i := uint64(1); i <= farmCount; i++ {
 		farm := farm{
@@ -309,10 +362,20 @@ func generateNodes(db *sql.DB) error {
 		sru := rnd(200, 30*1024) * 1024 * 1024 * 1024 // 100GB -> 30TB
 		cru := rnd(4, 128)
 		up := flip(nodeUpRatio)
+		standby := !up && flip(nodeStandbyRatio)
 		updatedAt := time.Now().Unix() - int64(rnd(60*60*3, 60*60*24*30*12))
 		if up {
 			updatedAt = time.Now().Unix() - int64(rnd(0, 60*60*1))
 		}
+		power := `{"state": "Up", "target": "Up"}`
+		if standby {
+			updatedAt = time.Now().Unix() - int64(rnd(60*60*1, 60*60*24))
+			power = `{"state": "Down", "target": "Down"}`
+		}
+		gridVersion := uint64(3)
+		if flip(.1) {
+			gridVersion = 2
+		}
 		nodesMRU[i] = mru - max(2*uint64(gridtypes.Gigabyte), mru/10)
 		nodesSRU[i] = sru - 100*uint64(gridtypes.Gigabyte)
 		nodesHRU[i] = hru
@@ -335,11 +398,12 @@ func generateNodes(db *sql.DB) error {
 			created:           uint64(time.Now().Unix()),
 			created_at:        uint64(time.Now().Unix()),
 			farming_policy_id: 1,
-			grid_version:      3,
+			grid_version:      gridVersion,
 			certification:     "Diy",
-			secure:            false,
-			virtualized:       false,
+			secure:            flip(nodeSecureRatio),
+			virtualized:       flip(nodeVirtualizedRatio),
 			serial_number:     "",
+			power:             power,
 		}
 		total_resources := node_resources_total{
 			id:      fmt.Sprintf("total-resources-%d", i),
@@ -376,6 +440,34 @@ func generateNodes(db *sql.DB) error {
 				panic(err)
 			}
 		}
+		for j := uint64(1); j <= rnd(1, 2); j++ {
+			ips := ""
+			if flip(nodeInterfaceIPRatio) {
+				ips = fmt.Sprintf("10.%d.%d.%d", j, i/250, i%250+1)
+			}
+			if _, err := db.Exec(insertQuery(&interfaces{
+				id:      fmt.Sprintf("interface-%d-%d", i, j),
+				name:    fmt.Sprintf("eth%d", j-1),
+				mac:     fmt.Sprintf("00:00:00:%02x:%02x:%02x", j, i/256, i%256),
+				ips:     ips,
+				node_id: fmt.Sprintf("node-%d", i),
+			})); err != nil {
+				panic(err)
+			}
+		}
+		if flip(nodeGPURatio) {
+			for j := uint64(1); j <= rnd(1, 2); j++ {
+				gpu := gpus[rnd(0, uint64(len(gpus)-1))]
+				gpu.id = fmt.Sprintf("gpu-%d-%d", i, j)
+				gpu.node_twin_id = node.twin_id
+				if flip(.3) {
+					gpu.contract = rnd(1, contractCount)
+				}
+				if _, err := db.Exec(insertQuery(&gpu)); err != nil {
+					panic(err)
+				}
+			}
+		}
 	}
 	return nil
 }
@@ -384,6 +476,12 @@ func generateData(db *sql.DB) error {
 	if err := generateTwins(db); err != nil {
 		panic(err)
 	}
+	if err := generatePricingPolicies(db); err != nil {
+		panic(err)
+	}
+	if err := generateFarmingPolicies(db); err != nil {
+		panic(err)
+	}
 	if err := generateFarms(db); err != nil {
 		panic(err)
 	}
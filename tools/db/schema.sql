@@ -328,7 +328,8 @@ CREATE TABLE public.node (
     updated_at numeric NOT NULL,
     location_id character varying NOT NULL,
     certification character varying(9),
-    connection_price integer
+    connection_price integer,
+    power jsonb
 );
 
 
@@ -355,6 +356,21 @@ CREATE TABLE public.node_contract (
 
 ALTER TABLE public.node_contract OWNER TO postgres;
 
+--
+-- Name: node_gpu; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.node_gpu (
+    id character varying NOT NULL,
+    node_twin_id integer NOT NULL,
+    vendor text NOT NULL,
+    device text NOT NULL,
+    contract integer
+);
+
+
+ALTER TABLE public.node_gpu OWNER TO postgres;
+
 --
 -- Name: node_resources_free; Type: TABLE; Schema: public; Owner: postgres
 --
@@ -849,6 +865,14 @@ ALTER TABLE ONLY public.transfer
     ADD CONSTRAINT "PK_fd9ddbdd49a17afcbe014401295" PRIMARY KEY (id);
 
 
+--
+-- Name: node_gpu PK_node_gpu; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.node_gpu
+    ADD CONSTRAINT "PK_node_gpu" PRIMARY KEY (id);
+
+
 --
 -- Name: node_resources_used REL_75870a8ed1c14efd1dd4ef4792; Type: CONSTRAINT; Schema: public; Owner: postgres
 --
@@ -959,6 +983,13 @@ CREATE UNIQUE INDEX "IDX_d394b8b9afbb1b1a2346f9743c" ON public.public_config USI
 CREATE INDEX "IDX_f294cfb50bb7c7b976d86c08fd" ON public.node_contract USING btree (resources_used_id);
 
 
+--
+-- Name: IDX_node_gpu_node_twin_id; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX "IDX_node_gpu_node_twin_id" ON public.node_gpu USING btree (node_twin_id);
+
+
 --
 -- Name: IDX_fd430c3a2645c8f409f859c2aa; Type: INDEX; Schema: public; Owner: postgres
 --
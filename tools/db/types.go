@@ -38,6 +38,7 @@ type node struct {
 	created_at        uint64
 	updated_at        uint64
 	location_id       string
+	power             string
 }
 type twin struct {
 	id           string
@@ -116,3 +117,50 @@ type name_contract struct {
 	state        string
 	created_at   uint64
 }
+
+type node_gpu struct {
+	id           string
+	node_twin_id uint64
+	vendor       string
+	device       string
+	contract     uint64
+}
+
+type pricing_policy struct {
+	id                      string
+	grid_version            uint64
+	pricing_policy_id       uint64
+	name                    string
+	su                      string
+	cu                      string
+	nu                      string
+	ipu                     string
+	foundation_account      string
+	certified_sales_account string
+	dedicated_node_discount uint64
+}
+
+type farming_policy struct {
+	id                 string
+	grid_version       uint64
+	farming_policy_id  uint64
+	name               string
+	cu                 uint64
+	su                 uint64
+	nu                 uint64
+	ipv4               uint64
+	minimal_uptime     uint64
+	policy_created     uint64
+	policy_end         uint64
+	immutable          bool
+	node_certification string
+	farm_certification string
+}
+
+type interfaces struct {
+	id      string
+	name    string
+	mac     string
+	ips     string
+	node_id string
+}